@@ -1,679 +0,0 @@
-package main
-
-import (
-	"encoding/json"
-	"fmt"
-	"os"
-	"path/filepath"
-	"sort"
-	"strings"
-	"time"
-
-	"github.com/charmbracelet/glamour"
-	"github.com/charmbracelet/lipgloss"
-)
-
-// Theme defines the color scheme for console output
-type Theme struct {
-	Score    lipgloss.Style
-	Hash     lipgloss.Style
-	Location lipgloss.Style
-	LineNum  lipgloss.Style
-	Summary  lipgloss.Style
-	Dim      lipgloss.Style
-}
-
-// One Dark palette (Atom One)
-const (
-	colorBranch = "#5C6370" // comment gray
-	colorGreen  = "#98C379" // green
-	colorPurple = "#C678DD" // purple
-	colorBlue   = "#61AFEF" // blue
-	colorText   = "#ABB2BF" // light gray
-	colorRed    = "#E06C75" // red
-	colorYellow = "#E5C07B" // yellow
-)
-
-// DefaultTheme is the default color scheme
-var DefaultTheme = Theme{
-	Score:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(colorYellow)),
-	Hash:     lipgloss.NewStyle().Foreground(lipgloss.Color(colorBranch)),
-	Location: lipgloss.NewStyle().Foreground(lipgloss.Color(colorBlue)),
-	LineNum:  lipgloss.NewStyle().Foreground(lipgloss.Color(colorPurple)),
-	Summary:  lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(colorGreen)),
-	Dim:      lipgloss.NewStyle().Foreground(lipgloss.Color(colorBranch)),
-}
-
-// Current theme (can be changed at runtime)
-var theme = DefaultTheme
-
-// Similarity color styles
-var (
-	simGreen       = lipgloss.NewStyle().Foreground(lipgloss.Color(colorGreen))  // 95%+
-	simYellowGreen = lipgloss.NewStyle().Foreground(lipgloss.Color(colorBlue))   // 85%+
-	simOrange      = lipgloss.NewStyle().Foreground(lipgloss.Color(colorPurple)) // 75%+
-	simRed         = lipgloss.NewStyle().Foreground(lipgloss.Color(colorRed))    // 60%+
-	simDim         = lipgloss.NewStyle().Foreground(lipgloss.Color(colorBranch)) // below 60%
-)
-
-// renderSimilarity returns a colorized similarity string
-func renderSimilarity(similarity float64) string {
-	pct := similarity * 100
-	text := fmt.Sprintf("%.0f%% similar", pct)
-	switch {
-	case pct >= 95:
-		return simGreen.Render(text)
-	case pct >= 85:
-		return simYellowGreen.Render(text)
-	case pct >= 75:
-		return simOrange.Render(text)
-	case pct >= 60:
-		return simRed.Render(text)
-	default:
-		return simDim.Render(text)
-	}
-}
-
-// PrintScanStart prints the initial scanning message
-func PrintScanStart(fileCount, workerCount int) {
-	fmt.Printf("Scanning %d files using %d workers...\n", fileCount, workerCount)
-}
-
-// PrintParseComplete prints parsing completion stats
-func PrintParseComplete(fileCount, cacheHits, cacheMisses, totalLines int, duration time.Duration) {
-	if cacheHits > 0 {
-		fmt.Printf("Parsed %d files (%d cached, %d parsed) in %s (%d lines of code)\n",
-			fileCount, cacheHits, cacheMisses, duration.Round(time.Millisecond), totalLines)
-	} else {
-		fmt.Printf("Parsed %d files in %s (%d lines of code)\n",
-			fileCount, duration.Round(time.Millisecond), totalLines)
-	}
-}
-
-// PrintDetectStart prints pattern detection start message
-func PrintDetectStart() {
-	fmt.Printf("Detecting patterns...\n")
-}
-
-// PrintDetectComplete prints pattern detection completion
-func PrintDetectComplete(duration time.Duration) {
-	fmt.Printf("Pattern detection took %s\n", duration.Round(time.Millisecond))
-}
-
-// PrintFilterComplete prints filtering completion and stats
-func PrintFilterComplete(duration time.Duration, skippedBlocked, skippedLowScore, skippedLowSimilarity int, minScore int, minSimilarity float64) {
-	fmt.Printf("Filtering took %s\n", duration.Round(time.Millisecond))
-
-	if skippedBlocked > 0 {
-		fmt.Printf("Filtered %d common patterns\n", skippedBlocked)
-	}
-	if skippedLowScore > 0 {
-		fmt.Printf("Filtered %d low-score patterns (score < %d)\n", skippedLowScore, minScore)
-	}
-	if skippedLowSimilarity > 0 {
-		fmt.Printf("Filtered %d low-similarity patterns (similarity < %.0f%%)\n", skippedLowSimilarity, minSimilarity*100)
-	}
-}
-
-// PrintIgnoredPatterns prints count of loaded ignored patterns
-func PrintIgnoredPatterns(count int) {
-	if count > 0 {
-		fmt.Printf("Loaded %d ignored patterns from ignore.json\n", count)
-	}
-}
-
-// PrintGitHubAnnotations outputs GitHub Actions annotations for matches
-func PrintGitHubAnnotations(matches []PatternMatch, top int, githubLevel string, gitDiff string, changedFiles map[string]bool) {
-	annotationCount := 0
-	for _, m := range matches[:top] {
-		loc := m.Locations[0]
-		// Skip if --git-diff is set and file is not in changed files
-		if gitDiff != "" && !changedFiles[loc.Filename] {
-			continue
-		}
-		otherLocs := make([]string, 0, len(m.Locations)-1)
-		for _, other := range m.Locations[1:] {
-			otherLocs = append(otherLocs, fmt.Sprintf("%s:%d", filepath.Base(other.Filename), other.LineStart))
-		}
-		endLine := loc.LineStart + len(m.Pattern) - 1
-		msg := fmt.Sprintf("Duplicate code also at: %s", strings.Join(otherLocs, ", "))
-		fmt.Printf("::%s file=%s,line=%d,endLine=%d,title=Duplicate (%d lines, %.0f%% similar, score %d)::%s\n",
-			githubLevel, loc.Filename, loc.LineStart, endLine, len(m.Pattern), m.Similarity*100, m.Score, msg)
-		annotationCount++
-	}
-	if annotationCount > 0 {
-		fmt.Printf("\n")
-	}
-}
-
-// PrintMatchSummary prints the summary of found patterns
-func PrintMatchSummary(matchCount, minOccur, top int) {
-	fmt.Printf("Found %s patterns with %d+ occurrences (showing top %d by score)\n\n",
-		theme.Summary.Render(fmt.Sprintf("%d", matchCount)), minOccur, top)
-}
-
-// PrintMatches prints the top matches with their locations
-func PrintMatches(matches []PatternMatch, top int) {
-	for i, m := range matches[:top] {
-		fmt.Printf("\n%s  %s  %s  %s  %s  %s\n",
-			theme.Summary.Render(fmt.Sprintf("Pattern %d", i+1)),
-			theme.Hash.Render(fmt.Sprintf("[%016x]", m.Hash)),
-			theme.Score.Render(fmt.Sprintf("Score %d", m.Score)),
-			renderSimilarity(m.Similarity),
-			theme.Dim.Render(fmt.Sprintf("%d lines", len(m.Pattern))),
-			theme.Dim.Render(fmt.Sprintf("%d occurrences", len(m.Locations))))
-		for _, loc := range m.Locations {
-			fmt.Printf("  %s%s%s\n",
-				theme.Location.Render(loc.Filename),
-				theme.Dim.Render(":"),
-				theme.LineNum.Render(fmt.Sprintf("%d", loc.LineStart)))
-		}
-	}
-}
-
-// PrintHotspots prints the duplication hotspots
-func PrintHotspots(matches []PatternMatch) {
-	// Count duplicated lines per file
-	fileDupLines := make(map[string]int)
-	for _, m := range matches {
-		patternLen := len(m.Pattern)
-		for _, loc := range m.Locations {
-			fileDupLines[loc.Filename] += patternLen
-		}
-	}
-
-	// Sort files by duplicated line count
-	type fileHotspot struct {
-		filename string
-		lines    int
-	}
-	var hotspots []fileHotspot
-	for f, lines := range fileDupLines {
-		hotspots = append(hotspots, fileHotspot{f, lines})
-	}
-	sort.Slice(hotspots, func(i, j int) bool {
-		return hotspots[i].lines > hotspots[j].lines
-	})
-
-	// Show top 5 hotspots
-	if len(hotspots) > 0 {
-		fmt.Printf("\n%s\n", theme.Summary.Render("Duplication hotspots (lines):"))
-		showHotspots := 5
-		if len(hotspots) < showHotspots {
-			showHotspots = len(hotspots)
-		}
-		for i := 0; i < showHotspots; i++ {
-			fmt.Printf("  %s %s\n",
-				theme.LineNum.Render(fmt.Sprintf("%4d", hotspots[i].lines)),
-				theme.Location.Render(hotspots[i].filename))
-		}
-	}
-}
-
-// PrintTotalSummary prints the final summary line
-func PrintTotalSummary(matchCount, fileCount, totalLines int, elapsed time.Duration) {
-	fmt.Printf("\nTotal: %s duplicate patterns in %s files (%s lines) in %s\n",
-		theme.Summary.Render(fmt.Sprintf("%d", matchCount)),
-		theme.Summary.Render(fmt.Sprintf("%d", fileCount)),
-		theme.Summary.Render(fmt.Sprintf("%d", totalLines)),
-		theme.Summary.Render(elapsed.Round(time.Millisecond).String()))
-	fmt.Printf("\n%s\n", theme.Dim.Render("Tip: Even partial matches may contain extractable sub-sections. Look for common logic that could be refactored into shared helpers, base classes, modules or using generics functuins / types where supported."))
-}
-
-// PrintShowingPatterns prints the footer showing selected patterns range
-func PrintShowingPatterns(skip, limit int) {
-	fmt.Printf("Showing pattern %s to %s\n",
-		theme.Summary.Render(fmt.Sprintf("%d", skip)),
-		theme.Summary.Render(fmt.Sprintf("%d", limit)))
-}
-
-// langFromExt maps file extensions to markdown code block language hints
-var langFromExt = map[string]string{
-	".go":    "go",
-	".c":     "c",
-	".h":     "c",
-	".cpp":   "cpp",
-	".hpp":   "cpp",
-	".cc":    "cpp",
-	".cxx":   "cpp",
-	".java":  "java",
-	".js":    "javascript",
-	".jsx":   "jsx",
-	".ts":    "typescript",
-	".tsx":   "tsx",
-	".cs":    "csharp",
-	".swift": "swift",
-	".kt":    "kotlin",
-	".kts":   "kotlin",
-	".scala": "scala",
-	".rs":    "rust",
-	".php":   "php",
-	".py":    "python",
-	".rb":    "ruby",
-	".sh":    "bash",
-	".bash":  "bash",
-	".zsh":   "zsh",
-	".sql":   "sql",
-	".lua":   "lua",
-	".hs":    "haskell",
-	".elm":   "elm",
-	".yaml":  "yaml",
-	".yml":   "yaml",
-	".toml":  "toml",
-	".json":  "json",
-	".xml":   "xml",
-	".html":  "html",
-	".css":   "css",
-	".scss":  "scss",
-	".dart":  "dart",
-	".r":     "r",
-	".R":     "r",
-	".jl":    "julia",
-	".ex":    "elixir",
-	".exs":   "elixir",
-	".clj":   "clojure",
-	".cljs":  "clojure",
-	".v":     "v",
-	".zig":   "zig",
-	".nim":   "nim",
-}
-
-// normalizeIndent removes common leading whitespace from lines
-func normalizeIndent(entries []Entry) []string {
-	if len(entries) == 0 {
-		return nil
-	}
-
-	// Find minimum indent across all non-empty lines
-	minIndent := -1
-	for _, e := range entries {
-		line := e.GetRaw()
-		indent := 0
-		for _, r := range line {
-			if r == ' ' {
-				indent++
-			} else if r == '\t' {
-				indent += 4
-			} else {
-				break
-			}
-		}
-		if minIndent < 0 || indent < minIndent {
-			minIndent = indent
-		}
-	}
-
-	// Strip minimum indent from each line
-	result := make([]string, len(entries))
-	for i, e := range entries {
-		line := e.GetRaw()
-		stripped := 0
-		start := 0
-		for j, r := range line {
-			if stripped >= minIndent {
-				start = j
-				break
-			}
-			if r == ' ' {
-				stripped++
-			} else if r == '\t' {
-				stripped += 4
-			} else {
-				start = j
-				break
-			}
-			start = j + 1
-		}
-		result[i] = line[start:]
-	}
-
-	return result
-}
-
-// PrintDetailedMatches prints detailed pattern matches with source code using glow
-func PrintDetailedMatches(matches []PatternMatch, ext string) {
-	lang := langFromExt[ext]
-	if lang == "" {
-		lang = strings.TrimPrefix(ext, ".")
-	}
-
-	// Group matches by hash to detect multiple clusters
-	hashCounts := make(map[uint64]int)
-	for _, m := range matches {
-		hashCounts[m.Hash]++
-	}
-
-	// Track cluster number per hash
-	hashClusterNum := make(map[uint64]int)
-
-	for i, m := range matches {
-		// Determine if this hash has multiple clusters
-		clusterInfo := ""
-		if hashCounts[m.Hash] > 1 {
-			hashClusterNum[m.Hash]++
-			clusterInfo = fmt.Sprintf(" (Cluster %d/%d)", hashClusterNum[m.Hash], hashCounts[m.Hash])
-		}
-
-		// Print header with colorized similarity
-		fmt.Printf("\n%s%s  %s  %s  %s  %s  %s\n",
-			theme.Summary.Render(fmt.Sprintf("Pattern %d", i+1)),
-			theme.Dim.Render(clusterInfo),
-			theme.Hash.Render(fmt.Sprintf("[%016x]", m.Hash)),
-			theme.Score.Render(fmt.Sprintf("Score %d", m.Score)),
-			renderSimilarity(m.Similarity),
-			theme.Dim.Render(fmt.Sprintf("%d lines", len(m.Pattern))),
-			theme.Dim.Render(fmt.Sprintf("%d occurrences", len(m.Locations))))
-
-		// Render each occurrence with styled header + code block
-		for j, loc := range m.Locations {
-			fmt.Printf("\n  %s %s\n",
-				theme.LineNum.Render(fmt.Sprintf("Occurrence %d", j+1)),
-				theme.Location.Render(fmt.Sprintf("%s:%d", loc.Filename, loc.LineStart)))
-
-			var sb strings.Builder
-			langLocal := langFromExt[strings.ToLower(filepath.Ext(loc.Filename))]
-			sb.WriteString(fmt.Sprintf("```%s\n", langLocal))
-			normalizedLines := normalizeIndent(loc.Pattern)
-			for _, line := range normalizedLines {
-				sb.WriteString(line + "\n")
-			}
-			sb.WriteString("```\n")
-			renderWithGlow(sb.String())
-		}
-		fmt.Println(theme.Dim.Render("───────────────────────────────────────────────────────────────────────────────"))
-	}
-}
-
-// renderWithGlow pipes markdown content through glow for rendering
-const glowOneDarkJSON = `{
-  "document": { "color": "#ABB2BF", "backgroundColor": "#282C34" },
-  "blockQuote": { "color": "#5C6370", "italic": true },
-  "heading": { "color": "#61AFEF", "bold": true },
-  "h1": { "color": "#61AFEF", "bold": true },
-  "h2": { "color": "#E5C07B", "bold": true },
-  "h3": { "color": "#98C379", "bold": true },
-  "strong": { "color": "#E5C07B", "bold": true },
-  "emph": { "color": "#E06C75", "italic": true },
-  "link": { "color": "#61AFEF", "underline": true },
-  "item": { "color": "#ABB2BF" },
-  "table": { "header": { "color": "#61AFEF", "bold": true }, "row": { "color": "#ABB2BF" }, "cellPadding": 1 },
-  "horizontalRule": { "format": "─" },
-  "chroma": { "style": "dracula" }
-}`
-
-const glamOneDark = `{
-  "document": {
-    "block_prefix": "",
-    "block_suffix": "",
-    "color": "#ABB2BF",
-    "margin": 2
-  },
-  "block_quote": {
-    "indent": 1,
-    "indent_token": "│ ",
-    "color": "#5C6370"
-  },
-  "paragraph": {},
-  "list": {
-    "color": "#ABB2BF",
-    "level_indent": 2
-  },
-  "heading": {
-    "block_suffix": "\n",
-    "color": "#C678DD",
-    "bold": true
-  },
-  "h1": { "prefix": "# ", "bold": true },
-  "h2": { "prefix": "## " },
-  "h3": { "prefix": "### " },
-  "text": {},
-  "strikethrough": { "crossed_out": true },
-  "emph": { "italic": true, "color": "#E06C75" },
-  "strong": { "bold": true, "color": "#E5C07B" },
-  "hr": { "color": "#5C6370", "format": "\n────────\n" },
-  "item": { "block_prefix": "• ", "color": "#98C379" },
-  "enumeration": { "block_prefix": ". ", "color": "#61AFEF" },
-  "link": { "color": "#61AFEF", "underline": true },
-  "link_text": { "color": "#56B6C2" },
-  "code": { "color": "#61AFEF" },
-  "code_block": {
-    "color": "#ABB2BF",
-    "margin": 2,
-    "chroma": {
-      "text": { "color": "#ABB2BF" },
-      "error": { "color": "#ABB2BF", "background_color": "#E06C75" },
-      "comment": { "color": "#5C6370" },
-      "comment_preproc": { "color": "#C678DD" },
-      "keyword": { "color": "#C678DD" },
-      "keyword_reserved": { "color": "#C678DD" },
-      "keyword_namespace": { "color": "#C678DD" },
-      "keyword_type": { "color": "#E5C07B" },
-      "operator": { "color": "#56B6C2" },
-      "punctuation": { "color": "#ABB2BF" },
-      "name": { "color": "#ABB2BF" },
-      "name_builtin": { "color": "#56B6C2" },
-      "name_tag": { "color": "#E06C75" },
-      "name_attribute": { "color": "#E5C07B" },
-      "name_class": { "color": "#E5C07B" },
-      "name_constant": { "color": "#D19A66" },
-      "name_decorator": { "color": "#E5C07B" },
-      "name_exception": {},
-      "name_function": { "color": "#61AFEF" },
-      "name_other": {},
-      "literal": {},
-      "literal_number": { "color": "#D19A66" },
-      "literal_date": {},
-      "literal_string": { "color": "#98C379" },
-      "literal_string_escape": { "color": "#56B6C2" },
-      "generic_deleted": { "color": "#E06C75" },
-      "generic_emph": { "italic": true },
-      "generic_inserted": { "color": "#98C379" },
-      "generic_strong": { "bold": true },
-      "generic_subheading": { "color": "#C678DD" }
-    }
-  },
-  "table": {},
-  "definition_list": {},
-  "definition_term": {},
-  "definition_description": { "block_prefix": "\n→ " }
-}`
-
-func renderWithGlow(markdown string) {
-	r, err := glamour.NewTermRenderer(
-		glamour.WithStylesFromJSONBytes([]byte(glamOneDark)),
-		glamour.WithWordWrap(0),
-	)
-	if err != nil {
-		fmt.Print(markdown)
-		return
-	}
-	out, err := r.Render(markdown)
-	if err != nil {
-		fmt.Print(markdown)
-		return
-	}
-	fmt.Print(out)
-}
-
-// ReadJSONResults reads results from a JSON file
-func ReadJSONResults(path string) ([]JSONPattern, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	var output JSONOutput
-	if err := json.Unmarshal(data, &output); err != nil {
-		return nil, err
-	}
-
-	return output.Patterns, nil
-}
-
-// PrintDetailedMatchesFromJSON prints detailed pattern matches from JSON results
-func PrintDetailedMatchesFromJSON(patterns []JSONPattern, ext string) {
-	lang := langFromExt[ext]
-	if lang == "" {
-		lang = strings.TrimPrefix(ext, ".")
-	}
-
-	// Group patterns by hash to detect multiple clusters
-	hashCounts := make(map[string]int)
-	for _, p := range patterns {
-		hashCounts[p.Hash]++
-	}
-
-	// Track cluster number per hash
-	hashClusterNum := make(map[string]int)
-
-	for i, p := range patterns {
-		// Determine if this hash has multiple clusters
-		clusterInfo := ""
-		if hashCounts[p.Hash] > 1 {
-			hashClusterNum[p.Hash]++
-			clusterInfo = fmt.Sprintf(" (Cluster %d/%d)", hashClusterNum[p.Hash], hashCounts[p.Hash])
-		}
-
-		// Print header with colorized similarity
-		fmt.Printf("\n%s%s  %s  %s  %s  %s  %s\n",
-			theme.Summary.Render(fmt.Sprintf("Pattern %d", i+1)),
-			theme.Dim.Render(clusterInfo),
-			theme.Hash.Render(fmt.Sprintf("[%s]", p.Hash)),
-			theme.Score.Render(fmt.Sprintf("Score %d", p.Score)),
-			renderSimilarity(p.Similarity),
-			theme.Dim.Render(fmt.Sprintf("%d lines", p.Lines)),
-			theme.Dim.Render(fmt.Sprintf("%d occurrences", p.Occurrences)))
-
-		// Render each occurrence with styled header + code block
-		for j, loc := range p.Locations {
-			fmt.Printf("\n  %s %s\n",
-				theme.LineNum.Render(fmt.Sprintf("Occurrence %d", j+1)),
-				theme.Location.Render(fmt.Sprintf("%s:%d", loc.Filename, loc.LineStart)))
-
-			// Read source lines from file
-			lines := readSourceLines(loc.Filename, loc.LineStart, p.Lines)
-			var sb strings.Builder
-			langLocal := langFromExt[strings.ToLower(filepath.Ext(loc.Filename))]
-			sb.WriteString(fmt.Sprintf("```%s\n", langLocal))
-			for _, line := range lines {
-				sb.WriteString(line + "\n")
-			}
-			sb.WriteString("```\n")
-			renderWithGlow(sb.String())
-		}
-		fmt.Println(theme.Dim.Render("───────────────────────────────────────────────────────────────────────────────"))
-	}
-}
-
-// readSourceLines reads specific lines from a file and normalizes indent
-func readSourceLines(filename string, startLine, count int) []string {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return []string{fmt.Sprintf("// Error reading file: %v", err)}
-	}
-
-	allLines := strings.Split(string(data), "\n")
-	var result []string
-
-	// Find minimum indent for normalization
-	minIndent := -1
-	for i := startLine - 1; i < startLine-1+count && i < len(allLines); i++ {
-		if i < 0 {
-			continue
-		}
-		line := allLines[i]
-		indent := 0
-		for _, r := range line {
-			if r == ' ' {
-				indent++
-			} else if r == '\t' {
-				indent += 4
-			} else {
-				break
-			}
-		}
-		if len(strings.TrimSpace(line)) > 0 && (minIndent < 0 || indent < minIndent) {
-			minIndent = indent
-		}
-	}
-	if minIndent < 0 {
-		minIndent = 0
-	}
-
-	// Extract and normalize lines
-	for i := startLine - 1; i < startLine-1+count && i < len(allLines); i++ {
-		if i < 0 {
-			continue
-		}
-		line := allLines[i]
-		// Strip minimum indent
-		stripped := 0
-		start := 0
-		for j, r := range line {
-			if stripped >= minIndent {
-				start = j
-				break
-			}
-			if r == ' ' {
-				stripped++
-			} else if r == '\t' {
-				stripped += 4
-			} else {
-				start = j
-				break
-			}
-			start = j + 1
-		}
-		result = append(result, line[start:])
-	}
-
-	return result
-}
-
-// WriteJSONResults writes the results to a JSON file
-func WriteJSONResults(matches []PatternMatch, outputPath string) error {
-	jsonOutput := JSONOutput{
-		TotalPatterns: len(matches),
-		Patterns:      make([]JSONPattern, 0, len(matches)),
-	}
-
-	for _, m := range matches {
-		locs := make([]JSONLocation, len(m.Locations))
-		for i, loc := range m.Locations {
-			locs[i] = JSONLocation{
-				Filename:  loc.Filename,
-				LineStart: loc.LineStart,
-			}
-		}
-
-		jsonOutput.Patterns = append(jsonOutput.Patterns, JSONPattern{
-			Hash:        fmt.Sprintf("%016x", m.Hash),
-			Score:       m.Score,
-			Lines:       len(m.Pattern),
-			Similarity:  m.Similarity,
-			Occurrences: len(m.Locations),
-			Locations:   locs,
-		})
-	}
-
-	// Create output directory
-	outputDir := filepath.Dir(outputPath)
-	if err := os.MkdirAll(outputDir, 0o755); err != nil {
-		return fmt.Errorf("creating output directory: %w", err)
-	}
-
-	jsonData, err := json.MarshalIndent(jsonOutput, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshaling JSON: %w", err)
-	}
-
-	if err := os.WriteFile(outputPath, jsonData, 0o644); err != nil {
-		return fmt.Errorf("writing JSON file: %w", err)
-	}
-	return nil
-}
-
-// PrintResultsPath prints the path to the results file
-func PrintResultsPath(outputPath string) {
-	fmt.Printf("Results written to: %s\n", theme.Location.Render(outputPath))
-}
@@ -0,0 +1,224 @@
+package engine
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// AccessorClass is a class made up largely of boilerplate getters and setters, a
+// candidate for a record, Lombok or auto-properties (--accessors)
+type AccessorClass struct {
+	Filename string `json:"filename"`
+	Class    string `json:"class"`
+	Line     int    `json:"line"`    // line of the class declaration
+	Getters  int    `json:"getters"` // one-line get/is methods
+	Setters  int    `json:"setters"` // one-line set methods
+	Pairs    int    `json:"pairs"`   // properties with both a getter and a setter
+}
+
+// Accessors returns the total number of accessors in the class
+func (c AccessorClass) Accessors() int {
+	return c.Getters + c.Setters
+}
+
+// findAccessorClasses finds classes with at least minAccessors one-line getters and
+// setters, most accessors first. The inlineable strategy flags each such one-liner
+// as a duplicate; this reports the class they add up to. Unreadable files are
+// recorded as warnings.
+func findAccessorClasses(files []string, minAccessors int, warnings *Warnings) []AccessorClass {
+	var classes []AccessorClass
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			warnings.Add("accessors", path, err)
+			continue
+		}
+		for _, class := range accessorClasses(path, cStyleStripper.Preparse(string(content))) {
+			if class.Accessors() >= minAccessors {
+				classes = append(classes, class)
+			}
+		}
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		if classes[i].Accessors() != classes[j].Accessors() {
+			return classes[i].Accessors() > classes[j].Accessors()
+		}
+		if classes[i].Filename != classes[j].Filename {
+			return classes[i].Filename < classes[j].Filename
+		}
+		return classes[i].Line < classes[j].Line
+	})
+	return classes
+}
+
+// accessorClasses tallies the accessors of each class in a file. Accessors count
+// toward the class declared most recently above them, so members of a nested class
+// declared before its outer class's accessors are attributed to the nested one.
+func accessorClasses(path, content string) []AccessorClass {
+	lines := strings.Split(content, "\n")
+	var classes []AccessorClass
+	var getters, setters map[string]bool
+	flush := func() {
+		if len(classes) == 0 {
+			return
+		}
+		c := &classes[len(classes)-1]
+		for property := range getters {
+			if setters[property] {
+				c.Pairs++
+			}
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		if name, ok := classDeclaration(lines[i]); ok {
+			flush()
+			classes = append(classes, AccessorClass{Filename: path, Class: name, Line: i + 1})
+			getters, setters = make(map[string]bool), make(map[string]bool)
+			continue
+		}
+		if len(classes) == 0 {
+			continue
+		}
+		kind, property, ok := accessor(lines, i)
+		if !ok {
+			continue
+		}
+		c := &classes[len(classes)-1]
+		if kind == "set" {
+			c.Setters++
+			setters[property] = true
+		} else {
+			c.Getters++
+			getters[property] = true
+		}
+	}
+	flush()
+	return classes
+}
+
+// classDeclaration returns the class name of a `... class Name ...` line
+func classDeclaration(line string) (string, bool) {
+	fields := strings.Fields(line)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "class" {
+			name := strings.TrimFunc(fields[i+1], func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' })
+			if end := strings.IndexAny(name, "<({:"); end >= 0 {
+				name = name[:end]
+			}
+			return name, name != ""
+		}
+	}
+	return "", false
+}
+
+// accessor reports whether the method declared on lines[i] is a getter or setter:
+// an access modifier first, a get/set/is name and a single-statement body, on the
+// same line, the next line, or between braces on the lines after. kind is "get" or
+// "set"; property is the name without its prefix.
+func accessor(lines []string, i int) (kind, property string, ok bool) {
+	line := strings.TrimSpace(lines[i])
+	if !accessModifiers[firstWord(line, false)] {
+		return "", "", false
+	}
+	open := strings.Index(line, "(")
+	if open < 0 {
+		return "", "", false
+	}
+	fields := strings.Fields(line[:open])
+	if len(fields) == 0 {
+		return "", "", false
+	}
+	kind, property, ok = accessorName(fields[len(fields)-1])
+	if !ok {
+		return "", "", false
+	}
+
+	body, ok := singleStatementBody(lines, i, line[open:])
+	if !ok {
+		return "", "", false
+	}
+	params := line[open:]
+	if kind == "set" {
+		ok = strings.Contains(body, "=") && !strings.Contains(body, "==")
+	} else {
+		ok = strings.HasPrefix(params, "()") && (strings.HasPrefix(body, "return ") || strings.Contains(params, "=>"))
+	}
+	return kind, property, ok
+}
+
+// accessorName splits getName/setName/isName into its kind and property
+func accessorName(name string) (kind, property string, ok bool) {
+	for _, prefix := range []string{"get", "set", "is"} {
+		rest, found := strings.CutPrefix(name, prefix)
+		if !found {
+			// C# style: GetName, SetName
+			rest, found = strings.CutPrefix(name, strings.ToUpper(prefix[:1])+prefix[1:])
+		}
+		if found && rest != "" && unicode.IsUpper(rune(rest[0])) {
+			if prefix == "is" {
+				prefix = "get"
+			}
+			return prefix, rest, true
+		}
+	}
+	return "", "", false
+}
+
+// singleStatementBody returns the one statement in a method body, given the rest of
+// the declaration line from its parameter list
+func singleStatementBody(lines []string, i int, rest string) (string, bool) {
+	if _, expr, found := strings.Cut(rest, "=>"); found {
+		return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(expr), ";")), true
+	}
+	if brace := strings.Index(rest, "{"); brace >= 0 {
+		inline := strings.TrimSpace(rest[brace+1:])
+		if inline != "" {
+			// { statement; } on the declaration line
+			body, found := strings.CutSuffix(inline, "}")
+			return statement(body), found && isStatement(body)
+		}
+		return bodyLines(lines, i+1)
+	}
+	// Brace on the next line (C#/Allman)
+	if next := nextCodeLine(lines, i+1); next >= 0 && strings.TrimSpace(lines[next]) == "{" {
+		return bodyLines(lines, next+1)
+	}
+	return "", false
+}
+
+// bodyLines returns the statement of a body that is one line followed by a lone `}`
+func bodyLines(lines []string, from int) (string, bool) {
+	body := nextCodeLine(lines, from)
+	if body < 0 {
+		return "", false
+	}
+	end := nextCodeLine(lines, body+1)
+	if end < 0 || strings.TrimSpace(lines[end]) != "}" || !isStatement(lines[body]) {
+		return "", false
+	}
+	return statement(lines[body]), true
+}
+
+// nextCodeLine returns the index of the first non-blank line from `from`, or -1
+func nextCodeLine(lines []string, from int) int {
+	for j := from; j < len(lines); j++ {
+		if !isWhitespaceOnly(lines[j]) {
+			return j
+		}
+	}
+	return -1
+}
+
+// isStatement reports whether text is a single simple statement
+func isStatement(text string) bool {
+	text = strings.TrimSpace(text)
+	return text != "" && strings.Count(text, ";") <= 1 && !strings.ContainsAny(text, "{}")
+}
+
+// statement trims a statement and its terminating semicolon
+func statement(text string) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), ";"))
+}
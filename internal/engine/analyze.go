@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// StrategySummary holds the aggregate results of running a single strategy
+type StrategySummary struct {
+	Name             string
+	Patterns         int
+	DuplicatedLines  int
+	MedianSimilarity float64
+	Stats            FilterStats
+	Duration         time.Duration
+}
+
+// runAnalyze runs every strategy over the same files and prints a comparison table
+func runAnalyze(files []string, folder string, strategies map[string]Strategy, warnings *Warnings, minOccur, minScore, minSize, maxSize int, minSimilarity float64, keepOverlaps bool) {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var summaries []StrategySummary
+	for _, name := range names {
+		fmt.Printf("\nRunning strategy %s...\n", name)
+		activeStrategy = strategies[name]
+		start := time.Now()
+
+		suppressions := &Suppressions{}
+		fileData, _, _ := parseFilesWithCache(files, nil, nil, warnings, suppressions)
+		expected, _ := LoadExpectedHashes(folder, minSize, warnings)
+		patterns := detectPatterns(fileData, len(fileData), minOccur, minSize, maxSize, keepOverlaps)
+		matches, stats := FilterPatterns(patterns, FilterConfig{
+			MinOccur:      minOccur,
+			MinScore:      minScore,
+			MinSimilarity: minSimilarity,
+			UserIgnored:   mergeIgnored(LoadIgnoredHashes(folder, name, warnings), expected),
+			Suppressed:    suppressions,
+		})
+
+		summaries = append(summaries, summarizeStrategy(name, matches, stats, time.Since(start)))
+	}
+
+	PrintStrategyComparison(summaries)
+	PrintWarnings(warnings.Items())
+}
+
+// summarizeStrategy computes the comparison metrics for one strategy's matches
+func summarizeStrategy(name string, matches []PatternMatch, stats FilterStats, duration time.Duration) StrategySummary {
+	summary := StrategySummary{
+		Name:     name,
+		Patterns: len(matches),
+		Stats:    stats,
+		Duration: duration,
+	}
+
+	similarities := make([]float64, len(matches))
+	for i, m := range matches {
+		summary.DuplicatedLines += len(m.Pattern) * len(m.Locations)
+		similarities[i] = m.Similarity
+	}
+	summary.MedianSimilarity = median(similarities)
+
+	return summary
+}
+
+// median returns the median of values (0 for an empty slice)
+func median(values []float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	sorted := make([]float64, n)
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,62 @@
+package engine
+
+import "strings"
+
+// groupAnnotations folds runs of annotation/decorator lines into the declaration that
+// follows them (set from --group-annotations), so decorated declarations are a unit
+var groupAnnotations bool
+
+// isAnnotationLine reports whether line starts an annotation, decorator or attribute:
+// @Component (Java/Kotlin/TS/Python/Dart), #[derive(..)] (Rust), and in languages with
+// bracket attributes [Serializable] (C#). Elsewhere a bracketed line is an array literal
+// or slice row.
+func isAnnotationLine(line string, lang *Language) bool {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "@interface"):
+		return false // Java annotation type declaration, not a usage
+	case strings.HasPrefix(trimmed, "@"):
+		return len(trimmed) > 1
+	case strings.HasPrefix(trimmed, "#["):
+		return true
+	case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+		return lang != nil && lang.BracketAttributes
+	}
+	return false
+}
+
+// annotationGrouper buffers annotation lines until their declaration is seen
+type annotationGrouper struct {
+	lines     []string
+	firstLine int
+	depth     int // open parens/brackets in a multi-line annotation
+}
+
+// add consumes line if it belongs to an annotation, returning true if it was buffered
+func (g *annotationGrouper) add(lineNum int, line string, lang *Language) bool {
+	if g.depth <= 0 && !isAnnotationLine(line, lang) {
+		return false
+	}
+	if len(g.lines) == 0 {
+		g.firstLine = lineNum
+	}
+	trimmed := strings.TrimSpace(line)
+	g.lines = append(g.lines, trimmed)
+	g.depth += strings.Count(trimmed, "(") + strings.Count(trimmed, "{") -
+		strings.Count(trimmed, ")") - strings.Count(trimmed, "}")
+	return true
+}
+
+// attach appends buffered annotations to a declaration line and returns the merged
+// line and the line number the unit starts at. The declaration stays first so its
+// first word and indentation drive the hash; annotations contribute to similarity.
+func (g *annotationGrouper) attach(lineNum int, line string) (int, string) {
+	if len(g.lines) == 0 {
+		return lineNum, line
+	}
+	merged := line + " " + strings.Join(g.lines, " ")
+	start := g.firstLine
+	g.lines = nil
+	g.depth = 0
+	return start, merged
+}
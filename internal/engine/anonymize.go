@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// PathAnonymizer replaces file paths in reports with stable hashed identifiers
+// (--anonymize), so the shape of the duplication can be shared without revealing
+// the tree. Extensions are kept so the language stays visible; line numbers are
+// untouched. A nil anonymizer leaves paths as they are.
+type PathAnonymizer struct {
+	mu        sync.Mutex
+	originals map[string]string // identifier -> original path
+}
+
+// anonymizer is the active path anonymizer (set from --anonymize, nil when disabled)
+var anonymizer *PathAnonymizer
+
+// Name returns the identifier for a file path: the same path always gets the same one
+func (a *PathAnonymizer) Name(path string) string {
+	return a.name("file", path, filepath.Ext(path))
+}
+
+// Dir returns the identifier for a directory path
+func (a *PathAnonymizer) Dir(path string) string {
+	return a.name("dir", path, "")
+}
+
+func (a *PathAnonymizer) name(kind, path, ext string) string {
+	if a == nil || path == "" {
+		return path
+	}
+	id := fmt.Sprintf("%s-%012x%s", kind, xxhash.Sum64String(path)&0xffffffffffff, ext)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.originals == nil {
+		a.originals = make(map[string]string)
+	}
+	a.originals[id] = path
+	return id
+}
+
+// Original returns the path behind an identifier, for reading anonymized files back
+func (a *PathAnonymizer) Original(name string) string {
+	if a == nil {
+		return name
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if path, ok := a.originals[name]; ok {
+		return path
+	}
+	return name
+}
+
+// WriteMapping writes the identifier -> path mapping as JSON, for local reference only
+func (a *PathAnonymizer) WriteMapping(path string) error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	data, err := json.MarshalIndent(a.originals, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
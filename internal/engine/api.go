@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// quiet silences the progress quickdup prints to stdout, for library use (Analyze)
+var quiet bool
+
+// Options configures Analyze: the thresholds of the command line flags of the same name
+type Options struct {
+	Strategy         string  // --strategy
+	SimilarityMetric string  // --similarity-metric
+	ScorePreset      string  // --score-preset
+	MinOccur         int     // --min
+	MinScore         int     // --min-score
+	MinSize          int     // --min-size
+	MaxSize          int     // --max-size, 0 for no limit
+	MinSimilarity    float64 // --min-similarity
+	MaxSimilarity    float64 // --max-similarity, 0 for none
+	MinDirs          int     // --min-dirs, counting directories of the working directory; 0 for any
+	KeepOverlaps     bool    // --keep-overlaps
+	ExcludeData      bool    // --exclude-data
+}
+
+// DefaultOptions returns the command line's defaults
+func DefaultOptions() Options {
+	return Options{
+		Strategy:         "normalized-indent",
+		SimilarityMetric: SimilarityJaccard,
+		ScorePreset:      ScorePresetBalanced,
+		MinOccur:         2,
+		MinScore:         5,
+		MinSize:          3,
+		MinSimilarity:    0.75,
+		MaxSimilarity:    1.0,
+	}
+}
+
+// Analyze finds the duplicated code across files, as a scan of them on the command
+// line would, without its cache or output: matches are sorted by score, highest first,
+// with paths as given. Binary, minified and otherwise skipped files are left out; a
+// file that can't be read fails the analysis. Analyze sets package state, so calls
+// must not run concurrently with each other or with Main.
+func Analyze(files []string, opts Options) ([]PatternMatch, error) {
+	strategy, ok := newStrategies()[opts.Strategy]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy: %s", opts.Strategy)
+	}
+	metric, ok := similarityMetrics[opts.SimilarityMetric]
+	if !ok {
+		return nil, fmt.Errorf("unknown similarity metric: %s", opts.SimilarityMetric)
+	}
+	preset, ok := scorePresets[opts.ScorePreset]
+	if !ok {
+		return nil, fmt.Errorf("unknown score preset: %s", opts.ScorePreset)
+	}
+	switch {
+	case opts.MinOccur < 2:
+		return nil, errors.New("MinOccur must be >= 2")
+	case opts.MinSize < 1:
+		return nil, errors.New("MinSize must be >= 1")
+	case opts.MaxSize > 0 && opts.MaxSize < opts.MinSize:
+		return nil, errors.New("MaxSize must be >= MinSize")
+	case opts.MaxSimilarity > 0 && opts.MaxSimilarity < opts.MinSimilarity:
+		return nil, errors.New("MaxSimilarity must be >= MinSimilarity")
+	case opts.MinDirs < 0:
+		return nil, errors.New("MinDirs must be >= 0")
+	}
+	activeStrategy = strategy
+	tokenSimilarity = metric
+	scorePreset = preset
+	quiet = true
+
+	warnings := &Warnings{}
+	suppressions := &Suppressions{}
+	fileData, _, _ := parseFilesWithCache(files, nil, nil, warnings, suppressions)
+	for _, w := range warnings.Items() {
+		if w.Kind == "parse" {
+			return nil, &ScanError{Op: "parse", Path: w.Path, Err: errors.New(w.Message)}
+		}
+	}
+
+	patterns := detectPatterns(fileData, len(fileData), opts.MinOccur, opts.MinSize, opts.MaxSize, opts.KeepOverlaps)
+	matches, _ := FilterPatterns(patterns, FilterConfig{
+		MinOccur:       opts.MinOccur,
+		MinMode:        MinModeCluster,
+		MinScore:       opts.MinScore,
+		MinSimilarity:  opts.MinSimilarity,
+		MaxSimilarity:  opts.MaxSimilarity,
+		MinDirs:        opts.MinDirs,
+		Suppressed:     suppressions,
+		ExcludeData:    opts.ExcludeData,
+		Representative: RepresentativeMedoid,
+	})
+	return matches, nil
+}
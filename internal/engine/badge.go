@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// badgeColors are the badge colors from no duplication up, one more than thresholds
+var badgeColors = []string{"brightgreen", "yellow", "orange", "red"}
+
+// Badge is a shields.io endpoint badge (https://shields.io/badges/endpoint-badge)
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// parseBadgeThresholds parses ascending percentages, e.g. "5,10,20", where the badge
+// turns yellow, orange and red
+func parseBadgeThresholds(s string) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != len(badgeColors)-1 {
+		return nil, fmt.Errorf("expected %d comma-separated percentages, got %q", len(badgeColors)-1, s)
+	}
+	thresholds := make([]float64, len(parts))
+	for i, part := range parts {
+		t, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || t < 0 || t > 100 {
+			return nil, fmt.Errorf("invalid percentage %q", part)
+		}
+		if i > 0 && t < thresholds[i-1] {
+			return nil, fmt.Errorf("percentages must be ascending: %q", s)
+		}
+		thresholds[i] = t
+	}
+	return thresholds, nil
+}
+
+// duplicationPercent returns the share of parsed lines that are part of an occurrence
+// of a match, each line counted once however many matches cover it
+func duplicationPercent(matches []PatternMatch, fileData map[string][]Entry) float64 {
+	total := 0
+	for _, entries := range fileData {
+		total += len(entries)
+	}
+	if total == 0 {
+		return 0
+	}
+	covered := make(map[string]map[int]bool)
+	for _, m := range matches {
+		for _, loc := range m.Locations {
+			if covered[loc.Filename] == nil {
+				covered[loc.Filename] = make(map[int]bool)
+			}
+			entries := fileData[loc.Filename]
+			end := locationEndLine(loc)
+			i := sort.Search(len(entries), func(i int) bool { return entries[i].GetLineNumber() >= loc.LineStart })
+			for ; i < len(entries) && entries[i].GetLineNumber() <= end; i++ {
+				covered[loc.Filename][entries[i].GetLineNumber()] = true
+			}
+		}
+	}
+	duplicated := 0
+	for _, lines := range covered {
+		duplicated += len(lines)
+	}
+	return float64(duplicated) * 100 / float64(total)
+}
+
+// newBadge returns the duplication badge for percent, colored by thresholds
+func newBadge(percent float64, thresholds []float64) Badge {
+	color := badgeColors[0]
+	for i, t := range thresholds {
+		if percent >= t {
+			color = badgeColors[i+1]
+		}
+	}
+	return Badge{
+		SchemaVersion: 1,
+		Label:         "duplication",
+		Message:       fmt.Sprintf("%.1f%%", percent),
+		Color:         color,
+	}
+}
+
+// WriteBadge writes badge as JSON to path
+func WriteBadge(badge Badge, path string) error {
+	data, err := json.Marshal(badge)
+	if err != nil {
+		return &ScanError{Op: "encode badge", Path: path, Err: err}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return &ScanError{Op: "create output directory", Path: filepath.Dir(path), Err: err}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return &ScanError{Op: "write badge", Path: path, Err: err}
+	}
+	return nil
+}
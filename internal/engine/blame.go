@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// annotateBlameAges runs git blame on every location of the given matches and records
+// the newest commit time touching the pattern. Expensive, so only call it on top-N matches.
+func annotateBlameAges(matches []PatternMatch) {
+	for i := range matches {
+		var newest time.Time
+		for _, loc := range matches[i].Locations {
+			t, err := newestCommitTime(sourcePath(loc.Filename), loc.LineStart, locationEndLine(loc))
+			if err != nil {
+				if debugEnabled {
+					fmt.Printf("[debug] git blame failed for %s:%d: %v\n", loc.Filename, loc.LineStart, err)
+				}
+				continue
+			}
+			if t.After(newest) {
+				newest = t
+			}
+		}
+		matches[i].NewestChange = newest
+	}
+}
+
+// filterByBlameAge keeps matches whose newest change is at most maxAgeDays old
+func filterByBlameAge(matches []PatternMatch, maxAgeDays int, now time.Time) []PatternMatch {
+	cutoff := now.Add(-time.Duration(maxAgeDays) * 24 * time.Hour)
+	var result []PatternMatch
+	for _, m := range matches {
+		if !m.NewestChange.IsZero() && !m.NewestChange.Before(cutoff) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// newestCommitTime returns the newest committer time among lines startLine..endLine of a file
+func newestCommitTime(filename string, startLine, endLine int) (time.Time, error) {
+	cmd := exec.Command("git", "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", startLine, endLine), "--", filepath.Base(filename))
+	cmd.Dir = filepath.Dir(filename)
+	output, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var newest time.Time
+	for _, line := range strings.Split(string(output), "\n") {
+		value, ok := strings.CutPrefix(line, "committer-time ")
+		if !ok {
+			continue
+		}
+		unix, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		if t := time.Unix(unix, 0); t.After(newest) {
+			newest = t
+		}
+	}
+	if newest.IsZero() {
+		return time.Time{}, fmt.Errorf("no commit times in blame output")
+	}
+	return newest, nil
+}
+
+// formatAge renders a duration in days (or "today")
+func formatAge(age time.Duration) string {
+	days := int(age.Hours() / 24)
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "1 day ago"
+	default:
+		return fmt.Sprintf("%d days ago", days)
+	}
+}
@@ -0,0 +1,329 @@
+package engine
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// CachedFile stores parsed entries with the file's stamp for incremental parsing
+type CachedFile struct {
+	Stamp      uint64 // mod time or content hash, per FileCache.KeyMode
+	Entries    []WordIndentEntry
+	Suppressed []int // lines covered by quickdup:ignore markers
+}
+
+// FileCache stores all cached file data
+type FileCache struct {
+	Version int    // cache format version for invalidation
+	Options string // parse options the entries were produced with (see parseOptionsKey)
+	KeyMode string // what file stamps are: CacheKeyMTime or CacheKeyContent
+	Files   map[string]CachedFile
+}
+
+const cacheVersion = 5
+
+// Cache key modes (--cache-key): how the parse cache tells a file changed
+const (
+	CacheKeyMTime   = "mtime"   // modification time: cheap, but reset by checkouts and copies
+	CacheKeyContent = "content" // hash of the file's bytes: reads every file, survives checkouts
+)
+
+// cacheKeyMode is the cache key mode in use (set from --cache-key)
+var cacheKeyMode = CacheKeyMTime
+
+// fileStamp returns what the parse cache compares to tell a file changed: its mod time,
+// or with --cache-key content a hash of its content
+func fileStamp(path string) (uint64, error) {
+	if cacheKeyMode == CacheKeyContent {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		return xxhash.Sum64(data), nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(info.ModTime().UnixNano()), nil
+}
+
+// cacheFlushInterval is how often parse results are saved while parsing is still
+// running, so a scan killed midway (e.g. by a CI timeout) resumes from cache
+const cacheFlushInterval = 30 * time.Second
+
+// cacheable reports whether a strategy's entries can be cached: the cache stores
+// WordIndentEntry only
+func cacheable(strategyName string) bool {
+	return strategyName == "word-indent"
+}
+
+// loadCache reads the strategy's cache from cacheDir, returning nil if missing or stale
+// along with why a cache couldn't be used ("" if there was none to use)
+func loadCache(cacheDir string, strategyName string) (*FileCache, string) {
+	if !cacheable(strategyName) {
+		return nil, fmt.Sprintf("the %s strategy isn't cached (only word-indent is), parsing all files", strategyName)
+	}
+
+	cachePath := filepath.Join(cacheDir, strategyName+"-cache.gob")
+	file, err := os.Open(cachePath)
+	if err != nil {
+		return nil, ""
+	}
+	defer file.Close()
+
+	var cache FileCache
+	decoder := gob.NewDecoder(file)
+	if err := decoder.Decode(&cache); err != nil {
+		return nil, "cache file unreadable, re-parsing all files"
+	}
+
+	// Check version, parse options and key mode
+	switch {
+	case cache.Version != cacheVersion:
+		return nil, "cache format changed, re-parsing all files"
+	case cache.Options != parseOptionsKey():
+		return nil, "parse options changed, re-parsing all files"
+	case cache.KeyMode != cacheKeyMode:
+		return nil, fmt.Sprintf("cache key changed from %s to %s, re-parsing all files", cache.KeyMode, cacheKeyMode)
+	}
+
+	return &cache, ""
+}
+
+// saveCache saves the file cache to cacheDir; stamps are the files' stamps when they
+// were parsed (see fileStamp)
+func saveCache(cacheDir string, strategyName string, files []string, fileData map[string][]Entry, stamps map[string]uint64, suppressions *Suppressions) {
+	if !cacheable(strategyName) {
+		return
+	}
+
+	// Build cache from current file data
+	cache := FileCache{
+		Version: cacheVersion,
+		Options: parseOptionsKey(),
+		KeyMode: cacheKeyMode,
+		Files:   make(map[string]CachedFile),
+	}
+
+	for _, path := range files {
+		entries, ok := fileData[path]
+		if !ok {
+			continue
+		}
+		stamp, ok := stamps[path]
+		if !ok {
+			continue
+		}
+		// Convert []Entry to []WordIndentEntry for serialization
+		concrete := make([]WordIndentEntry, len(entries))
+		for i, e := range entries {
+			concrete[i] = *e.(*WordIndentEntry)
+		}
+		cache.Files[path] = CachedFile{
+			Stamp:      stamp,
+			Entries:    concrete,
+			Suppressed: suppressions.Lines(path),
+		}
+	}
+
+	// Ensure directory exists
+	os.MkdirAll(cacheDir, 0755)
+
+	cachePath := filepath.Join(cacheDir, strategyName+"-cache.gob")
+	file, err := os.Create(cachePath)
+	if err != nil {
+		return // silently fail
+	}
+	defer file.Close()
+
+	encoder := gob.NewEncoder(file)
+	encoder.Encode(cache)
+}
+
+// CacheWriter collects parse results and saves them to the cache periodically while
+// parsing runs, and on Flush when parsing completes or a scan is cut short. A nil
+// writer (caching disabled, or a strategy that can't be cached) is a no-op; safe for
+// concurrent use by the parse workers.
+type CacheWriter struct {
+	dir          string
+	strategy     string
+	suppressions *Suppressions
+
+	mu        sync.Mutex
+	entries   map[string][]Entry
+	stamps    map[string]uint64
+	unsaved   int // files parsed (not loaded from cache) since the last save
+	lastSaved time.Time
+}
+
+// newCacheWriter returns a writer for the strategy's cache in dir, or nil if the
+// strategy can't be cached
+func newCacheWriter(dir, strategyName string, suppressions *Suppressions) *CacheWriter {
+	if !cacheable(strategyName) {
+		return nil
+	}
+	return &CacheWriter{
+		dir:          dir,
+		strategy:     strategyName,
+		suppressions: suppressions,
+		entries:      make(map[string][]Entry),
+		stamps:       make(map[string]uint64),
+		lastSaved:    time.Now(),
+	}
+}
+
+// Parsed records a file's entries and its stamp when read, saving the cache if it's
+// been a while; fresh is false for entries that were loaded from the cache
+func (w *CacheWriter) Parsed(path string, entries []Entry, stamp uint64, fresh bool) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries[path] = entries
+	w.stamps[path] = stamp
+	if fresh {
+		w.unsaved++
+	}
+	if w.unsaved > 0 && time.Since(w.lastSaved) >= cacheFlushInterval {
+		w.save()
+	}
+}
+
+// Flush saves the cache if any file was parsed since the last save
+func (w *CacheWriter) Flush() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.unsaved > 0 {
+		w.save()
+	}
+}
+
+func (w *CacheWriter) save() {
+	files := make([]string, 0, len(w.entries))
+	for path := range w.entries {
+		files = append(files, path)
+	}
+	saveCache(w.dir, w.strategy, files, w.entries, w.stamps, w.suppressions)
+	w.unsaved = 0
+	w.lastSaved = time.Now()
+}
+
+// skipKind returns the warning kind for files parseFile deliberately skips, or ""
+func skipKind(err error) string {
+	switch {
+	case errors.Is(err, errBinaryFile):
+		return "binary"
+	case errors.Is(err, errMinifiedFile):
+		return "minified"
+	case errors.Is(err, errTooManyLines):
+		return "too-long"
+	}
+	return ""
+}
+
+// parseFilesWithCache parses files using cache when possible
+// Files that fail to parse are skipped and recorded in warnings; quickdup:ignore
+// markers are recorded in suppressions; results are passed on to writer
+func parseFilesWithCache(files []string, cache *FileCache, writer *CacheWriter, warnings *Warnings, suppressions *Suppressions) (map[string][]Entry, int, int) {
+	numWorkers := runtime.NumCPU()
+	results := make(map[string][]Entry)
+	var mu sync.Mutex
+	var cacheHits atomic.Int64
+	var cacheMisses atomic.Int64
+	var done atomic.Int64
+
+	// Create work channel
+	work := make(chan string, len(files))
+	for _, f := range files {
+		work <- f
+	}
+	close(work)
+
+	// Start workers
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range work {
+				start := time.Now()
+				var entries []Entry
+				var fromCache bool
+
+				// Stamp the file before parsing, so a change made meanwhile is seen next run
+				var stamp uint64
+				stamped := false
+				if cache != nil || writer != nil {
+					var err error
+					stamp, err = fileStamp(path)
+					stamped = err == nil
+				}
+
+				// Check cache
+				if cache != nil && stamped {
+					if cached, ok := cache.Files[path]; ok {
+						if stamp == cached.Stamp {
+							// Convert []WordIndentEntry to []Entry
+							entries = make([]Entry, len(cached.Entries))
+							for i := range cached.Entries {
+								cached.Entries[i].restoreHashBytes()
+								entries[i] = &cached.Entries[i]
+							}
+							suppressions.Add(path, cached.Suppressed)
+							fromCache = true
+						}
+					}
+				}
+
+				// Parse if not cached
+				if !fromCache {
+					var suppressed []int
+					var err error
+					entries, suppressed, err = parseFile(path)
+					if kind := skipKind(err); kind != "" {
+						warnings.Add(kind, path, err)
+						continue
+					}
+					if err != nil {
+						warnings.Add("parse", path, err)
+						continue // skip files that fail to parse
+					}
+					suppressions.Add(path, suppressed)
+					cacheMisses.Add(1)
+				} else {
+					cacheHits.Add(1)
+				}
+				profile.Parsed(path, time.Since(start), len(entries), fromCache)
+				if stamped {
+					writer.Parsed(path, entries, stamp, !fromCache)
+				}
+
+				mu.Lock()
+				results[path] = entries
+				mu.Unlock()
+
+				if n := done.Add(1); n%progressFilesInterval == 0 {
+					progress.FilesParsed(int(n), len(files))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	progress.FilesParsed(int(done.Load()), len(files))
+	return results, int(cacheHits.Load()), int(cacheMisses.Load())
+}
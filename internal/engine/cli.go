@@ -0,0 +1,1076 @@
+package engine
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Active strategy (set from --strategy flag)
+var activeStrategy Strategy
+var debugEnabled bool
+
+// Default comment prefixes by file extension
+var commentPrefixes = map[string]string{
+	// C-style
+	".go":    "//",
+	".c":     "//",
+	".h":     "//",
+	".cpp":   "//",
+	".hpp":   "//",
+	".cc":    "//",
+	".cxx":   "//",
+	".java":  "//",
+	".js":    "//",
+	".jsx":   "//",
+	".ts":    "//",
+	".tsx":   "//",
+	".cs":    "//",
+	".swift": "//",
+	".kt":    "//",
+	".kts":   "//",
+	".scala": "//",
+	".rs":    "//",
+	".php":   "//",
+	".m":     "//",
+	".mm":    "//",
+	".dart":  "//",
+	".v":     "//",
+	".zig":   "//",
+	// Hash-style
+	".py":      "#",
+	".rb":      "#",
+	".sh":      "#",
+	".bash":    "#",
+	".zsh":     "#",
+	".pl":      "#",
+	".pm":      "#",
+	".r":       "#",
+	".R":       "#",
+	".yaml":    "#",
+	".yml":     "#",
+	".toml":    "#",
+	".tf":      "#",
+	".cmake":   "#",
+	".make":    "#",
+	".mk":      "#",
+	"makefile": "#", // -ext Makefile (see fileType)
+	".ps1":     "#",
+	".nim":     "#",
+	".jl":      "#",
+	".ex":      "#",
+	".exs":     "#",
+	".cr":      "#",
+	// Double-dash style
+	".sql":  "--",
+	".lua":  "--",
+	".hs":   "--",
+	".elm":  "--",
+	".ada":  "--",
+	".vhdl": "--",
+	// Semicolon style
+	".lisp": ";",
+	".cl":   ";",
+	".scm":  ";",
+	".clj":  ";",
+	".cljs": ";",
+	".el":   ";",
+	".asm":  ";",
+	// Percent style
+	".tex":    "%",
+	".mat":    "%", // MATLAB
+	".erl":    "%",
+	".hrl":    "%",
+	".pro":    "%",
+	".prolog": "%",
+	// Apostrophe style
+	".vb":  "'",
+	".bas": "'",
+	".vbs": "'",
+}
+
+// Main runs the quickdup command line with os.Args; cmd/quickdup is a wrapper around it
+func Main() {
+	// "quickdup analyze [flags]" runs every strategy and prints a comparison
+	analyzeMode := len(os.Args) > 1 && os.Args[1] == "analyze"
+	// "quickdup serve [flags]" answers scan requests over HTTP (see runServe)
+	serveMode := len(os.Args) > 1 && os.Args[1] == "serve"
+	if analyzeMode || serveMode {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
+	path := flag.String("path", ".", "Path to scan")
+	filePath := flag.String("file", "", "Scan a single file (overrides --path)")
+	ext := flag.String("ext", ".go", "File extensions to scan, comma-separated, e.g. \".ts,.tsx,.js\"")
+	minOccur := flag.Int("min", 2, "Minimum occurrences to report")
+	minScore := flag.Int("min-score", 5, "Minimum score to report (uniqueWords × adjusted similarity)")
+	minScorePerLine := flag.Float64("min-score-per-line", 0, "Minimum score per pattern line, replacing --min-score so the threshold scales with length (0 = off)")
+	minSize := flag.Int("min-size", 3, "Base pattern size to start growing from")
+	maxSize := flag.Int("max-size", 0, "Maximum pattern size to grow to (0 = no limit)")
+	minSimilarity := flag.Float64("min-similarity", 0.75, "Minimum token similarity between occurrences (0.0-1.0)")
+	maxSimilarity := flag.Float64("max-similarity", 1.0, "Maximum token similarity between occurrences (0.0-1.0), to skip exact copies")
+	topN := flag.Int("top", 10, "Show top N matches by pattern length")
+	extAlias := flag.String("ext-alias", "", "Treat extensions as another's language and scan them with it, e.g. \".h=.cpp,.tsx=.ts\"")
+	comment := flag.String("comment", "", "Override comment prefix (auto-detected by extension)")
+	noCache := flag.Bool("no-cache", false, "Disable incremental caching, force full re-parse and similarity computation")
+	flag.StringVar(&cacheKeyMode, "cache-key", CacheKeyMTime, "How the cache tells a file changed: mtime (modification time) or content (hash of the file, slower but survives checkouts that reset mod times)")
+	cacheDirFlag := flag.String("cache-dir", "", "Directory for the parse cache (default: <path>/.quickdup)")
+	githubAnnotations := flag.Bool("github-annotations", false, "Output GitHub Actions annotations for inline PR comments")
+	githubLevel := flag.String("github-level", "warning", "GitHub annotation level: notice, warning, or error")
+	gitDiff := flag.String("git-diff", "", "Only annotate files changed vs this git ref (e.g., origin/main)")
+	exclude := flag.String("exclude", "", "Exclude files matching patterns (comma-separated, e.g., '*.pb.go,*_gen.go')")
+	compare := flag.String("compare", "", "Compare duplicates between two commits (format: base..head)")
+	reportUnchanged := flag.String("report-unchanged", "", "Compare mode: also list duplicates unchanged between base and head (all, touched)")
+	strategyName := flag.String("strategy", "normalized-indent", "Detection strategy: word-indent, normalized-indent, word-only, inlineable, error-handling, ci-config, comments, token-normalized")
+	worst := flag.Bool("worst", false, "Only print the single highest-score pattern with its code, skipping the full report")
+	selectRange := flag.String("select", "", "Show detailed output for patterns (format: skip..limit, e.g., 0..5)")
+	listen := flag.String("listen", "127.0.0.1:7878", "Address quickdup serve listens on")
+	minMode := flag.String("min-mode", MinModeCluster, "What -min counts: cluster (each similarity cluster of a pattern needs -min occurrences) or pattern (the pattern does, before clustering)")
+	representative := flag.String("representative", RepresentativeMedoid, "Representative occurrence per match: medoid (most similar to the others) or first (by filename)")
+	excludeData := flag.Bool("exclude-data", false, "Drop matches that are pure data definitions (struct/enum/DTO fields); they're tagged kind=data-definition otherwise")
+	mergeAdjacent := flag.Bool("merge-adjacent", false, "Coalesce back-to-back occurrences of a match into one span with a repeat count")
+	baselineUpdate := flag.Bool("baseline-update", false, "Remove ignore.json entries that are no longer detected (never adds new ones)")
+	minDirs := flag.Int("min-dirs", 0, "Only report matches whose occurrences span N+ top-level directories of -path (cross-service copies in a monorepo); 0 disables")
+	onlyGrown := flag.Bool("only-grown", false, "Only report patterns that grew past -min-size lines (drops short coincidental matches)")
+	keepOverlaps := flag.Bool("keep-overlaps", false, "Keep overlapping occurrences (don't prune adjacent matches)")
+	sqlQueries := flag.Bool("sql", false, "Also report SQL queries duplicated across string literals (formatting-insensitive)")
+	magicNumbers := flag.Bool("magic-numbers", false, "Also report numeric literals repeated across files (named constant candidates)")
+	importReport := flag.Bool("import-report", false, "Also list import lines shared by many files of a package (shared prelude candidates)")
+	minAccessors := flag.Int("accessors", 0, "Also report classes with N+ one-line getters/setters (record/Lombok/auto-property candidates); 0 disables")
+	shingles := flag.Int("shingles", 0, "Also report fuzzy duplicates: regions sharing most of their N-line shingles though statements are reordered or edited (0 disables, e.g. 3)")
+	fuzzyOverlap := flag.Float64("fuzzy-overlap", 0.6, "Minimum shingle overlap (Jaccard, 0.0-1.0) of a fuzzy duplicate")
+	tokenStream := flag.Int("token-stream", 0, "Experimental: also report runs of N+ identical tokens regardless of line breaks (0 disables, e.g. 50)")
+	minRepeats := flag.Int("repeats", 0, "Report blocks repeated N+ times back-to-back as a single finding (0 disables)")
+	debug := flag.Bool("debug", false, "Print verbose progress for long-running phases and check preparsers keep line numbers")
+	timeoutSeconds := flag.Int("timeout", 20, "Hard timeout in seconds (0 disables)")
+	flag.BoolVar(&ignoreModifiers, "ignore-modifiers", false, "Skip leading access modifiers (public, private, internal, protected) when matching lines, so methods differing only in visibility match")
+	flag.IntVar(&detailMaxOccurrences, "md-max-occurrences", 0, "Detailed pattern view: show at most N occurrences per pattern (0 = all)")
+	flag.IntVar(&detailMaxLines, "md-max-lines", 0, "Detailed pattern view: show at most N lines per occurrence (0 = all)")
+	flag.BoolVar(&caseInsensitive, "case-insensitive", false, "Ignore letter case in first words and tokens (SQL, Pascal, Fortran, ...)")
+	similarityMetricName := flag.String("similarity-metric", SimilarityJaccard, "How occurrences are compared for similarity and clustering: jaccard (shared distinct tokens) or cosine (token frequencies)")
+	scorePresetName := flag.String("score-preset", ScorePresetBalanced, "Scoring sensitivity across strategies: conservative (near-verbatim copies only), balanced, or aggressive (looser copies, bonus for each occurrence beyond two)")
+	flag.Float64Var(&similarityFloor, "similarity-floor", 0.5, "Similarity scored as noise: the score's similarity factor rises from 0 here to 1 at verbatim copies (0.0-0.99)")
+	flag.IntVar(&maxLineLength, "max-line-length", 1000, "Skip lines longer than N bytes, and files made up mostly of them (minified); 0 disables")
+	flag.IntVar(&tabWidth, "tab-width", 4, "Columns of indentation a tab counts as, when comparing the indentation of lines")
+	flag.IntVar(&maxFileLines, "max-file-lines", 0, "Skip files with more than N lines, e.g. generated code (0 = no limit)")
+	flag.BoolVar(&respectGitignore, "respect-gitignore", false, "Skip paths ignored by .gitignore files (nested ones and ! negations included)")
+	flag.IntVar(&maxDepth, "max-depth", 0, "Descend at most N directory levels below the scan path (0 = no limit)")
+	flag.BoolVar(&groupAnnotations, "group-annotations", false, "Treat annotation/decorator lines and the declaration they precede as one unit")
+	profileOutput := flag.String("profile-output", "", "Write per-file parse and base-pattern timings as CSV to this file")
+	progressJSON := flag.String("progress-json", "", "Write progress events as JSON lines to a file, '-' (stderr), or 'fd:N'")
+	hashName := flag.String("hash", HashFNV, "Window hash algorithm: fnv (default, matches existing ignore lists) or xxhash (faster)")
+	format := flag.String("format", "text", "Output formats, comma-separated: one of text, terminal-wide (top matches with locations in columns) or grep (file:start:end per occurrence) on stdout, plus json (the summary line with --summary-only), markdown (.quickdup/patterns.md) and sarif (.quickdup/results.sarif)")
+	keepIdentical := flag.Bool("keep-identical-files", false, "Scan every copy of byte-identical files instead of one per set")
+	findSpec := flag.String("find", "", "Search for copies of one snippet (file:start-end) instead of reporting all duplication, most similar first")
+	dumpEntriesPath := flag.String("dump-entries", "", "Debug: parse just this file and print each line's entry, or why it was skipped")
+	sarifOutput := flag.String("sarif", "", "Also write the matches as a SARIF 2.1.0 log to this path, for code scanning dashboards")
+	similarityGraph := flag.String("similarity-graph", "", "Also write the pairwise similarity graph of the top matches' occurrences to this path: DOT (.dot, .gv) or GraphML (.graphml)")
+	flag.StringVar(&testsMode, "tests", TestsInclude, "Test files (foo_test.go, *.spec.ts, FooTest.java, test/ dirs, ...): include them, exclude them, or separate them into their own report of duplicated setup and assertions")
+	testMinOccur := flag.Int("test-min", 3, "Minimum occurrences of a test finding with --tests separate (some test duplication is fine)")
+	stdinMode := flag.Bool("stdin", false, "Find duplicates within one file read from standard input, its language taken from -ext; prints results JSON to stdout and writes nothing to .quickdup")
+	compact := flag.Bool("compact", false, "List the top matches one line each: score, lines, occurrences, similarity, file:line (+N more)")
+	worklist := flag.Bool("worklist", false, "Also write .quickdup/worklist.md: refactoring tasks ordered by ROI")
+	badge := flag.Bool("badge", false, "Also write .quickdup/badge.json: a shields.io endpoint badge of the duplicated line percentage")
+	badgeThresholds := flag.String("badge-thresholds", "5,10,20", "Duplication percentages where the badge turns yellow, orange and red")
+	roiWeights := flag.String("roi-weights", "", "Worklist ROI weights, e.g. \"score=1,occurrences=2,lines=0.5\" (the default)")
+	execTemplate := flag.String("exec", "", "Run this command for each top match, e.g. \"notify {hash} {file} {line}\" (also {end}, {lines}, {occurrences}, {score}, {similarity})")
+	execJobs := flag.Int("exec-jobs", 4, "Maximum --exec commands running at once")
+	pathBase := flag.String("path-base", "", "Report file paths relative to this directory (e.g. the repository root when scanning a subdirectory)")
+	anonymize := flag.Bool("anonymize", false, "Replace file paths with stable hashed identifiers in all output; the mapping is written to .quickdup/anonymize-map.json")
+	summaryOnly := flag.Bool("summary-only", false, "Print only the final summary line (a JSON object with --format json); skips the report and results.json")
+	hotspotMetric := flag.String("hotspot-metric", HotspotLines, "Rank duplication hotspots by: lines (duplicated lines) or score (summed match scores)")
+	tiers := flag.Bool("tiers", false, "Print top matches in actionable / review / likely-noise sections")
+	actionableScore := flag.Int("actionable-score", 12, "Tiers: minimum score of an actionable match")
+	actionableSimilarity := flag.Float64("actionable-similarity", 0.9, "Tiers: minimum similarity of an actionable match")
+	noiseScore := flag.Int("noise-score", 7, "Tiers: matches scoring below this are likely noise")
+	failOnScore := flag.Int("fail-on-score", 0, "CI gate: exit with status 2 if any match scores N or more (0 disables)")
+	failOnCount := flag.Int("fail-on-count", -1, "CI gate: exit with status 2 if there are more than N matches (-1 disables, 0 fails on any)")
+	repoURL := flag.String("repo", "", "Scan a remote git repository: fetch it shallowly into a temporary directory, scan -path within it, then remove it")
+	repoRef := flag.String("ref", "", "Branch, tag or commit to scan with --repo (default: the default branch)")
+	blameAge := flag.Int("blame-age", 0, "Only report top matches changed within N days, using git blame (0 disables)")
+	flag.Parse()
+	debugEnabled = *debug
+
+	if *repoURL != "" {
+		if serveMode {
+			fmt.Fprintf(os.Stderr, "Error: --repo can't be combined with serve; scan ?ref= of a local clone instead\n")
+			os.Exit(1)
+		}
+		os.Exit(runRepo(*repoURL, *repoRef, *path, analyzeMode))
+	}
+	if *repoRef != "" {
+		fmt.Fprintf(os.Stderr, "Error: --ref requires --repo\n")
+		os.Exit(1)
+	}
+
+	// Flag defaults, per-path threshold overrides and language rules from the scan
+	// path's .quickdup.json (or .quickdup.toml); flags given here win
+	configDir := *path
+	if *dumpEntriesPath != "" {
+		configDir = filepath.Dir(*dumpEntriesPath)
+	} else if *filePath != "" {
+		configDir = filepath.Dir(*filePath)
+	} else if info, err := os.Stat(*path); err == nil && !info.IsDir() {
+		configDir = filepath.Dir(*path)
+	}
+	configFile, err := loadConfigFile(configDir)
+	if err != nil {
+		fatal(err)
+	}
+	if err := applyConfigFlags(configFile, configDir); err != nil {
+		fatal(&ScanError{Op: "apply config", Path: configDir, Err: err})
+	}
+
+	if *progressJSON != "" {
+		p, err := openProgress(*progressJSON)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --progress-json: %v\n", err)
+			os.Exit(1)
+		}
+		progress = p
+		defer progress.Close()
+	}
+
+	if *profileOutput != "" {
+		profile = &FileProfile{}
+	}
+
+	// Machine-readable formats own stdout; route progress output to stderr
+	resultsOut := os.Stdout
+	stdoutFormat, fileFormatSet, err := parseFormats(*format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --format: %v\n", err)
+		os.Exit(1)
+	}
+	if stdoutFormat == "grep" || *stdinMode {
+		os.Stdout = os.Stderr
+	}
+	if *summaryOnly {
+		// Only the summary line goes to stdout; progress output is discarded
+		devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			fatal(err)
+		}
+		os.Stdout = devNull
+	}
+	// Ctrl-C: keep the parse progress so the next run resumes from cache
+	handleInterrupts()
+	// A server runs until stopped, so the timeout only bounds one-off scans
+	if *timeoutSeconds > 0 && !serveMode {
+		timeout := time.Duration(*timeoutSeconds) * time.Second
+		go func() {
+			time.Sleep(timeout)
+			fmt.Fprintf(os.Stderr, "Error: timed out after %s\n", timeout)
+			scan.Abort()                     // the next run resumes from the files parsed so far
+			profile.WriteCSV(*profileOutput) // what was timed so far shows the culprit
+			os.Exit(1)
+		}()
+	}
+	if !setHashAlgorithm(*hashName) {
+		fmt.Fprintf(os.Stderr, "Error: unknown --hash: %s\n", *hashName)
+		os.Exit(1)
+	}
+	if *compact && stdoutFormat != "text" {
+		fmt.Fprintf(os.Stderr, "Error: --compact lists matches in the text format, not %s\n", stdoutFormat)
+		os.Exit(1)
+	}
+	if testsMode != TestsInclude && testsMode != TestsExclude && testsMode != TestsSeparate {
+		fmt.Fprintf(os.Stderr, "Error: --tests must be '%s', '%s' or '%s'\n", TestsInclude, TestsExclude, TestsSeparate)
+		os.Exit(1)
+	}
+	if *testMinOccur < 2 {
+		fmt.Fprintf(os.Stderr, "Error: --test-min must be >= 2\n")
+		os.Exit(1)
+	}
+	if cacheKeyMode != CacheKeyMTime && cacheKeyMode != CacheKeyContent {
+		fmt.Fprintf(os.Stderr, "Error: --cache-key must be '%s' or '%s'\n", CacheKeyMTime, CacheKeyContent)
+		os.Exit(1)
+	}
+	if *minMode != MinModeCluster && *minMode != MinModePattern {
+		fmt.Fprintf(os.Stderr, "Error: --min-mode must be '%s' or '%s'\n", MinModeCluster, MinModePattern)
+		os.Exit(1)
+	}
+	if *representative != RepresentativeMedoid && *representative != RepresentativeFirst {
+		fmt.Fprintf(os.Stderr, "Error: --representative must be '%s' or '%s'\n", RepresentativeMedoid, RepresentativeFirst)
+		os.Exit(1)
+	}
+	if preset, ok := scorePresets[*scorePresetName]; ok {
+		scorePreset = preset
+	} else {
+		fmt.Fprintf(os.Stderr, "Error: --score-preset must be '%s', '%s' or '%s'\n", ScorePresetConservative, ScorePresetBalanced, ScorePresetAggressive)
+		os.Exit(1)
+	}
+	if metric, ok := similarityMetrics[*similarityMetricName]; ok {
+		tokenSimilarity = metric
+	} else {
+		fmt.Fprintf(os.Stderr, "Error: --similarity-metric must be '%s' or '%s'\n", SimilarityJaccard, SimilarityCosine)
+		os.Exit(1)
+	}
+	if *maxSize > 0 && *maxSize < *minSize {
+		fmt.Fprintf(os.Stderr, "Error: --max-size must be >= --min-size\n")
+		os.Exit(1)
+	}
+	if tabWidth < 1 {
+		fmt.Fprintf(os.Stderr, "Error: --tab-width must be >= 1\n")
+		os.Exit(1)
+	}
+	if similarityFloor < 0 || similarityFloor >= 1 {
+		fmt.Fprintf(os.Stderr, "Error: --similarity-floor must be at least 0.0 and below 1.0\n")
+		os.Exit(1)
+	}
+	if *actionableSimilarity < 0 || *actionableSimilarity > 1 {
+		fmt.Fprintf(os.Stderr, "Error: --actionable-similarity must be between 0.0 and 1.0\n")
+		os.Exit(1)
+	}
+	if *noiseScore > *actionableScore {
+		fmt.Fprintf(os.Stderr, "Error: --noise-score must be <= --actionable-score\n")
+		os.Exit(1)
+	}
+	if *hotspotMetric != HotspotLines && *hotspotMetric != HotspotScore {
+		fmt.Fprintf(os.Stderr, "Error: unknown --hotspot-metric: %s (use lines or score)\n", *hotspotMetric)
+		os.Exit(1)
+	}
+	if *shingles != 0 && (*shingles < 2 || *shingles >= fuzzyWindow) {
+		fmt.Fprintf(os.Stderr, "Error: --shingles must be 0 or between 2 and %d\n", fuzzyWindow-1)
+		os.Exit(1)
+	}
+	if *fuzzyOverlap <= 0 || *fuzzyOverlap > 1 {
+		fmt.Fprintf(os.Stderr, "Error: --fuzzy-overlap must be above 0.0 and at most 1.0\n")
+		os.Exit(1)
+	}
+	if *tokenStream != 0 && *tokenStream < minTokenStream {
+		fmt.Fprintf(os.Stderr, "Error: --token-stream must be 0 or at least %d\n", minTokenStream)
+		os.Exit(1)
+	}
+	if *failOnScore < 0 || *failOnCount < -1 {
+		fmt.Fprintf(os.Stderr, "Error: --fail-on-score must be >= 0 and --fail-on-count >= -1\n")
+		os.Exit(1)
+	}
+	if *minScorePerLine < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --min-score-per-line must be >= 0\n")
+		os.Exit(1)
+	}
+	if *minDirs < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --min-dirs must be >= 0\n")
+		os.Exit(1)
+	}
+	if *maxSimilarity < *minSimilarity {
+		fmt.Fprintf(os.Stderr, "Error: --max-similarity must be >= --min-similarity\n")
+		os.Exit(1)
+	}
+	if *similarityGraph != "" && graphFormat(*similarityGraph) == "" {
+		fmt.Fprintf(os.Stderr, "Error: --similarity-graph must end in .dot, .gv or .graphml\n")
+		os.Exit(1)
+	}
+	if *extAlias != "" {
+		aliases, err := parseExtAliases(*extAlias)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --ext-alias: %v\n", err)
+			os.Exit(1)
+		}
+		extAliases = aliases
+	}
+	if *pathBase != "" {
+		base, err := filepath.Abs(*pathBase)
+		if err != nil {
+			fatal(err)
+		}
+		reportBase = base
+	}
+	weights := defaultROIWeights
+	if *roiWeights != "" {
+		w, err := parseROIWeights(*roiWeights)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --roi-weights: %v\n", err)
+			os.Exit(1)
+		}
+		weights = w
+	}
+	thresholds, err := parseBadgeThresholds(*badgeThresholds)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --badge-thresholds: %v\n", err)
+		os.Exit(1)
+	}
+	var seed *SeedSpec
+	if *findSpec != "" {
+		s, err := parseSeedSpec(*findSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --find: %v\n", err)
+			os.Exit(1)
+		}
+		seed = &s
+	}
+	var execArgsTemplate []string
+	if *execTemplate != "" {
+		args, err := parseExecTemplate(*execTemplate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --exec: %v\n", err)
+			os.Exit(1)
+		}
+		execArgsTemplate = args
+	}
+	if *anonymize && *githubAnnotations {
+		// Annotations attach to real files in the PR; hashed paths can't be placed
+		fmt.Fprintf(os.Stderr, "Error: --anonymize can't be combined with --github-annotations\n")
+		os.Exit(1)
+	}
+
+	// Select strategy
+	strategies := newStrategies()
+	if s, ok := strategies[*strategyName]; ok {
+		activeStrategy = s
+	} else {
+		fmt.Fprintf(os.Stderr, "Unknown strategy: %s\n", *strategyName)
+		os.Exit(1)
+	}
+
+	// Handle compare mode
+	if *compare != "" {
+		parts := strings.Split(*compare, "..")
+		if len(parts) != 2 {
+			fmt.Fprintf(os.Stderr, "Error: --compare requires format 'base..head'\n")
+			os.Exit(1)
+		}
+		baseRef, headRef := parts[0], parts[1]
+		// Extract subdir from path if it's not "."
+		subdir := ""
+		if *path != "." {
+			subdir = *path
+		}
+		if *reportUnchanged != "" && *reportUnchanged != "all" && *reportUnchanged != "touched" {
+			fmt.Fprintf(os.Stderr, "Error: --report-unchanged must be 'all' or 'touched'\n")
+			os.Exit(1)
+		}
+		runCompare(baseRef, headRef, subdir, *strategyName, *reportUnchanged)
+		return
+	}
+
+	// Parse exclude patterns
+	var excludePatterns []string
+	if *exclude != "" {
+		for _, p := range strings.Split(*exclude, ",") {
+			p = strings.TrimSpace(p)
+			if p != "" {
+				excludePatterns = append(excludePatterns, p)
+			}
+		}
+	}
+
+	// Build set of changed files if --git-diff is specified
+	changedFiles := make(map[string]bool)
+	if *gitDiff != "" {
+		cmd := exec.Command("git", "diff", "--name-only", *gitDiff)
+		output, err := cmd.Output()
+		if err == nil {
+			for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+				if line != "" {
+					changedFiles[line] = true
+				}
+			}
+		}
+	}
+
+	startTime := time.Now()
+
+	folder := *path
+	extension := *ext
+	singleFile := ""
+	if *dumpEntriesPath != "" {
+		singleFile = *dumpEntriesPath
+	} else if *filePath != "" {
+		singleFile = *filePath
+	} else if info, err := os.Stat(*path); err == nil && !info.IsDir() {
+		singleFile = *path
+	}
+	if singleFile != "" {
+		info, err := os.Stat(singleFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: file not found: %s\n", singleFile)
+			os.Exit(1)
+		}
+		if info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Error: --file expects a file path: %s\n", singleFile)
+			os.Exit(1)
+		}
+		folder = filepath.Dir(singleFile)
+		extension = fileType(singleFile)
+		if extension == "" {
+			extension = *ext
+		}
+	}
+	extension = strings.Join(splitExtensions(strings.ToLower(extension)), ",")
+
+	if err := registerLanguages(configFile.Languages); err != nil {
+		fatal(err)
+	}
+
+	// Comment prefixes are resolved per file from its extension unless overridden
+	commentOverride = *comment
+
+	if *dumpEntriesPath != "" {
+		if err := dumpEntries(singleFile); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	// What a cut-short scan writes as partial results
+	outputPath := filepath.Join(folder, ".quickdup", *strategyName+"-results.json")
+	config := &JSONConfig{
+		Version:          toolVersion(),
+		Strategy:         *strategyName,
+		Extension:        extension,
+		MinOccur:         *minOccur,
+		MinMode:          *minMode,
+		MinScore:         *minScore,
+		MinScorePerLine:  *minScorePerLine,
+		MinSize:          *minSize,
+		MaxSize:          *maxSize,
+		OnlyGrown:        *onlyGrown,
+		MinDirs:          *minDirs,
+		MinSimilarity:    *minSimilarity,
+		MaxSimilarity:    *maxSimilarity,
+		SimilarityFloor:  similarityFloor,
+		SimilarityMetric: *similarityMetricName,
+		ScorePreset:      *scorePresetName,
+		Exclude:          excludePatterns,
+		ParseOptions:     parseOptionsKey(),
+		Overrides:        configFile.Overrides,
+	}
+	if testsMode != TestsInclude {
+		config.Tests = testsMode
+	}
+	if testsMode == TestsSeparate {
+		config.TestMinOccur = *testMinOccur
+	}
+	minLength := 0
+	if *onlyGrown {
+		minLength = *minSize + 1
+	}
+	tierConfig := TierConfig{
+		ActionableScore:      *actionableScore,
+		ActionableSimilarity: *actionableSimilarity,
+		NoiseScore:           *noiseScore,
+	}
+
+	// Scans outside the main pipeline: many over HTTP, or one of standard input
+	if serveMode || *stdinMode {
+		scanConfig := ScanConfig{
+			Root:         folder,
+			Strategy:     *strategyName,
+			Extension:    extension,
+			Exclude:      excludePatterns,
+			MinSize:      *minSize,
+			MaxSize:      *maxSize,
+			DetectOccur:  lowestMinOccur(*minOccur, configFile.Overrides),
+			KeepOverlaps: *keepOverlaps,
+			Filter: FilterConfig{
+				MinOccur:        *minOccur,
+				MinMode:         *minMode,
+				MinScore:        *minScore,
+				MinScorePerLine: *minScorePerLine,
+				MinLength:       minLength,
+				MinSimilarity:   *minSimilarity,
+				MaxSimilarity:   *maxSimilarity,
+				MinDirs:         *minDirs,
+				ExcludeData:     *excludeData,
+				Representative:  *representative,
+				Overrides:       configFile.Overrides,
+			},
+			Tiers:  tierConfig,
+			Config: config,
+		}
+		if serveMode {
+			fatal(runServe(*listen, scanConfig))
+		}
+		if err := runStdin(resultsOut, scanConfig); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	// Load user-ignored hashes from ignore.json
+	warnings := &Warnings{}
+	userIgnored := LoadIgnoredHashes(folder, *strategyName, warnings)
+	PrintIgnoredPatterns(len(userIgnored))
+	expected, snippets := LoadExpectedHashes(folder, *minSize, warnings)
+	PrintExpectedSnippets(snippets)
+	userIgnored = mergeIgnored(userIgnored, expected)
+
+	// First pass: count files
+	scanRoot := folder
+	if singleFile != "" {
+		scanRoot = singleFile
+	}
+	files, err := collectFiles(scanRoot, extension, excludePatterns, warnings)
+	if err != nil {
+		fatal(err)
+	}
+	var identical []IdenticalFiles
+	if !*keepIdentical {
+		files, identical = dedupIdenticalFiles(files)
+	}
+	if *anonymize {
+		anonymizer = &PathAnonymizer{}
+	}
+	reportIdenticalFiles(identical)
+
+	totalFiles := len(files)
+	if totalFiles == 0 {
+		fmt.Printf("No %s files found in %s\n", extension, folder)
+		os.Exit(0)
+	}
+
+	// Analyze mode: run every strategy and compare, instead of reporting findings
+	if analyzeMode {
+		runAnalyze(files, folder, strategies, warnings, *minOccur, *minScore, *minSize, *maxSize, *minSimilarity, *keepOverlaps)
+		return
+	}
+
+	if !*summaryOnly && !*worst && !*githubAnnotations && seed == nil {
+		scan.SetOutput(outputPath, ResultsExtras{IdenticalFiles: identical, Config: config})
+	}
+
+	// Phase 1: Parse all files in parallel (with caching)
+	PrintScanStart(totalFiles, runtime.NumCPU())
+
+	parseStart := time.Now()
+	progress.PhaseStart("parse")
+	scan.Phase("parse")
+	cacheDir := filepath.Join(folder, ".quickdup")
+	if *cacheDirFlag != "" {
+		cacheDir = *cacheDirFlag
+	}
+	var cache *FileCache
+	if !*noCache {
+		var skipped string
+		cache, skipped = loadCache(cacheDir, *strategyName)
+		PrintCacheSkipped(skipped)
+	}
+
+	suppressions := &Suppressions{}
+	var cacheWriter *CacheWriter
+	if !*noCache {
+		cacheWriter = newCacheWriter(cacheDir, *strategyName, suppressions)
+		scan.SetCache(cacheWriter)
+	}
+	fileData, cacheHits, cacheMisses := parseFilesWithCache(files, cache, cacheWriter, warnings, suppressions)
+
+	// Save updated cache
+	cacheWriter.Flush()
+	parseTime := time.Since(parseStart)
+	progress.PhaseEnd("parse")
+
+	// Count total lines of code (non-blank, non-comment)
+	totalLines := 0
+	for _, entries := range fileData {
+		totalLines += len(entries)
+	}
+
+	PrintParseComplete(len(fileData), cacheHits, cacheMisses, totalLines, parseTime)
+	PrintSkippedBinary(warnings.Count("binary"))
+	PrintSkippedOversized(warnings.Count("minified"), warnings.Count("too-long"), maxLineLength, maxFileLines)
+	PrintSkippedIdentical(identical)
+
+	// Targeted search: copies of one snippet instead of a duplication report
+	if seed != nil {
+		entries, err := seedEntries(*seed, fileData)
+		if err != nil {
+			fatal(err)
+		}
+		found := findSeed(*seed, entries, fileData)
+		for i := range found {
+			found[i].Filename = reportPath(found[i].Filename)
+		}
+		if stdoutFormat == "grep" {
+			PrintSeedGrep(resultsOut, found, len(entries))
+		} else {
+			PrintSeedMatches(*seed, len(entries), found)
+		}
+		PrintWarnings(reportWarnings(warnings.Items()))
+		return
+	}
+
+	// Tests get their own report; the main one covers production code
+	codeData := fileData
+	var testData map[string][]Entry
+	if testsMode == TestsSeparate {
+		codeData, testData = splitTestFiles(scanRoot, fileData)
+	}
+
+	// Phase 2: Pattern detection with growth
+	detectStart := time.Now()
+	PrintDetectStart()
+	progress.PhaseStart("detect")
+	scan.Phase("detect")
+	// Overrides may report with fewer occurrences than -min, so detection keeps those
+	detectMinOccur := lowestMinOccur(*minOccur, configFile.Overrides)
+	patterns := detectPatterns(codeData, len(codeData), detectMinOccur, *minSize, *maxSize, *keepOverlaps)
+	var testPatterns map[uint64][]PatternLocation
+	if len(testData) > 0 {
+		detectTestOccur := lowestMinOccur(*testMinOccur, configFile.Overrides)
+		testPatterns = detectPatterns(testData, len(testData), detectTestOccur, *minSize, *maxSize, *keepOverlaps)
+	}
+	var repeats []RepeatedRun
+	if *minRepeats > 0 {
+		ignored := activeStrategy.BlockedHashes()
+		for hash := range userIgnored {
+			ignored[hash] = true
+		}
+		repeats = findRepeatedRuns(codeData, *minSize, *minRepeats, *minSimilarity, ignored)
+	}
+	var queries []SQLDuplicate
+	if *sqlQueries {
+		queries = findDuplicateQueries(files, *minOccur, warnings)
+	}
+	var numbers []MagicNumber
+	if *magicNumbers {
+		numbers = findMagicNumbers(files, *minOccur, warnings)
+	}
+	var imports []CommonImport
+	if *importReport {
+		imports = findCommonImports(files, *minOccur, warnings)
+	}
+	var accessors []AccessorClass
+	if *minAccessors > 0 {
+		accessors = findAccessorClasses(files, *minAccessors, warnings)
+	}
+	detectTime := time.Since(detectStart)
+	progress.PhaseEnd("detect")
+	PrintDetectComplete(detectTime)
+
+	if err := profile.WriteCSV(*profileOutput); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --profile-output: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *baselineUpdate {
+		// Test duplication still present keeps its entries too
+		kept, removed, err := PruneIgnoredHashes(folder, *strategyName, patterns, testPatterns)
+		if err != nil {
+			fatal(err)
+		}
+		PrintBaselineUpdate(kept, removed)
+	}
+
+	// Filter and score matches
+	filterStart := time.Now()
+	progress.PhaseStart("filter")
+	scan.Phase("filter")
+	var similarities *SimilarityCache
+	if !*noCache {
+		similarities = loadSimilarityCache(cacheDir, *strategyName, similarityCacheKey(*strategyName, *similarityMetricName, codeData))
+	}
+	filterConfig := FilterConfig{
+		MinOccur:        *minOccur,
+		MinMode:         *minMode,
+		MinScore:        *minScore,
+		MinScorePerLine: *minScorePerLine,
+		MinLength:       minLength,
+		MinSimilarity:   *minSimilarity,
+		MaxSimilarity:   *maxSimilarity,
+		MinDirs:         *minDirs,
+		UserIgnored:     userIgnored,
+		Suppressed:      suppressions,
+		ExcludeData:     *excludeData,
+		Similarities:    similarities,
+		Representative:  *representative,
+		Overrides:       configFile.Overrides,
+		Root:            folder,
+	}
+	matches, filterStats := FilterPatterns(patterns, filterConfig)
+	similarities.save(cacheDir, *strategyName)
+	if *mergeAdjacent {
+		matches = mergeAdjacentOccurrences(matches)
+	}
+	assignTiers(matches, tierConfig)
+	var fuzzy []FuzzyDuplicate
+	if *shingles > 0 {
+		fuzzy = findFuzzyDuplicates(codeData, *shingles, *fuzzyOverlap, matches)
+	}
+	var tokenClones []TokenClone
+	if *tokenStream > 0 {
+		tokenClones = findTokenClones(codeData, *tokenStream, matches)
+	}
+	duplication := 0.0
+	if *badge {
+		duplication = duplicationPercent(matches, codeData) // before report paths replace file names
+	}
+	var testMatches []PatternMatch
+	if testData != nil {
+		testFilter := filterConfig
+		testFilter.MinOccur = *testMinOccur
+		testFilter.Similarities = nil // cached for the production patterns
+		testMatches = findTestDuplicates(testPatterns, testFilter)
+		assignTiers(testMatches, tierConfig)
+	}
+	reportFindings(matches, repeats, fuzzy, tokenClones, queries, numbers, imports, accessors)
+	reportFindings(testMatches, nil, nil, nil, nil, nil, nil, nil)
+	scan.SetMatches(matches)
+	filterTime := time.Since(filterStart)
+	progress.PhaseEnd("filter")
+
+	// Report results
+	scoreThreshold := fmt.Sprintf("%d", *minScore)
+	if *minScorePerLine > 0 {
+		scoreThreshold = fmt.Sprintf("%g × lines", *minScorePerLine)
+	}
+	PrintFilterComplete(filterTime, filterStats.SkippedBlocked, filterStats.SkippedLowScore, filterStats.SkippedLowSimilarity, filterStats.SkippedHighSimilarity, filterStats.SkippedSuppressed, filterStats.SkippedDataDefinition, filterStats.SkippedUngrown, filterStats.SkippedFewDirs, scoreThreshold, *minSimilarity, *maxSimilarity, *minDirs)
+	PrintSimilarityReuse(similarities.Hits())
+
+	// CI gate: fail once the report is out, whichever way this run ends
+	if reason := gateFailure(matches, *failOnScore, *failOnCount); reason != "" {
+		defer func() {
+			PrintGateFailure(reason)
+			progress.Close()
+			os.Exit(2)
+		}()
+	}
+
+	// Dashboards: just the numbers, no report and no results file
+	if *summaryOnly {
+		PrintSummaryOnly(resultsOut, fileFormatSet["json"], len(matches), len(fileData), totalLines, time.Since(startTime))
+		return
+	}
+
+	// Fast triage: show just the worst offender and stop
+	if *worst {
+		PrintDetailedMatches(TopN(matches, 1))
+		PrintTotalSummary(len(matches), len(fileData), totalLines, time.Since(startTime))
+		return
+	}
+
+	top := TopN(matches, *topN)
+
+	// Annotate top matches with git blame age and keep only recent ones
+	if *blameAge > 0 {
+		now := time.Now()
+		annotateBlameAges(top)
+		top = filterByBlameAge(top, *blameAge, now)
+		PrintBlameAges(top, *blameAge, now)
+	}
+
+	switch stdoutFormat {
+	case "grep":
+		PrintGrepLocations(resultsOut, top)
+	case "text":
+		switch {
+		case *tiers && *compact:
+			PrintTieredMatches(matches, *topN, PrintMatchesCompact)
+		case *tiers:
+			PrintTieredMatches(matches, *topN, func(tierMatches []PatternMatch) {
+				PrintMatches(tierMatches, len(tierMatches))
+			})
+		case *compact:
+			PrintMatchSummary(len(matches), *minOccur, len(top))
+			PrintMatchesCompact(top)
+		}
+	case "terminal-wide":
+		width := terminalWidth()
+		if *tiers {
+			PrintTieredMatches(matches, *topN, func(tierMatches []PatternMatch) {
+				PrintMatchesWide(tierMatches, width)
+			})
+			break
+		}
+		PrintMatchSummary(len(matches), *minOccur, len(top))
+		PrintMatchesWide(top, width)
+	}
+
+	if *githubAnnotations {
+		PrintGitHubAnnotations(top, len(top), *githubLevel, *gitDiff, changedFiles)
+	}
+
+	PrintHotspots(matches, *hotspotMetric)
+	PrintLengthHistogram(matches)
+	filePairs := longestPerFilePair(matches)
+	PrintLongestPerFilePair(filePairs)
+	PrintRepeatedRuns(repeats)
+	PrintFuzzyDuplicates(fuzzy)
+	PrintTokenClones(tokenClones)
+	PrintDuplicateQueries(queries)
+	PrintMagicNumbers(numbers)
+	PrintCommonImports(imports)
+	PrintAccessorClasses(accessors)
+	PrintTestDuplicates(TopN(testMatches, *topN), len(testMatches), *topN)
+	PrintIdenticalFiles(identical)
+
+	if execArgsTemplate != nil {
+		results := runExecHook(execArgsTemplate, top, *execJobs)
+		for _, r := range results {
+			if r.Err != nil {
+				warnings.Add("exec", "", fmt.Errorf("[%016x] %s: %v", r.Hash, strings.Join(r.Args, " "), r.Err))
+			}
+		}
+		PrintExecResults(results)
+	}
+
+	// SARIF is for code scanning uploads, which CI runs alongside annotations
+	sarifPath := *sarifOutput
+	if sarifPath == "" && fileFormatSet["sarif"] {
+		sarifPath = filepath.Join(filepath.Dir(outputPath), "results.sarif")
+	}
+	if sarifPath != "" {
+		if err := WriteSARIF(matches, *strategyName, sarifPath); err != nil {
+			fatal(err)
+		}
+	}
+
+	if *githubAnnotations {
+		elapsed := time.Since(startTime)
+		PrintWarnings(reportWarnings(warnings.Items()))
+		PrintTotalSummary(len(matches), len(fileData), totalLines, elapsed)
+		progress.Summary(len(matches), len(fileData), totalLines, elapsed)
+		return
+	}
+
+	progress.PhaseStart("output")
+	scan.Finish()
+	if err := WriteJSONResults(matches, ResultsExtras{
+		Warnings:       reportWarnings(warnings.Items()),
+		Repeats:        repeats,
+		Fuzzy:          fuzzy,
+		TokenClones:    tokenClones,
+		SQLQueries:     queries,
+		MagicNumbers:   numbers,
+		CommonImports:  imports,
+		Accessors:      accessors,
+		TestMatches:    testMatches,
+		ScannedFiles:   reportScannedFiles(scannedFiles(fileData)),
+		IdenticalFiles: identical,
+		Histogram:      lengthHistogram(matches),
+		FilePairs:      filePairs,
+		Config:         config,
+		Timings: &Timings{
+			ParseMs:  parseTime.Milliseconds(),
+			DetectMs: detectTime.Milliseconds(),
+			FilterMs: filterTime.Milliseconds(),
+			TotalMs:  time.Since(startTime).Milliseconds(),
+			Files:    len(fileData),
+			Lines:    totalLines,
+		},
+	}, outputPath); err != nil {
+		fatal(err)
+	}
+	worklistPath := ""
+	if *worklist {
+		worklistPath = filepath.Join(filepath.Dir(outputPath), "worklist.md")
+		if err := WriteWorklist(matches, weights, worklistPath); err != nil {
+			fatal(err)
+		}
+	}
+	badgePath := ""
+	if *badge {
+		badgePath = filepath.Join(filepath.Dir(outputPath), "badge.json")
+		if err := WriteBadge(newBadge(duplication, thresholds), badgePath); err != nil {
+			fatal(err)
+		}
+	}
+	markdownPath := ""
+	if fileFormatSet["markdown"] {
+		markdownPath = filepath.Join(filepath.Dir(outputPath), "patterns.md")
+		if err := WriteMarkdownReport(top, markdownPath); err != nil {
+			fatal(err)
+		}
+	}
+	if *similarityGraph != "" {
+		if err := WriteSimilarityGraph(buildSimilarityGraphs(top, patterns, filterConfig), *similarityGraph); err != nil {
+			fatal(err)
+		}
+	}
+	if err := anonymizer.WriteMapping(filepath.Join(cacheDir, "anonymize-map.json")); err != nil {
+		fatal(err)
+	}
+	progress.PhaseEnd("output")
+
+	// If --select was provided, show detailed output from the JSON
+	if *selectRange != "" {
+		patterns, err := ReadJSONResults(outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading results: %v\n", err)
+			os.Exit(1)
+		}
+		skip, limit, err := parseSelectRange(*selectRange)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		selected := selectJSONPatterns(patterns, skip, limit)
+		PrintDetailedMatchesFromJSON(selected)
+		PrintShowingPatterns(skip, limit)
+	}
+
+	elapsed := time.Since(startTime)
+	PrintWarnings(reportWarnings(warnings.Items()))
+	PrintTotalSummary(len(matches), len(fileData), totalLines, elapsed)
+	PrintResultsPath(outputPath)
+	if worklistPath != "" {
+		PrintWorklistPath(worklistPath)
+	}
+	if markdownPath != "" {
+		PrintMarkdownPath(markdownPath)
+	}
+	if badgePath != "" {
+		PrintBadgePath(badgePath, duplication)
+	}
+	if sarifPath != "" {
+		PrintSARIFPath(sarifPath)
+	}
+	if *similarityGraph != "" {
+		PrintSimilarityGraphPath(*similarityGraph)
+	}
+	progress.Summary(len(matches), len(fileData), totalLines, elapsed)
+}
+
+// fatal prints err to stderr and exits with status 1
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
+
+// parseSelectRange parses a "skip..limit" string into skip and limit integers
+func parseSelectRange(s string) (skip, limit int, err error) {
+	parts := strings.Split(s, "..")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("--select requires format 'skip..limit' (e.g., 0..3)")
+	}
+	if _, err := fmt.Sscanf(parts[0], "%d", &skip); err != nil {
+		return 0, 0, fmt.Errorf("invalid skip value: %s", parts[0])
+	}
+	if _, err := fmt.Sscanf(parts[1], "%d", &limit); err != nil {
+		return 0, 0, fmt.Errorf("invalid limit value: %s", parts[1])
+	}
+	if skip < 0 || limit < 0 {
+		return 0, 0, fmt.Errorf("skip and limit must be non-negative")
+	}
+	return skip, limit, nil
+}
+
+// selectMatches returns a slice of matches starting at skip with at most limit items
+func selectMatches(matches []PatternMatch, skip, limit int) []PatternMatch {
+	if skip >= len(matches) {
+		return nil
+	}
+	end := skip + limit
+	if end > len(matches) {
+		end = len(matches)
+	}
+	return matches[skip:end]
+}
+
+// selectJSONPatterns returns a slice of JSON patterns starting at skip with at most limit items
+func selectJSONPatterns(patterns []JSONPattern, skip, limit int) []JSONPattern {
+	if skip >= len(patterns) {
+		return nil
+	}
+	end := skip + limit
+	if end > len(patterns) {
+		end = len(patterns)
+	}
+	return patterns[skip:end]
+}
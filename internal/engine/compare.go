@@ -0,0 +1,393 @@
+package engine
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// runCompare compares duplicate patterns between two git commits
+// reportUnchanged is "", "all", or "touched" (only patterns in files the diff touched)
+func runCompare(baseRef, headRef, subdir, strategyName string, reportUnchanged string) {
+	fmt.Printf("Comparing duplicates: %s -> %s\n", baseRef, headRef)
+	if subdir != "" {
+		fmt.Printf("Subdirectory: %s\n", subdir)
+	}
+	fmt.Println()
+
+	// Create temporary worktrees
+	baseDir, err := os.MkdirTemp("", "quickdup-base-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating temp dir: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(baseDir)
+
+	headDir, err := os.MkdirTemp("", "quickdup-head-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating temp dir: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(headDir)
+
+	// Create worktrees
+	fmt.Printf("Creating worktree for %s...\n", baseRef)
+	cmd := exec.Command("git", "worktree", "add", "--detach", baseDir, baseRef)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating base worktree: %v\n%s\n", err, output)
+		os.Exit(1)
+	}
+	defer exec.Command("git", "worktree", "remove", "--force", baseDir).Run()
+
+	fmt.Printf("Creating worktree for %s...\n", headRef)
+	cmd = exec.Command("git", "worktree", "add", "--detach", headDir, headRef)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating head worktree: %v\n%s\n", err, output)
+		os.Exit(1)
+	}
+	defer exec.Command("git", "worktree", "remove", "--force", headDir).Run()
+
+	// Both scans run with the flags given here, so parse and scoring options apply to
+	// base and head alike; unset flags keep their defaults there too
+	var args []string
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "compare", "report-unchanged", "path", "no-cache", "cache-dir", "cache-key":
+			return
+		}
+		args = append(args, fmt.Sprintf("-%s=%s", f.Name, f.Value.String()))
+	})
+	// Worktrees are fresh checkouts, so a parse cache would never hit
+	args = append(args, "-no-cache")
+
+	// Determine scan paths (worktree root or subdir within)
+	baseScanPath := baseDir
+	headScanPath := headDir
+	if subdir != "" {
+		baseScanPath = filepath.Join(baseDir, subdir)
+		headScanPath = filepath.Join(headDir, subdir)
+	}
+
+	// Run quickdup on base
+	fmt.Printf("\nScanning %s...\n", baseRef)
+	baseArgs := append([]string{"-path", baseScanPath}, args...)
+	cmd = exec.Command(os.Args[0], baseArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: quickdup on base returned error: %v\n", err)
+	}
+
+	// Run quickdup on head
+	fmt.Printf("\nScanning %s...\n", headRef)
+	headArgs := append([]string{"-path", headScanPath}, args...)
+	cmd = exec.Command(os.Args[0], headArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: quickdup on head returned error: %v\n", err)
+	}
+
+	// Load results from both
+	baseResults := loadJSONResults(filepath.Join(baseScanPath, ".quickdup", strategyName+"-results.json"))
+	headResults := loadJSONResults(filepath.Join(headScanPath, ".quickdup", strategyName+"-results.json"))
+
+	// Build hash -> occurrences maps
+	baseOccur := make(map[string]int)
+	basePatterns := make(map[string]JSONPattern)
+	for _, p := range baseResults.Patterns {
+		baseOccur[p.Hash] = p.Occurrences
+		basePatterns[p.Hash] = p
+	}
+
+	headOccur := make(map[string]int)
+	headPatterns := make(map[string]JSONPattern)
+	for _, p := range headResults.Patterns {
+		headOccur[p.Hash] = p.Occurrences
+		headPatterns[p.Hash] = p
+	}
+
+	// Find lingering duplicates (reduced but not eliminated)
+	fmt.Printf("\n%s\n", strings.Repeat("=", 60))
+	fmt.Printf("COMPARISON RESULTS: %s -> %s\n", baseRef, headRef)
+	fmt.Printf("%s\n\n", strings.Repeat("=", 60))
+
+	type lingering struct {
+		hash      string
+		baseCount int
+		headCount int
+		removed   int
+		pattern   JSONPattern
+	}
+	var lingeringPatterns []lingering
+
+	for hash, baseCount := range baseOccur {
+		headCount := headOccur[hash]
+		if headCount > 0 && headCount < baseCount {
+			lingeringPatterns = append(lingeringPatterns, lingering{
+				hash:      hash,
+				baseCount: baseCount,
+				headCount: headCount,
+				removed:   baseCount - headCount,
+				pattern:   headPatterns[hash],
+			})
+		}
+	}
+
+	// Sort by removed count descending
+	sort.Slice(lingeringPatterns, func(i, j int) bool {
+		return lingeringPatterns[i].removed > lingeringPatterns[j].removed
+	})
+
+	// Map base paths to head paths so moved files aren't reported as removed
+	renames := gitRenames(baseRef, headRef)
+
+	if len(lingeringPatterns) == 0 {
+		fmt.Printf("No lingering duplicates found. All refactoring appears complete!\n")
+	} else {
+		fmt.Printf("Found %d patterns with incomplete refactoring:\n\n", len(lingeringPatterns))
+		for _, l := range lingeringPatterns {
+			fmt.Printf("%s %s removed, %s lingering - potentially missed refactoring?\n",
+				theme.Hash.Render(fmt.Sprintf("[%s]", l.hash)),
+				theme.Summary.Render(fmt.Sprintf("%d", l.removed)),
+				theme.Score.Render(fmt.Sprintf("%d", l.headCount)))
+			printPatternPreview(l.pattern)
+			if removed := removedFiles(basePatterns[l.hash], l.pattern, baseDir, headDir, renames); len(removed) > 0 {
+				fmt.Printf("  Removed from:\n")
+				for _, f := range removed {
+					fmt.Printf("    %s\n", theme.Dim.Render(f))
+				}
+			}
+			fmt.Printf("  Remaining locations:\n")
+			for _, loc := range l.pattern.Locations {
+				// Make path relative by stripping worktree prefix
+				relPath := strings.TrimPrefix(loc.Filename, headScanPath+"/")
+				fmt.Printf("    %s\n", theme.Location.Render(fmt.Sprintf("%s:%d", relPath, loc.LineStart)))
+			}
+			fmt.Println()
+		}
+	}
+
+	// Report pre-existing duplication the change left untouched
+	if reportUnchanged != "" {
+		var touched map[string]bool
+		if reportUnchanged == "touched" {
+			touched = gitChangedFiles(baseRef, headRef)
+		}
+		printUnchangedPatterns(baseOccur, headPatterns, headDir, headScanPath, touched)
+	}
+
+	// Also report completely removed patterns
+	var fullyRemoved int
+	for hash, baseCount := range baseOccur {
+		if headOccur[hash] == 0 {
+			fullyRemoved++
+			_ = baseCount // unused but shows intent
+		}
+	}
+	if fullyRemoved > 0 {
+		fmt.Printf("\n%s duplicate patterns were completely removed.\n", theme.Summary.Render(fmt.Sprintf("%d", fullyRemoved)))
+	}
+
+	// Report new patterns with their code: the duplication this change introduced
+	var newPatterns []JSONPattern
+	for hash, p := range headPatterns {
+		if baseOccur[hash] == 0 {
+			newPatterns = append(newPatterns, p)
+		}
+	}
+	if len(newPatterns) > 0 {
+		fmt.Printf("%s new duplicate patterns were introduced.\n", theme.Score.Render(fmt.Sprintf("%d", len(newPatterns))))
+		printNewPatterns(newPatterns, headScanPath)
+	}
+}
+
+// newPatternPreviewLines caps the code shown per new pattern
+const newPatternPreviewLines = 20
+
+// printNewPatterns lists patterns unique to head by score, with their head locations
+// and the code of the representative occurrence, read from the head worktree
+func printNewPatterns(patterns []JSONPattern, headScanPath string) {
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].Score != patterns[j].Score {
+			return patterns[i].Score > patterns[j].Score
+		}
+		return patterns[i].Hash < patterns[j].Hash
+	})
+
+	fmt.Printf("\nNew duplication introduced by this change:\n")
+	for _, p := range patterns {
+		fmt.Printf("\n%s %s occurrences, score %s, %s\n",
+			theme.Hash.Render(fmt.Sprintf("[%s]", p.Hash)),
+			theme.Summary.Render(fmt.Sprintf("%d", p.Occurrences)),
+			theme.Score.Render(fmt.Sprintf("%d", p.Score)),
+			renderSimilarity(p.Similarity))
+		rep := p.Locations[0]
+		for _, loc := range p.Locations {
+			relPath := strings.TrimPrefix(loc.Filename, headScanPath+"/")
+			fmt.Printf("    %s\n", theme.Location.Render(fmt.Sprintf("%s:%s", relPath, jsonLocationLines(loc))))
+			if loc.Canonical {
+				rep = loc
+			}
+		}
+
+		lines := readSourceLines(rep.Filename, rep.LineStart, min(p.Lines, newPatternPreviewLines))
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("```%s\n", langForFile(rep.Filename)))
+		for _, line := range lines {
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString("```\n")
+		renderWithGlow(sb.String())
+		if p.Lines > newPatternPreviewLines {
+			fmt.Printf("  %s\n", theme.Dim.Render(fmt.Sprintf("(+%d more lines)", p.Lines-newPatternPreviewLines)))
+		}
+	}
+}
+
+// printUnchangedPatterns lists patterns with the same occurrence count in base and head.
+// If touched is non-nil, only patterns with a location in a touched file are listed.
+func printUnchangedPatterns(baseOccur map[string]int, headPatterns map[string]JSONPattern, headDir, headScanPath string, touched map[string]bool) {
+	var unchanged []JSONPattern
+	for hash, p := range headPatterns {
+		if baseOccur[hash] != p.Occurrences {
+			continue
+		}
+		if touched != nil && !touchesAny(p, headDir, touched) {
+			continue
+		}
+		unchanged = append(unchanged, p)
+	}
+	sort.Slice(unchanged, func(i, j int) bool {
+		if unchanged[i].Score != unchanged[j].Score {
+			return unchanged[i].Score > unchanged[j].Score
+		}
+		return unchanged[i].Hash < unchanged[j].Hash
+	})
+
+	scope := ""
+	if touched != nil {
+		scope = " in touched files"
+	}
+	if len(unchanged) == 0 {
+		fmt.Printf("\nNo unchanged duplicates%s.\n", scope)
+		return
+	}
+	fmt.Printf("\nFound %d unchanged duplicate patterns%s:\n\n", len(unchanged), scope)
+	for _, p := range unchanged {
+		fmt.Printf("%s %s occurrences, score %s\n",
+			theme.Hash.Render(fmt.Sprintf("[%s]", p.Hash)),
+			theme.Summary.Render(fmt.Sprintf("%d", p.Occurrences)),
+			theme.Score.Render(fmt.Sprintf("%d", p.Score)))
+		printPatternPreview(p)
+		for _, loc := range p.Locations {
+			relPath := strings.TrimPrefix(loc.Filename, headScanPath+"/")
+			fmt.Printf("    %s\n", theme.Location.Render(fmt.Sprintf("%s:%d", relPath, loc.LineStart)))
+		}
+		fmt.Println()
+	}
+}
+
+// touchesAny reports whether any location of p is in a touched file (paths relative to repo root)
+func touchesAny(p JSONPattern, headDir string, touched map[string]bool) bool {
+	for _, loc := range p.Locations {
+		if touched[filepath.ToSlash(strings.TrimPrefix(loc.Filename, headDir+"/"))] {
+			return true
+		}
+	}
+	return false
+}
+
+// removedFiles returns files (relative to repo root) that lost occurrences of a pattern
+// between base and head. Base paths are mapped through renames first, so a file that
+// was only moved isn't reported as removed.
+func removedFiles(base, head JSONPattern, baseDir, headDir string, renames map[string]string) []string {
+	counts := make(map[string]int)
+	for _, loc := range base.Locations {
+		rel := filepath.ToSlash(strings.TrimPrefix(loc.Filename, baseDir+"/"))
+		if renamed, ok := renames[rel]; ok {
+			rel = renamed
+		}
+		counts[rel]++
+	}
+	for _, loc := range head.Locations {
+		counts[filepath.ToSlash(strings.TrimPrefix(loc.Filename, headDir+"/"))]--
+	}
+
+	var removed []string
+	for f, c := range counts {
+		if c > 0 {
+			removed = append(removed, f)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// gitRenames returns a map of base path -> head path for files renamed between two refs
+func gitRenames(baseRef, headRef string) map[string]string {
+	renames := make(map[string]string)
+	output, err := exec.Command("git", "diff", "--find-renames", "--name-status", baseRef, headRef).Output()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: git rename detection failed: %v\n", err)
+		return renames
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		// Rename lines look like "R095<TAB>old/path<TAB>new/path"
+		fields := strings.Split(line, "\t")
+		if len(fields) == 3 && strings.HasPrefix(fields[0], "R") {
+			renames[fields[1]] = fields[2]
+		}
+	}
+	return renames
+}
+
+// gitChangedFiles returns the set of files changed between two refs (paths relative to repo root)
+func gitChangedFiles(baseRef, headRef string) map[string]bool {
+	changed := make(map[string]bool)
+	output, err := exec.Command("git", "diff", "--name-only", baseRef, headRef).Output()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: git diff %s %s failed: %v\n", baseRef, headRef, err)
+		return changed
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			changed[line] = true
+		}
+	}
+	return changed
+}
+
+// printPatternPreview prints the first line of a pattern's code, if the results include it
+func printPatternPreview(p JSONPattern) {
+	if len(p.Pattern) == 0 {
+		return
+	}
+	preview := truncate(p.Pattern[0], 80)
+	if len(p.Pattern) > 1 {
+		preview += theme.Dim.Render(fmt.Sprintf(" (+%d lines)", len(p.Pattern)-1))
+	}
+	fmt.Printf("  %s\n", preview)
+}
+
+func loadJSONResults(path string) JSONOutput {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return JSONOutput{}
+	}
+	var output JSONOutput
+	json.Unmarshal(data, &output)
+	return output
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
@@ -0,0 +1,326 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configFileName is the per-repository config file, read from the scan path
+const configFileName = ".quickdup.json"
+
+// tomlConfigFileName is the TOML alternative to .quickdup.json, for flag defaults only
+const tomlConfigFileName = ".quickdup.toml"
+
+// ConfigFile is the contents of .quickdup.json (or .quickdup.toml)
+type ConfigFile struct {
+	// Flag defaults, named after their flags; flags given on the command line win
+	Path          *string  `json:"path,omitempty"` // relative to the config file's directory
+	Ext           *string  `json:"ext,omitempty"`
+	Min           *int     `json:"min,omitempty"`
+	MinScore      *int     `json:"min_score,omitempty"`
+	MinSize       *int     `json:"min_size,omitempty"`
+	MinSimilarity *float64 `json:"min_similarity,omitempty"`
+	Exclude       []string `json:"exclude,omitempty"`
+	Strategy      *string  `json:"strategy,omitempty"`
+
+	// Overrides set thresholds for parts of the tree; the first matching path wins
+	Overrides []ThresholdOverride `json:"overrides,omitempty"`
+	// Languages override or extend the built-in language rules, keyed by extension
+	Languages map[string]LanguageConfig `json:"languages,omitempty"`
+}
+
+// ThresholdOverride replaces the global thresholds for matches whose primary
+// location (the first by path) is under Path. Unset fields keep the global value.
+type ThresholdOverride struct {
+	Path          string   `json:"path"` // glob relative to the scan path; ** spans directories
+	MinOccur      *int     `json:"min,omitempty"`
+	MinScore      *int     `json:"min_score,omitempty"`
+	MinSimilarity *float64 `json:"min_similarity,omitempty"`
+}
+
+// Thresholds are the filter thresholds applied to one match
+type Thresholds struct {
+	MinOccur      int
+	MinScore      int
+	MinSimilarity float64
+}
+
+// loadConfigFile reads .quickdup.json or .quickdup.toml from folder; a missing file
+// is an empty config. Unknown keys are errors, so a typo doesn't go unnoticed.
+func loadConfigFile(folder string) (*ConfigFile, error) {
+	path := filepath.Join(folder, configFileName)
+	tomlPath := filepath.Join(folder, tomlConfigFileName)
+	data, err := os.ReadFile(path)
+	tomlData, tomlErr := os.ReadFile(tomlPath)
+	switch {
+	case err == nil && tomlErr == nil:
+		return nil, &ScanError{Op: "read config", Path: folder, Err: fmt.Errorf("both %s and %s exist; keep one", configFileName, tomlConfigFileName)}
+	case os.IsNotExist(err) && os.IsNotExist(tomlErr):
+		return &ConfigFile{}, nil
+	case os.IsNotExist(err):
+		if tomlErr != nil {
+			return nil, &ScanError{Op: "read config", Path: tomlPath, Err: tomlErr}
+		}
+		if data, err = tomlToJSON(tomlData); err != nil {
+			return nil, &ScanError{Op: "parse config", Path: tomlPath, Err: err}
+		}
+		path = tomlPath
+	case err != nil:
+		return nil, &ScanError{Op: "read config", Path: path, Err: err}
+	}
+	var config ConfigFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, &ScanError{Op: "parse config", Path: path, Err: err}
+	}
+	for i, o := range config.Overrides {
+		if err := o.validate(); err != nil {
+			return nil, &ScanError{Op: "parse config", Path: path, Err: fmt.Errorf("overrides[%d]: %w", i, err)}
+		}
+	}
+	return &config, nil
+}
+
+// flagValues returns the flag defaults the config sets, by flag name; path is made
+// relative to dir, the config file's directory
+func (c *ConfigFile) flagValues(dir string) map[string]string {
+	values := make(map[string]string)
+	if c.Path != nil {
+		values["path"] = filepath.Join(dir, *c.Path)
+	}
+	if c.Ext != nil {
+		values["ext"] = *c.Ext
+	}
+	if c.Min != nil {
+		values["min"] = strconv.Itoa(*c.Min)
+	}
+	if c.MinScore != nil {
+		values["min-score"] = strconv.Itoa(*c.MinScore)
+	}
+	if c.MinSize != nil {
+		values["min-size"] = strconv.Itoa(*c.MinSize)
+	}
+	if c.MinSimilarity != nil {
+		values["min-similarity"] = strconv.FormatFloat(*c.MinSimilarity, 'g', -1, 64)
+	}
+	if c.Exclude != nil {
+		values["exclude"] = strings.Join(c.Exclude, ",")
+	}
+	if c.Strategy != nil {
+		values["strategy"] = *c.Strategy
+	}
+	return values
+}
+
+// applyConfigFlags sets the flags the config file gives defaults for, except those
+// given on the command line
+func applyConfigFlags(config *ConfigFile, dir string) error {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for name, value := range config.flagValues(dir) {
+		if explicit[name] {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// tomlToJSON converts the flat subset of TOML .quickdup.toml is written in (top-level
+// key = value pairs of strings, numbers, booleans and single-line arrays) to JSON, for
+// decoding like .quickdup.json. Tables are rejected: overrides and languages need
+// .quickdup.json.
+func tomlToJSON(data []byte) ([]byte, error) {
+	doc := make(map[string]any)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("line %d: tables are not supported in %s; use %s for overrides and languages", i+1, tomlConfigFileName, configFileName)
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		key, raw = strings.TrimSpace(key), strings.TrimSpace(raw)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		if _, dup := doc[key]; dup {
+			return nil, fmt.Errorf("line %d: %s is set twice", i+1, key)
+		}
+		value, err := tomlValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", i+1, key, err)
+		}
+		doc[key] = value
+	}
+	return json.Marshal(doc)
+}
+
+// tomlValue parses a TOML scalar or single-line array
+func tomlValue(raw string) (any, error) {
+	switch {
+	case strings.HasPrefix(raw, "[") && strings.HasSuffix(raw, "]"):
+		var items []any
+		for _, part := range splitTOMLArray(raw[1 : len(raw)-1]) {
+			item, err := tomlValue(part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case strings.HasPrefix(raw, `"`):
+		return strconv.Unquote(raw)
+	case strings.HasPrefix(raw, "'") && strings.HasSuffix(raw, "'") && len(raw) >= 2:
+		return raw[1 : len(raw)-1], nil
+	case raw == "true" || raw == "false":
+		return raw == "true", nil
+	}
+	if n, err := strconv.ParseFloat(strings.ReplaceAll(raw, "_", ""), 64); err == nil {
+		return n, nil
+	}
+	return nil, fmt.Errorf("invalid value %s", raw)
+}
+
+// stripTOMLComment cuts a # comment that isn't inside a string
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitTOMLArray splits array items at commas outside strings, dropping a trailing comma
+func splitTOMLArray(s string) []string {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+func (o ThresholdOverride) validate() error {
+	switch {
+	case o.Path == "":
+		return fmt.Errorf("path is required")
+	case o.MinOccur != nil && *o.MinOccur < 2:
+		return fmt.Errorf("min must be >= 2")
+	case o.MinSimilarity != nil && (*o.MinSimilarity < 0 || *o.MinSimilarity > 1):
+		return fmt.Errorf("min_similarity must be between 0.0 and 1.0")
+	}
+	return nil
+}
+
+// lowestMinOccur returns the smallest occurrence count any path may report with, which
+// pattern detection has to keep
+func lowestMinOccur(minOccur int, overrides []ThresholdOverride) int {
+	for _, o := range overrides {
+		if o.MinOccur != nil {
+			minOccur = min(minOccur, *o.MinOccur)
+		}
+	}
+	return minOccur
+}
+
+// thresholdsFor returns the thresholds for a match with the given occurrences: the
+// global ones, or those of the first override matching its primary location
+func thresholdsFor(global Thresholds, overrides []ThresholdOverride, root string, locs []PatternLocation) Thresholds {
+	if len(overrides) == 0 || len(locs) == 0 {
+		return global
+	}
+	primary := locs[0]
+	for _, loc := range locs[1:] {
+		if locationLess(loc, primary) {
+			primary = loc
+		}
+	}
+	rel, err := filepath.Rel(root, primary.Filename)
+	if err != nil {
+		rel = primary.Filename
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, o := range overrides {
+		if !matchPathGlob(o.Path, rel) {
+			continue
+		}
+		t := global
+		if o.MinOccur != nil {
+			t.MinOccur = *o.MinOccur
+		}
+		if o.MinScore != nil {
+			t.MinScore = *o.MinScore
+		}
+		if o.MinSimilarity != nil {
+			t.MinSimilarity = *o.MinSimilarity
+		}
+		return t
+	}
+	return global
+}
+
+// matchPathGlob matches a slash-separated path against a glob in which ** matches any
+// number of directories (including none) and other segments use filepath.Match
+func matchPathGlob(pattern, path string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+func matchSegments(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
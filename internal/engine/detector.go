@@ -0,0 +1,278 @@
+package engine
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// filterOverlappingOccurrences removes adjacent occurrences within the same file
+// For occurrences at positions N and N+1, only keeps N (the earlier one)
+func filterOverlappingOccurrences(locs []PatternLocation, patternLen int) []PatternLocation {
+	if len(locs) <= 1 {
+		return locs
+	}
+
+	// Group by filename
+	byFile := make(map[string][]PatternLocation)
+	for _, loc := range locs {
+		byFile[loc.Filename] = append(byFile[loc.Filename], loc)
+	}
+
+	var result []PatternLocation
+	for _, fileLocs := range byFile {
+		if len(fileLocs) == 1 {
+			result = append(result, fileLocs[0])
+			continue
+		}
+
+		// Sort by EntryIndex
+		sort.Slice(fileLocs, func(i, j int) bool {
+			return fileLocs[i].EntryIndex < fileLocs[j].EntryIndex
+		})
+
+		// Keep non-overlapping: if positions overlap, keep only the first
+		lastEnd := -1
+		for _, loc := range fileLocs {
+			if loc.EntryIndex >= lastEnd {
+				result = append(result, loc)
+				lastEnd = loc.EntryIndex + patternLen
+			}
+		}
+	}
+
+	return result
+}
+
+func detectPatterns(fileData map[string][]Entry, totalFiles int, minOccur int, minSize int, maxSize int, keepOverlaps bool) map[uint64][]PatternLocation {
+	numWorkers := runtime.NumCPU()
+
+	// Build file list for parallel iteration
+	files := make([]string, 0, len(fileData))
+	for f := range fileData {
+		files = append(files, f)
+	}
+
+	// Step 1: Generate base patterns in parallel (per file)
+	basePatterns := generateBasePatternsParallel(fileData, files, minSize, numWorkers)
+	if debugEnabled {
+		fmt.Printf("[debug] base patterns: %d (minOccur=%d)\n", len(basePatterns), minOccur)
+	}
+
+	// Step 2: Filter base patterns to >= minOccur
+	survivors := make(map[uint64][]PatternLocation)
+	for hash, locs := range basePatterns {
+		if len(locs) >= minOccur {
+			survivors[hash] = locs
+		}
+	}
+
+	// Step 3: Grow patterns by extending the window
+	allPatterns, grownTo := growPatterns(survivors, fileData, minOccur, minSize, maxSize, keepOverlaps, numWorkers)
+	if maxSize > 0 && grownTo >= maxSize {
+		if !quiet {
+			fmt.Printf("Growth stopped at %d lines (max-size)\n", grownTo)
+		}
+	} else if !quiet {
+		fmt.Printf("Growth stopped at %d lines\n", grownTo)
+	}
+	if !keepOverlaps {
+		splitInterleaved(fileData, allPatterns, minSize, minOccur)
+	}
+	return allPatterns
+}
+
+// generateBasePatternsParallel generates base patterns using parallel workers
+func generateBasePatternsParallel(fileData map[string][]Entry, files []string, minSize int, numWorkers int) map[uint64][]PatternLocation {
+	result := make(map[uint64][]PatternLocation)
+	var mu sync.Mutex
+
+	work := make(chan string, len(files))
+	for _, f := range files {
+		work <- f
+	}
+	close(work)
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			local := make(map[uint64][]PatternLocation)
+
+			for filename := range work {
+				start := time.Now()
+				entries := fileData[filename]
+				n := len(entries)
+
+				for i := 0; i <= n-minSize; i++ {
+					window := entries[i : i+minSize]
+					hash := activeStrategy.Hash(window)
+					patternCopy := make([]Entry, len(window))
+					copy(patternCopy, window)
+
+					local[hash] = append(local[hash], PatternLocation{
+						Filename:   filename,
+						LineStart:  entries[i].GetLineNumber(),
+						EntryIndex: i,
+						Pattern:    patternCopy,
+					})
+				}
+				profile.BasePatterns(filename, time.Since(start), max(0, n-minSize+1))
+			}
+
+			// Merge local results
+			mu.Lock()
+			for hash, locs := range local {
+				result[hash] = append(result[hash], locs...)
+			}
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return result
+}
+
+// growTask is a bucket of occurrences sharing a window hash, to be grown by a line
+type growTask struct {
+	hash   uint64
+	length int
+	locs   []PatternLocation
+}
+
+// growPatterns grows each surviving bucket a line at a time for as long as minOccur of
+// its occurrences still match, returning the patterns at the length each occurrence
+// stopped growing and the longest length reached. A bucket's children at the next
+// length can only come from its own occurrences, so buckets grow independently:
+// numWorkers goroutines take them from one queue, which growing a bucket adds its
+// surviving children to.
+func growPatterns(survivors map[uint64][]PatternLocation, fileData map[string][]Entry, minOccur, minSize, maxSize int, keepOverlaps bool, numWorkers int) (map[uint64][]PatternLocation, int) {
+	allPatterns := make(map[uint64][]PatternLocation)
+	grownTo := minSize - 1
+
+	// Everything below is guarded by mu
+	var mu sync.Mutex
+	ready := sync.NewCond(&mu)
+	queue := make([]growTask, 0, len(survivors))
+	for hash, locs := range survivors {
+		queue = append(queue, growTask{hash, minSize, locs})
+	}
+	open := map[int]int{minSize: len(queue)}    // tasks of each length queued or growing
+	created := map[int]int{minSize: len(queue)} // tasks of each length: its survivors
+	finished := minSize - 1                     // lengths up to this have no open tasks left
+	pending := len(queue)
+
+	// reportGenerations reports each length whose tasks are all done, as a finished
+	// generation of the next length's survivors
+	reportGenerations := func() {
+		for open[finished+1] == 0 && created[finished+1] > 0 {
+			finished++
+			if maxSize > 0 && finished >= maxSize {
+				continue
+			}
+			if debugEnabled {
+				fmt.Printf("[debug] survivors at len=%d: %d\n", finished+1, created[finished+1])
+			}
+			progress.Generation(finished+1, created[finished+1])
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				for len(queue) == 0 && pending > 0 {
+					ready.Wait()
+				}
+				if len(queue) == 0 {
+					mu.Unlock()
+					return
+				}
+				task := queue[0]
+				queue = queue[1:]
+				mu.Unlock()
+
+				children, kept := growBucket(task, fileData, minOccur, maxSize)
+				if !keepOverlaps {
+					kept = filterOverlappingOccurrences(kept, task.length)
+				}
+
+				mu.Lock()
+				if len(kept) >= minOccur {
+					allPatterns[task.hash] = kept
+				}
+				grownTo = max(grownTo, task.length)
+				queue = append(queue, children...)
+				pending += len(children) - 1
+				open[task.length]--
+				open[task.length+1] += len(children)
+				created[task.length+1] += len(children)
+				reportGenerations()
+				if len(children) > 0 || pending == 0 {
+					ready.Broadcast()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return allPatterns, grownTo
+}
+
+// growBucket extends the occurrences of a bucket by one line, returning the buckets of
+// minOccur or more occurrences they grow into and the occurrences that didn't grow
+// into one. A bucket at maxSize doesn't grow.
+func growBucket(task growTask, fileData map[string][]Entry, minOccur, maxSize int) ([]growTask, []PatternLocation) {
+	if maxSize > 0 && task.length >= maxSize {
+		return nil, task.locs
+	}
+
+	// Group the occurrences by the hash of their extended window
+	newLen := task.length + 1
+	next := make(map[uint64][]int)
+	for i, loc := range task.locs {
+		entries := fileData[loc.Filename]
+		if loc.EntryIndex+newLen > len(entries) {
+			continue
+		}
+		hash := activeStrategy.Hash(entries[loc.EntryIndex : loc.EntryIndex+newLen])
+		next[hash] = append(next[hash], i)
+	}
+
+	var children []growTask
+	grew := make([]bool, len(task.locs))
+	for hash, indexes := range next {
+		if len(indexes) < minOccur {
+			continue
+		}
+		child := growTask{hash: hash, length: newLen, locs: make([]PatternLocation, len(indexes))}
+		for j, i := range indexes {
+			loc := task.locs[i]
+			window := fileData[loc.Filename][loc.EntryIndex : loc.EntryIndex+newLen]
+			patternCopy := make([]Entry, len(window))
+			copy(patternCopy, window)
+			child.locs[j] = PatternLocation{
+				Filename:   loc.Filename,
+				LineStart:  loc.LineStart,
+				EntryIndex: loc.EntryIndex,
+				Pattern:    patternCopy,
+			}
+			grew[i] = true
+		}
+		children = append(children, child)
+	}
+
+	kept := make([]PatternLocation, 0, len(task.locs))
+	for i, loc := range task.locs {
+		if !grew[i] {
+			kept = append(kept, loc)
+		}
+	}
+	return children, kept
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"os"
+	"strings"
+)
+
+// dumpEntries parses a single file with the active strategy and prints every source
+// line next to the entry it produced, or the reason it was skipped (--dump-entries)
+func dumpEntries(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	entries, _, err := parseFile(path)
+	if err != nil {
+		return err
+	}
+	byLine := make(map[int]Entry, len(entries))
+	for _, e := range entries {
+		byLine[e.GetLineNumber()] = e
+	}
+
+	PrintDumpHeader(path, activeStrategy.Name(), len(entries))
+	source := strings.Split(string(data), "\n")
+	lang := languageFor(path)
+	preparsed := strings.Split(activeStrategy.Preparse(string(data), &lang), "\n")
+	for i, line := range source {
+		lineNum := i + 1
+		if e, ok := byLine[lineNum]; ok {
+			PrintDumpEntry(lineNum, strings.TrimSuffix(string(e.HashBytes()), "\n"), line)
+			continue
+		}
+		preparsedLine := ""
+		if i < len(preparsed) {
+			preparsedLine = preparsed[i]
+		}
+		PrintDumpSkipped(lineNum, skipReason(line, preparsedLine, &lang), line)
+	}
+	return nil
+}
+
+// skipReason explains why a source line produced no entry, checking the skip layers
+// in the order parseFile and the strategies apply them
+func skipReason(line, preparsed string, lang *Language) string {
+	switch {
+	case isWhitespaceOnly(line):
+		return "blank"
+	case isWhitespaceOnly(preparsed):
+		return "preparse" // block comment, or outside what the strategy parses
+	case maxLineLength > 0 && len(preparsed) > maxLineLength:
+		return "too long"
+	case isCommentOnly(preparsed, lang):
+		return "comment"
+	case shouldSkipByFirstWord(preparsed, lang):
+		return "skip word " + extractFirstWord(preparsed)
+	case groupAnnotations:
+		return "grouped" // an annotation folded into its declaration
+	}
+	return "strategy"
+}
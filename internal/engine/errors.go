@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ScanError describes a fatal failure during one phase of a scan
+type ScanError struct {
+	Op   string // phase that failed, e.g. "walk", "write results"
+	Path string // file or directory involved (may be empty)
+	Err  error
+}
+
+func (e *ScanError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %v", e.Op, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Path, e.Err)
+}
+
+func (e *ScanError) Unwrap() error { return e.Err }
+
+// Warning is a non-fatal issue encountered during a scan (unreadable file, bad ignore.json, ...)
+type Warning struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// Warnings collects non-fatal issues so a single bad file doesn't abort a scan.
+// Safe for concurrent use by parse workers.
+type Warnings struct {
+	mu    sync.Mutex
+	items []Warning
+}
+
+// Add records a warning; a nil collector silently drops it
+func (w *Warnings) Add(kind, path string, err error) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.items = append(w.items, Warning{Kind: kind, Path: path, Message: err.Error()})
+	w.mu.Unlock()
+}
+
+// Items returns a copy of the collected warnings
+func (w *Warnings) Items() []Warning {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Warning(nil), w.items...)
+}
+
+// Count returns how many warnings of the given kind were recorded
+func (w *Warnings) Count(kind string) int {
+	if w == nil {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := 0
+	for _, item := range w.items {
+		if item.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
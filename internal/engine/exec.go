@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ExecResult is the outcome of running the --exec command for one match
+type ExecResult struct {
+	Hash   uint64
+	Args   []string
+	Output []byte // combined stdout and stderr
+	Err    error
+}
+
+// parseExecTemplate splits an --exec template into arguments. Single and double
+// quotes group words as in a shell, but the command runs directly, never through
+// one: substituted paths can't inject commands.
+func parseExecTemplate(template string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range template {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
+
+// execArgs substitutes a match's placeholders into the template arguments. {file},
+// {line} and {end} refer to the canonical occurrence.
+func execArgs(template []string, m PatternMatch) []string {
+	loc := m.Locations[m.Representative]
+	replacer := strings.NewReplacer(
+		"{hash}", fmt.Sprintf("%016x", m.Hash),
+		"{file}", loc.Filename,
+		"{line}", strconv.Itoa(loc.LineStart),
+		"{end}", strconv.Itoa(locationEndLine(loc)),
+		"{lines}", strconv.Itoa(len(m.Pattern)),
+		"{occurrences}", strconv.Itoa(len(m.Locations)),
+		"{score}", strconv.Itoa(m.Score),
+		"{similarity}", fmt.Sprintf("%.2f", m.Similarity),
+	)
+	args := make([]string, len(template))
+	for i, arg := range template {
+		args[i] = replacer.Replace(arg)
+	}
+	return args
+}
+
+// runExecHook runs the template command once per match, at most jobs at a time.
+// Results come back in match order.
+func runExecHook(template []string, matches []PatternMatch, jobs int) []ExecResult {
+	results := make([]ExecResult, len(matches))
+	sem := make(chan struct{}, max(jobs, 1))
+	var wg sync.WaitGroup
+	for i, m := range matches {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			args := execArgs(template, m)
+			output, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+			results[i] = ExecResult{Hash: m.Hash, Args: args, Output: output, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
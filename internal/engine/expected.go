@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// expectedDir holds snippets of expected duplication, under .quickdup
+const expectedDir = "expected"
+
+// LoadExpectedHashes parses every .quickdup/expected/*.txt snippet with the active
+// strategy and returns the hashes of its blocks of minSize lines or more, so matches of
+// the snippet or any part of it are ignored like ignore.json entries; it also returns
+// how many snippets were read. A snippet's language is that of the name before .txt
+// (builder.go.txt is Go). Unreadable snippets are recorded in warnings and skipped.
+func LoadExpectedHashes(dir string, minSize int, warnings *Warnings) (map[uint64]bool, int) {
+	paths, _ := filepath.Glob(filepath.Join(dir, ".quickdup", expectedDir, "*.txt"))
+	hashes := make(map[uint64]bool)
+	snippets := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			warnings.Add("expected-file", path, err)
+			continue
+		}
+		entries, _, err := parseContent(strings.TrimSuffix(path, ".txt"), data)
+		if err != nil {
+			warnings.Add("expected-file", path, err)
+			continue
+		}
+		snippets++
+		for start := 0; start+minSize <= len(entries); start++ {
+			for end := start + minSize; end <= len(entries); end++ {
+				hashes[activeStrategy.Hash(entries[start:end])] = true
+			}
+		}
+	}
+	return hashes, snippets
+}
+
+// mergeIgnored adds the hashes of extra to ignored, allocating it if nil
+func mergeIgnored(ignored, extra map[uint64]bool) map[uint64]bool {
+	if len(extra) == 0 {
+		return ignored
+	}
+	if ignored == nil {
+		ignored = make(map[uint64]bool, len(extra))
+	}
+	for hash := range extra {
+		ignored[hash] = true
+	}
+	return ignored
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// builtinExtAliases maps extensions to the extension whose language rules (comment
+// prefix, skip words, code fence language) they share. They only change the rules;
+// scanning still takes just the -ext extension.
+var builtinExtAliases = map[string]string{
+	".hpp": ".cpp", ".hh": ".cpp", ".hxx": ".cpp", ".cc": ".cpp", ".cxx": ".cpp",
+	".mjs": ".js", ".cjs": ".js",
+	".mts": ".ts", ".cts": ".ts",
+	".kts": ".kt",
+	".pyi": ".py",
+	".yml": ".yaml",
+}
+
+// extAliases are the aliases given with --ext-alias. Besides sharing language rules,
+// their files are scanned together with the -ext extension they alias or are
+// aliased by, as one corpus.
+var extAliases = map[string]string{}
+
+// parseExtAliases parses --ext-alias: ".h=.cpp,.tsx=.ts"
+func parseExtAliases(s string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		from, to, ok := strings.Cut(strings.TrimSpace(part), "=")
+		from, to = strings.ToLower(strings.TrimSpace(from)), strings.ToLower(strings.TrimSpace(to))
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("expected .ext=.language-ext, got %q", part)
+		}
+		if from == to {
+			return nil, fmt.Errorf("%s is aliased to itself", from)
+		}
+		aliases[from] = to
+	}
+	return aliases, nil
+}
+
+// languageExt returns the extension whose language rules ext follows: its alias, or
+// ext itself
+func languageExt(ext string) string {
+	if to, ok := extAliases[ext]; ok {
+		return to
+	}
+	if to, ok := builtinExtAliases[ext]; ok {
+		return to
+	}
+	return ext
+}
+
+// splitExtensions splits the comma-separated -ext list, dropping blanks
+func splitExtensions(extensions string) []string {
+	var exts []string
+	for _, e := range strings.Split(extensions, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			exts = append(exts, e)
+		}
+	}
+	return exts
+}
+
+// scansExt reports whether a file of type ext belongs to a scan of extensions (the
+// -ext list): one of them, or one --ext-alias pools with one
+func scansExt(ext, extensions string) bool {
+	for _, extension := range splitExtensions(extensions) {
+		if scansOneExt(ext, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+func scansOneExt(ext, extension string) bool {
+	if strings.EqualFold(ext, extension) {
+		return true
+	}
+	ext = strings.ToLower(ext)
+	_, aliased := extAliases[ext]
+	_, aliasedTo := extAliases[extension]
+	return (aliased || aliasedTo) && languageExt(ext) == languageExt(extension)
+}
+
+// extAliasesKey describes the --ext-alias aliases for cache keys
+func extAliasesKey() string {
+	pairs := make([]string, 0, len(extAliases))
+	for from, to := range extAliases {
+		pairs = append(pairs, from+"="+to)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
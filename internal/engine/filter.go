@@ -0,0 +1,410 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FilterConfig holds the configuration for filtering patterns
+type FilterConfig struct {
+	MinOccur        int
+	MinMode         string // what MinOccur counts: MinModeCluster or MinModePattern
+	MinScore        int
+	MinScorePerLine float64 // if > 0, replaces MinScore with this times the pattern's length
+	MinLength       int     // patterns with fewer lines are dropped (-only-grown: min-size + 1)
+	MinSimilarity   float64
+	MaxSimilarity   float64          // upper similarity bound, 0 for none
+	MinDirs         int              // top-level directories of Root the occurrences must span, 0 for any
+	UserIgnored     map[uint64]bool  // user-defined patterns to ignore
+	Suppressed      *Suppressions    // inline quickdup:ignore markers
+	ExcludeData     bool             // drop matches classified as data definitions
+	Similarities    *SimilarityCache // similarity matrices reused across runs, nil to always compute
+
+	Representative string // how to pick each match's representative occurrence (medoid, first)
+
+	Overrides []ThresholdOverride // per-path thresholds from .quickdup.json
+	Root      string              // scan path the override globs are relative to
+}
+
+// What -min counts (--min-mode)
+const (
+	MinModeCluster = "cluster" // each similarity cluster needs -min occurrences
+	MinModePattern = "pattern" // the pattern needs -min occurrences before clustering; clusters need two
+)
+
+// clusterMinOccur returns the occurrences a similarity cluster needs, where minOccur
+// is the (possibly overridden) -min
+func (c FilterConfig) clusterMinOccur(minOccur int) int {
+	if c.MinMode == MinModePattern {
+		return min(minOccur, 2)
+	}
+	return minOccur
+}
+
+// minScore returns the score threshold for a pattern of the given length, where
+// flat is the (possibly overridden) -min-score
+func (c FilterConfig) minScore(lines, flat int) int {
+	if c.MinScorePerLine > 0 {
+		return int(math.Ceil(c.MinScorePerLine * float64(lines)))
+	}
+	return flat
+}
+
+// thresholds returns the thresholds for a pattern's occurrences, applying the first
+// override matching their primary location
+func (c FilterConfig) thresholds(locs []PatternLocation) Thresholds {
+	global := Thresholds{MinOccur: c.MinOccur, MinScore: c.MinScore, MinSimilarity: c.MinSimilarity}
+	return thresholdsFor(global, c.Overrides, c.Root, locs)
+}
+
+// FilterStats holds statistics about filtered patterns
+type FilterStats struct {
+	SkippedBlocked        int
+	SkippedLowScore       int
+	SkippedLowSimilarity  int
+	SkippedHighSimilarity int
+	SkippedSuppressed     int
+	SkippedDataDefinition int
+	SkippedUngrown        int
+	SkippedFewDirs        int
+}
+
+// FilterPatterns filters raw patterns into scored matches
+// Returns sorted matches (by score descending) and filter statistics
+func FilterPatterns(patterns map[uint64][]PatternLocation, config FilterConfig) ([]PatternMatch, FilterStats) {
+	var stats FilterStats
+
+	// Get blocked hashes from strategy
+	blockedHashes := activeStrategy.BlockedHashes()
+
+	// First pass: filter blocked patterns and collect candidates
+	type candidate struct {
+		hash       uint64
+		locs       []PatternLocation
+		thresholds Thresholds
+	}
+	var candidates []candidate
+
+	for hash, locs := range patterns {
+		if blockedHashes[hash] || config.UserIgnored[hash] {
+			stats.SkippedBlocked++
+			continue
+		}
+		// Patterns that stopped growing at the base window are mostly coincidence
+		if len(locs[0].Pattern) < config.MinLength {
+			stats.SkippedUngrown++
+			continue
+		}
+		t := config.thresholds(locs)
+		// Occurrences marked quickdup:ignore don't count; the other copies may still match
+		if kept := unsuppressed(locs, config.Suppressed); len(kept) < len(locs) {
+			if len(kept) < t.MinOccur {
+				stats.SkippedSuppressed++
+				continue
+			}
+			locs = kept
+		}
+		if len(locs) >= t.MinOccur {
+			candidates = append(candidates, candidate{hash, locs, t})
+		}
+	}
+
+	// Second pass: parallel clustering by similarity
+	type clusterResult struct {
+		index    int
+		clusters []ClusterResult
+	}
+	results := make([]clusterResult, len(candidates))
+	numWorkers := runtime.NumCPU()
+
+	var wg sync.WaitGroup
+	work := make(chan int, len(candidates))
+	for i := range candidates {
+		work <- i
+	}
+	close(work)
+
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range work {
+				c := candidates[idx]
+				locs := sortedLocations(c.locs)
+				matrix := config.Similarities.Lookup(c.hash, locs)
+				if matrix == nil {
+					matrix = similarityMatrix(locs)
+					config.Similarities.Store(c.hash, matrix)
+				}
+				clusters := clusterBySimilarity(locs, matrix, c.thresholds.MinSimilarity)
+				results[idx] = clusterResult{idx, clusters}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Third pass: collect matches from clusters that pass thresholds
+	var matches []PatternMatch
+	for _, r := range results {
+		c := candidates[r.index]
+		for _, cluster := range r.clusters {
+			// A cluster may not include the pattern's primary location: its own
+			// primary decides the occurrence and score thresholds
+			t := config.thresholds(cluster.Locations)
+
+			// Skip clusters that don't meet minimum occurrence threshold
+			if len(cluster.Locations) < config.clusterMinOccur(t.MinOccur) {
+				stats.SkippedLowSimilarity++
+				continue
+			}
+			if config.MaxSimilarity > 0 && cluster.Similarity > config.MaxSimilarity {
+				stats.SkippedHighSimilarity++
+				continue
+			}
+			var dirs []string
+			if config.MinDirs > 0 {
+				if dirs = topLevelDirs(config.Root, cluster.Locations); len(dirs) < config.MinDirs {
+					stats.SkippedFewDirs++
+					continue
+				}
+			}
+
+			rep := representativeIndex(cluster, config.Representative)
+			pattern := cluster.Locations[rep].Pattern
+			score := weightOccurrences(activeStrategy.Score(pattern, cluster.Similarity), len(cluster.Locations))
+			// A zero score is the strategy rejecting the pattern, even with -min-score 0
+			if score <= 0 || score < config.minScore(len(pattern), t.MinScore) {
+				stats.SkippedLowScore++
+				continue
+			}
+
+			lang := languageFor(cluster.Locations[rep].Filename)
+			kind := classifyPattern(pattern, &lang)
+			if config.ExcludeData && kind == KindDataDefinition {
+				stats.SkippedDataDefinition++
+				continue
+			}
+
+			matches = append(matches, PatternMatch{
+				Hash:           c.hash,
+				Locations:      cluster.Locations,
+				Pattern:        pattern,
+				Similarity:     cluster.Similarity,
+				Score:          score,
+				Representative: rep,
+				Substitutions:  cluster.Substitutions,
+				Kind:           kind,
+				Steps:          stanzaSteps(pattern),
+				Dirs:           dirs,
+			})
+		}
+	}
+
+	// Sort by score descending, then by hash for deterministic order
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Hash < matches[j].Hash
+	})
+
+	return matches, stats
+}
+
+// topLevelDirs returns the distinct top-level directories of root the occurrences are
+// in, sorted: the first segment of each path relative to root, "." for files directly
+// in it
+func topLevelDirs(root string, locs []PatternLocation) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, loc := range locs {
+		rel, err := filepath.Rel(filepath.Clean(root), loc.Filename)
+		if err != nil {
+			rel = loc.Filename
+		}
+		dir, _, nested := strings.Cut(filepath.ToSlash(rel), "/")
+		if !nested {
+			dir = "."
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// unsuppressed returns the occurrences that don't start inside a quickdup:ignore block
+func unsuppressed(locs []PatternLocation, suppressed *Suppressions) []PatternLocation {
+	if suppressed == nil {
+		return locs
+	}
+	kept := make([]PatternLocation, 0, len(locs))
+	for _, loc := range locs {
+		if !suppressed.Covers(loc.Filename, loc.LineStart) {
+			kept = append(kept, loc)
+		}
+	}
+	return kept
+}
+
+// mergeAdjacentOccurrences coalesces occurrences of a match that follow each other
+// with no gap in the same file (block repeated back-to-back) into one span with a
+// repeat count
+func mergeAdjacentOccurrences(matches []PatternMatch) []PatternMatch {
+	for i := range matches {
+		m := &matches[i]
+		patternLen := len(m.Pattern)
+		locs := append([]PatternLocation(nil), m.Locations...)
+		sort.Slice(locs, func(a, b int) bool {
+			if locs[a].Filename != locs[b].Filename {
+				return locs[a].Filename < locs[b].Filename
+			}
+			return locs[a].EntryIndex < locs[b].EntryIndex
+		})
+
+		merged := locs[:0]
+		for _, loc := range locs {
+			if n := len(merged); n > 0 {
+				last := &merged[n-1]
+				copies := max(last.Repeats, 1)
+				if last.Filename == loc.Filename && last.EntryIndex+copies*patternLen == loc.EntryIndex {
+					last.Repeats = copies + 1
+					last.SpanEndLine = locationEndLine(loc)
+					continue
+				}
+			}
+			merged = append(merged, loc)
+		}
+		if len(merged) == len(m.Locations) {
+			continue
+		}
+
+		// Keep the representative pointing at the span that contains it
+		rep := m.Locations[m.Representative]
+		m.Representative = 0
+		for j, loc := range merged {
+			if loc.Filename == rep.Filename && loc.EntryIndex <= rep.EntryIndex &&
+				rep.EntryIndex < loc.EntryIndex+max(loc.Repeats, 1)*patternLen {
+				m.Representative = j
+			}
+		}
+		m.Locations = merged
+	}
+	return matches
+}
+
+// TopN returns at most n matches from the slice
+func TopN(matches []PatternMatch, n int) []PatternMatch {
+	if len(matches) < n {
+		n = len(matches)
+	}
+	return matches[:n]
+}
+
+// LoadIgnoredHashes reads ignore.json and returns user-ignored hashes, creating an
+// empty ignore.json to fill in when there is none.
+// A malformed ignore.json is recorded in warnings and treated as empty
+func LoadIgnoredHashes(dir string, strategyName string, warnings *Warnings) map[uint64]bool {
+	ignorePath := filepath.Join(dir, ".quickdup", strategyName+"-ignore.json")
+	if _, err := os.Stat(ignorePath); os.IsNotExist(err) {
+		emptyIgnore := IgnoreFile{HashAlgorithm: hashAlgorithm, Ignored: []string{}}
+		if jsonData, err := json.MarshalIndent(emptyIgnore, "", "  "); err == nil {
+			os.MkdirAll(filepath.Join(dir, ".quickdup"), 0755)
+			os.WriteFile(ignorePath, jsonData, 0644)
+		}
+		return nil
+	}
+	return readIgnoredHashes(dir, strategyName, warnings)
+}
+
+// readIgnoredHashes reads ignore.json like LoadIgnoredHashes but never writes: without
+// one nothing is ignored. For scans of directories quickdup doesn't own (quickdup serve).
+func readIgnoredHashes(dir string, strategyName string, warnings *Warnings) map[uint64]bool {
+	ignorePath := filepath.Join(dir, ".quickdup", strategyName+"-ignore.json")
+	data, err := os.ReadFile(ignorePath)
+	if err != nil {
+		return nil
+	}
+
+	var ignoreFile IgnoreFile
+	if err := json.Unmarshal(data, &ignoreFile); err != nil {
+		warnings.Add("ignore-file", ignorePath, err)
+		return nil
+	}
+	if algorithm := ignoreFileHashAlgorithm(ignoreFile); algorithm != hashAlgorithm && len(ignoreFile.Ignored) > 0 {
+		warnings.Add("ignore-file", ignorePath, fmt.Errorf("hashes were taken with --hash %s but this scan uses %s, so none will match", algorithm, hashAlgorithm))
+	}
+
+	ignored := make(map[uint64]bool)
+	for _, hashStr := range ignoreFile.Ignored {
+		var hash uint64
+		if _, err := fmt.Sscanf(hashStr, "%x", &hash); err == nil {
+			ignored[hash] = true
+		}
+	}
+	return ignored
+}
+
+// detectedIn reports whether hash has occurrences in any of patternSets
+func detectedIn(hash uint64, patternSets []map[uint64][]PatternLocation) bool {
+	for _, patterns := range patternSets {
+		if len(patterns[hash]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// PruneIgnoredHashes rewrites ignore.json keeping only hashes still detected in any of
+// patternSets (production and test code are detected apart), so fixed duplication drops
+// out of the baseline. New patterns are never added. Returns how many entries were kept
+// and removed.
+func PruneIgnoredHashes(dir string, strategyName string, patternSets ...map[uint64][]PatternLocation) (int, int, error) {
+	ignorePath := filepath.Join(dir, ".quickdup", strategyName+"-ignore.json")
+	data, err := os.ReadFile(ignorePath)
+	if err != nil {
+		return 0, 0, &ScanError{Op: "read ignore file", Path: ignorePath, Err: err}
+	}
+
+	var ignoreFile IgnoreFile
+	if err := json.Unmarshal(data, &ignoreFile); err != nil {
+		return 0, 0, &ScanError{Op: "read ignore file", Path: ignorePath, Err: err}
+	}
+	// Under another algorithm every entry would look fixed
+	if algorithm := ignoreFileHashAlgorithm(ignoreFile); algorithm != hashAlgorithm {
+		return 0, 0, &ScanError{Op: "update baseline", Path: ignorePath, Err: fmt.Errorf("hashes were taken with --hash %s, not %s", algorithm, hashAlgorithm)}
+	}
+
+	kept := []string{}
+	for _, hashStr := range ignoreFile.Ignored {
+		var hash uint64
+		if _, err := fmt.Sscanf(hashStr, "%x", &hash); err == nil && !detectedIn(hash, patternSets) {
+			continue // no longer detected: fixed
+		}
+		kept = append(kept, hashStr)
+	}
+	removed := len(ignoreFile.Ignored) - len(kept)
+	if removed == 0 {
+		return len(kept), 0, nil
+	}
+
+	ignoreFile.Ignored = kept
+	ignoreFile.HashAlgorithm = hashAlgorithm
+	jsonData, err := json.MarshalIndent(ignoreFile, "", "  ")
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := os.WriteFile(ignorePath, jsonData, 0644); err != nil {
+		return 0, 0, &ScanError{Op: "write ignore file", Path: ignorePath, Err: err}
+	}
+	return len(kept), removed, nil
+}
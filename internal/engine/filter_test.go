@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterPatternsDropsZeroScores(t *testing.T) {
+	useStrategy(t, "inlineable")
+	silence(t)
+	loop := `
+    public void Process()
+    {
+        foreach (var item in items)
+        {
+            Handle(item);
+            Log(item);
+        }
+        Flush();
+    }
+`
+	getter := `
+    public int Count()
+    {
+        return count;
+    }
+`
+	tests := []struct {
+		name        string
+		member      string
+		wantMatches bool
+	}{
+		{"non-inlineable code", loop, false},
+		{"inlineable getters", getter, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileData := make(map[string][]Entry)
+			for _, name := range []string{"A.cs", "B.cs", "C.cs"} {
+				class := "class " + strings.TrimSuffix(name, ".cs") + "\n{" + tt.member + "}\n"
+				fileData[name] = parseSource(t, name, class)
+			}
+			patterns := detectPatterns(fileData, len(fileData), 2, 3, 0, false)
+			if len(patterns) == 0 {
+				t.Fatal("no patterns detected")
+			}
+
+			// -min-score 0 lets every score through but a zero one
+			matches, stats := FilterPatterns(patterns, FilterConfig{MinOccur: 2, MinScore: 0})
+			for _, m := range matches {
+				if m.Score <= 0 {
+					t.Errorf("match %016x reported with score %d", m.Hash, m.Score)
+				}
+			}
+			if got := len(matches) > 0; got != tt.wantMatches {
+				t.Errorf("%d matches (%d skipped for score), want matches: %v", len(matches), stats.SkippedLowScore, tt.wantMatches)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SeedSpec is the snippet --find searches for: a line range of one file
+type SeedSpec struct {
+	File  string
+	Start int
+	End   int
+}
+
+// SeedMatch is a window of the corpus with the seed's structure
+type SeedMatch struct {
+	Filename   string
+	LineStart  int
+	LineEnd    int
+	Similarity float64 // token similarity to the seed (0.0-1.0)
+}
+
+// parseSeedSpec parses "file:start-end"
+func parseSeedSpec(spec string) (SeedSpec, error) {
+	i := strings.LastIndex(spec, ":")
+	if i <= 0 {
+		return SeedSpec{}, fmt.Errorf("expected file:start-end, got %q", spec)
+	}
+	start, end, ok := strings.Cut(spec[i+1:], "-")
+	if !ok {
+		return SeedSpec{}, fmt.Errorf("expected a line range like 10-40, got %q", spec[i+1:])
+	}
+	s, err1 := strconv.Atoi(start)
+	e, err2 := strconv.Atoi(end)
+	if err1 != nil || err2 != nil || s < 1 || e < s {
+		return SeedSpec{}, fmt.Errorf("invalid line range %q", spec[i+1:])
+	}
+	return SeedSpec{File: spec[:i], Start: s, End: e}, nil
+}
+
+// seedEntries returns the entries of the seed's line range, from the parsed corpus
+// when the seed file is part of it
+func seedEntries(seed SeedSpec, fileData map[string][]Entry) ([]Entry, error) {
+	entries, ok := fileData[seed.File]
+	if !ok {
+		for name, e := range fileData {
+			if sameFile(name, seed.File) {
+				entries, ok = e, true
+				break
+			}
+		}
+	}
+	if !ok {
+		var err error
+		if entries, _, err = parseFile(seed.File); err != nil {
+			return nil, err
+		}
+	}
+	var seedEntries []Entry
+	for _, e := range entries {
+		if line := e.GetLineNumber(); line >= seed.Start && line <= seed.End {
+			seedEntries = append(seedEntries, e)
+		}
+	}
+	if len(seedEntries) == 0 {
+		return nil, fmt.Errorf("%s:%d-%d has no lines the %s strategy matches on", seed.File, seed.Start, seed.End, activeStrategy.Name())
+	}
+	return seedEntries, nil
+}
+
+// sameFile reports whether two paths name the same file
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// findSeed returns every window of the corpus hashing like the seed, other than the
+// seed itself, most similar first
+func findSeed(seed SeedSpec, entries []Entry, fileData map[string][]Entry) []SeedMatch {
+	n := len(entries)
+	hash := activeStrategy.Hash(entries)
+	seedLang := languageFor(seed.File)
+	seedTokens := similarityTokens(entries, &seedLang)
+
+	var found []SeedMatch
+	for filename, fileEntries := range fileData {
+		self := sameFile(filename, seed.File)
+		lang := languageFor(filename)
+		for i := 0; i+n <= len(fileEntries); i++ {
+			window := fileEntries[i : i+n]
+			start, end := window[0].GetLineNumber(), window[n-1].GetLineNumber()
+			if self && start <= seed.End && end >= seed.Start {
+				continue
+			}
+			if activeStrategy.Hash(window) != hash {
+				continue
+			}
+			found = append(found, SeedMatch{
+				Filename:   filename,
+				LineStart:  start,
+				LineEnd:    end,
+				Similarity: tokenSimilarity(seedTokens, similarityTokens(window, &lang)),
+			})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Similarity != found[j].Similarity {
+			return found[i].Similarity > found[j].Similarity
+		}
+		if found[i].Filename != found[j].Filename {
+			return found[i].Filename < found[j].Filename
+		}
+		return found[i].LineStart < found[j].LineStart
+	})
+	return found
+}
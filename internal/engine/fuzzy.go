@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Fuzzy duplicate regions are fixed windows of entries, overlapping by stepping
+// fuzzyStride entries at a time
+const (
+	fuzzyWindow = 15
+	fuzzyStride = 5
+)
+
+// maxShingleWindows skips shingles found in more windows than this: they're
+// boilerplate shared by everything and would make the pair count quadratic
+const maxShingleWindows = 50
+
+// FuzzyRegion is one side of a fuzzy duplicate
+type FuzzyRegion struct {
+	Filename  string `json:"filename"`
+	LineStart int    `json:"line_start"`
+	LineEnd   int    `json:"line_end"`
+}
+
+// FuzzyDuplicate is a pair of regions sharing most of their k-line shingles although
+// their statements are reordered or edited, which the exact detector can't see (--shingles)
+type FuzzyDuplicate struct {
+	Overlap float64        `json:"overlap"` // Jaccard overlap of the regions' shingle sets (0.0-1.0)
+	Regions [2]FuzzyRegion `json:"regions"`
+}
+
+// fuzzyWindowRef is a window of one file's entries
+type fuzzyWindowRef struct {
+	file     string
+	start    int // index of the window's first entry
+	shingles []uint64
+	sequence uint64 // hash of the window's entries in order
+}
+
+// findFuzzyDuplicates finds pairs of windows whose sets of k-entry shingles overlap
+// by at least minOverlap. Windows with identical entry sequences or covered by one of
+// the exact matches are left to the exact detector, and of overlapping window pairs
+// only the best is kept.
+func findFuzzyDuplicates(fileData map[string][]Entry, k int, minOverlap float64, matches []PatternMatch) []FuzzyDuplicate {
+	files := make([]string, 0, len(fileData))
+	for f := range fileData {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	var windows []fuzzyWindowRef
+	for _, file := range files {
+		entries := fileData[file]
+		for start := 0; start+fuzzyWindow <= len(entries); start += fuzzyStride {
+			windows = append(windows, shingleWindow(file, start, entries[start:start+fuzzyWindow], k))
+		}
+	}
+
+	// Inverted index: shingle -> windows containing it
+	index := make(map[uint64][]int)
+	for w, window := range windows {
+		for _, s := range window.shingles {
+			index[s] = append(index[s], w)
+		}
+	}
+
+	type pair struct {
+		a, b    int
+		overlap float64
+	}
+	var mu sync.Mutex
+	var pairs []pair
+	work := make(chan int, len(windows))
+	for w := range windows {
+		work <- w
+	}
+	close(work)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range work {
+				shared := make(map[int]int)
+				for _, s := range windows[a].shingles {
+					if posting := index[s]; len(posting) <= maxShingleWindows {
+						for _, b := range posting {
+							if b > a {
+								shared[b]++
+							}
+						}
+					}
+				}
+				var found []pair
+				for b, n := range shared {
+					if sameRegion(windows[a], windows[b]) || windows[a].sequence == windows[b].sequence {
+						continue
+					}
+					union := len(windows[a].shingles) + len(windows[b].shingles) - n
+					if overlap := float64(n) / float64(union); overlap >= minOverlap {
+						found = append(found, pair{a, b, overlap})
+					}
+				}
+				mu.Lock()
+				pairs = append(pairs, found...)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Best pairs first; a pair overlapping both regions of a kept pair adds nothing
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].overlap != pairs[j].overlap {
+			return pairs[i].overlap > pairs[j].overlap
+		}
+		if pairs[i].a != pairs[j].a {
+			return pairs[i].a < pairs[j].a
+		}
+		return pairs[i].b < pairs[j].b
+	})
+	exact := exactlyMatched(matches)
+	type filePair struct{ a, b string }
+	kept := make(map[filePair][]pair)
+	var duplicates []FuzzyDuplicate
+	for _, p := range pairs {
+		key := filePair{windows[p.a].file, windows[p.b].file}
+		covered := false
+		for _, q := range kept[key] {
+			if sameRegion(windows[p.a], windows[q.a]) && sameRegion(windows[p.b], windows[q.b]) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		a, b := fuzzyRegion(windows[p.a], fileData), fuzzyRegion(windows[p.b], fileData)
+		if exact(a, b) {
+			continue
+		}
+		kept[key] = append(kept[key], p)
+		duplicates = append(duplicates, FuzzyDuplicate{Overlap: p.overlap, Regions: [2]FuzzyRegion{a, b}})
+	}
+	return duplicates
+}
+
+// shingleWindow hashes a window's distinct k-entry shingles
+func shingleWindow(file string, start int, entries []Entry, k int) fuzzyWindowRef {
+	seen := make(map[uint64]bool)
+	var shingles []uint64
+	for i := 0; i+k <= len(entries); i++ {
+		h := xxhash.New()
+		for _, e := range entries[i : i+k] {
+			h.Write(e.HashBytes())
+		}
+		if s := h.Sum64(); !seen[s] {
+			seen[s] = true
+			shingles = append(shingles, s)
+		}
+	}
+	sequence := xxhash.New()
+	for _, e := range entries {
+		sequence.Write(e.HashBytes())
+	}
+	return fuzzyWindowRef{file: file, start: start, shingles: shingles, sequence: sequence.Sum64()}
+}
+
+// sameRegion reports whether two windows overlap in the same file
+func sameRegion(a, b fuzzyWindowRef) bool {
+	if a.file != b.file {
+		return false
+	}
+	return a.start < b.start+fuzzyWindow && b.start < a.start+fuzzyWindow
+}
+
+// fuzzyRegion returns the source lines a window spans
+func fuzzyRegion(w fuzzyWindowRef, fileData map[string][]Entry) FuzzyRegion {
+	entries := fileData[w.file]
+	return FuzzyRegion{
+		Filename:  w.file,
+		LineStart: entries[w.start].GetLineNumber(),
+		LineEnd:   entries[w.start+fuzzyWindow-1].GetLineNumber(),
+	}
+}
+
+// exactlyMatched returns a check for whether two regions are both occurrences of the
+// same exact match: such pairs are already reported
+func exactlyMatched(matches []PatternMatch) func(a, b FuzzyRegion) bool {
+	type span struct{ match, start, end int }
+	spans := make(map[string][]span)
+	for i, m := range matches {
+		for _, loc := range m.Locations {
+			spans[loc.Filename] = append(spans[loc.Filename], span{i, loc.LineStart, locationEndLine(loc)})
+		}
+	}
+	// covering returns the matches with an occurrence spanning most of a region
+	covering := func(r FuzzyRegion) map[int]bool {
+		found := make(map[int]bool)
+		for _, s := range spans[r.Filename] {
+			overlap := min(s.end, r.LineEnd) - max(s.start, r.LineStart) + 1
+			if overlap*2 > r.LineEnd-r.LineStart+1 {
+				found[s.match] = true
+			}
+		}
+		return found
+	}
+	return func(a, b FuzzyRegion) bool {
+		covers := covering(a)
+		for m := range covering(b) {
+			if covers[m] {
+				return true
+			}
+		}
+		return false
+	}
+}
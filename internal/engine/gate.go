@@ -0,0 +1,19 @@
+package engine
+
+import "fmt"
+
+// gateFailure returns why matches fail the CI gate, or "" if they pass: a match scoring
+// failOnScore or more (0 disables), or more than failOnCount matches (-1 disables)
+func gateFailure(matches []PatternMatch, failOnScore, failOnCount int) string {
+	if failOnCount >= 0 && len(matches) > failOnCount {
+		return fmt.Sprintf("%d duplicate patterns, more than the %d allowed (--fail-on-count)", len(matches), failOnCount)
+	}
+	if failOnScore > 0 {
+		for _, m := range matches {
+			if m.Score >= failOnScore {
+				return fmt.Sprintf("pattern %016x scores %d, at least %d (--fail-on-score)", m.Hash, m.Score, failOnScore)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// respectGitignore skips the paths .gitignore files ignore during the walk (set from
+// --respect-gitignore)
+var respectGitignore bool
+
+// gitignoreRule is one pattern line of a .gitignore
+type gitignoreRule struct {
+	pattern  string // slash-separated glob; ** spans directories
+	negate   bool   // !pattern re-includes what an earlier rule ignored
+	dirOnly  bool   // pattern/ matches directories only
+	anchored bool   // matched against the path from the .gitignore's directory, not the basename
+}
+
+// Gitignore holds the .gitignore rules of the directories a walk has entered, so paths
+// can be checked the way git does: the deepest .gitignore with a matching rule decides,
+// and within a file the last matching rule wins
+type Gitignore struct {
+	top   string                     // outermost directory whose rules apply: the repository root
+	rules map[string][]gitignoreRule // by absolute directory
+}
+
+// loadGitignore returns the rules that apply to a walk of root: those of root and of its
+// ancestors up to the repository root (the nearest directory with a .git), if any
+func loadGitignore(root string, warnings *Warnings) (*Gitignore, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	g := &Gitignore{top: abs, rules: make(map[string][]gitignoreRule)}
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			g.top = dir
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break // not in a repository: root's own rules only
+		}
+	}
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		g.enter(dir, warnings)
+		if dir == g.top {
+			break
+		}
+	}
+	return g, nil
+}
+
+// enter loads the .gitignore of a directory the walk descends into
+func (g *Gitignore) enter(dir string, warnings *Warnings) {
+	path := filepath.Join(dir, ".gitignore")
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		warnings.Add("gitignore", path, err)
+		return
+	}
+	defer file.Close()
+
+	var rules []gitignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseGitignoreLine(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		warnings.Add("gitignore", path, err)
+	}
+	if len(rules) > 0 {
+		g.rules[dir] = rules
+	}
+}
+
+// parseGitignoreLine parses a .gitignore line, reporting false for blanks and comments
+func parseGitignoreLine(line string) (gitignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if strings.HasPrefix(line, "#") {
+		return gitignoreRule{}, false
+	}
+	// Trailing spaces are dropped unless escaped with a backslash
+	trimmed := strings.TrimRight(line, " ")
+	if strings.HasSuffix(trimmed, `\`) && len(trimmed) < len(line) {
+		trimmed += " "
+	}
+	line = trimmed
+
+	var rule gitignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.HasPrefix(line, "/") {
+		rule.anchored = true
+		line = strings.TrimLeft(line, "/")
+	} else {
+		rule.anchored = strings.Contains(line, "/")
+	}
+	if line == "" {
+		return gitignoreRule{}, false
+	}
+	rule.pattern = line
+	return rule, true
+}
+
+// ignored reports whether the .gitignore rules of path's directories ignore it
+func (g *Gitignore) ignored(path string, isDir bool) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		if rules := g.rules[dir]; len(rules) > 0 {
+			rel, err := filepath.Rel(dir, abs)
+			if err == nil {
+				rel = filepath.ToSlash(rel)
+				for i := len(rules) - 1; i >= 0; i-- {
+					if rules[i].matches(rel, isDir) {
+						return !rules[i].negate
+					}
+				}
+			}
+		}
+		if dir == g.top || filepath.Dir(dir) == dir {
+			return false
+		}
+	}
+}
+
+// matches reports whether a rule matches rel, a slash-separated path relative to the
+// rule's .gitignore
+func (r gitignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored {
+		return matchPathGlob(r.pattern, rel)
+	}
+	return matchPathGlob(r.pattern, rel[strings.LastIndex(rel, "/")+1:])
+}
@@ -0,0 +1,259 @@
+package engine
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Similarity graph formats (--similarity-graph), by file extension
+const (
+	GraphFormatDOT     = "dot"
+	GraphFormatGraphML = "graphml"
+)
+
+// graphFormat returns the format a similarity graph path asks for, "" if none
+func graphFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".dot", ".gv":
+		return GraphFormatDOT
+	case ".graphml":
+		return GraphFormatGraphML
+	}
+	return ""
+}
+
+// SimilarityGraph is one pattern's occurrences as clustering saw them: an edge joins
+// two occurrences whose token similarity reaches the threshold, or that are the same
+// code with identifiers consistently renamed. Clusters are its connected components.
+type SimilarityGraph struct {
+	Hash      uint64
+	Lines     int
+	Threshold float64
+	Locations []PatternLocation // every unsuppressed occurrence, in clustering order
+	Cluster   []int             // per location: its cluster, numbered largest first
+	Reported  []bool            // per location: whether its cluster made the report
+	Edges     []SimilarityEdge
+}
+
+// SimilarityEdge joins locations From < To of a SimilarityGraph
+type SimilarityEdge struct {
+	From, To   int
+	Similarity float64
+	Renamed    bool
+}
+
+// buildSimilarityGraphs rebuilds the similarity graph behind each pattern of matches
+// (in reported form) from all of its occurrences, including those whose clusters were
+// filtered out
+func buildSimilarityGraphs(matches []PatternMatch, patterns map[uint64][]PatternLocation, config FilterConfig) []SimilarityGraph {
+	reported := make(map[uint64]map[string]bool)
+	var hashes []uint64
+	for _, m := range matches {
+		if reported[m.Hash] == nil {
+			reported[m.Hash] = make(map[string]bool)
+			hashes = append(hashes, m.Hash)
+		}
+		for _, loc := range m.Locations {
+			reported[m.Hash][locationKey(loc)] = true
+		}
+	}
+
+	var graphs []SimilarityGraph
+	for _, hash := range hashes {
+		locs := patterns[hash]
+		if len(locs) == 0 {
+			continue
+		}
+		// As FilterPatterns does: thresholds by primary location, suppressed copies left out
+		threshold := config.thresholds(locs).MinSimilarity
+		locs = sortedLocations(unsuppressed(locs, config.Suppressed))
+		matrix := config.Similarities.Lookup(hash, locs)
+		if matrix == nil {
+			matrix = similarityMatrix(locs)
+		}
+
+		g := SimilarityGraph{
+			Hash:      hash,
+			Lines:     len(locs[0].Pattern),
+			Threshold: threshold,
+			Locations: locs,
+			Cluster:   make([]int, len(locs)),
+			Reported:  make([]bool, len(locs)),
+		}
+		index := make(map[string]int, len(locs))
+		for i, loc := range locs {
+			index[locationKey(loc)] = i
+		}
+		for c, cluster := range clusterBySimilarity(locs, matrix, threshold) {
+			for _, loc := range cluster.Locations {
+				g.Cluster[index[locationKey(loc)]] = c
+			}
+		}
+		for i, loc := range locs {
+			g.Reported[i] = reported[hash][locationKey(PatternLocation{Filename: reportPath(loc.Filename), LineStart: loc.LineStart})]
+		}
+		n := len(locs)
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				p := pairIndex(i, j, n)
+				_, renamed := matrix.Renamings[p]
+				if renamed || matrix.Similarities[p] >= threshold {
+					g.Edges = append(g.Edges, SimilarityEdge{From: i, To: j, Similarity: matrix.Similarities[p], Renamed: renamed})
+				}
+			}
+		}
+		graphs = append(graphs, g)
+	}
+	return graphs
+}
+
+// WriteSimilarityGraph writes graphs as one document in the format path's extension
+// names: a DOT graph with a subgraph per pattern, or GraphML for Gephi, yEd and the like
+func WriteSimilarityGraph(graphs []SimilarityGraph, path string) error {
+	var data []byte
+	if graphFormat(path) == GraphFormatGraphML {
+		var err error
+		if data, err = graphML(graphs); err != nil {
+			return err
+		}
+	} else {
+		data = []byte(graphDOT(graphs))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return &ScanError{Op: "create output directory", Path: filepath.Dir(path), Err: err}
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return &ScanError{Op: "write similarity graph", Path: path, Err: err}
+	}
+	return nil
+}
+
+// graphNodeID identifies location i of graph g within the whole document
+func graphNodeID(g, i int) string {
+	return fmt.Sprintf("p%d_%d", g, i)
+}
+
+// graphNodeLabel is the reported file:line of a location
+func graphNodeLabel(loc PatternLocation) string {
+	return fmt.Sprintf("%s:%d", reportPath(loc.Filename), loc.LineStart)
+}
+
+// graphDOT renders graphs in Graphviz DOT: nodes are filled by cluster, occurrences
+// the report left out are dashed, and renamings are dashed edges
+func graphDOT(graphs []SimilarityGraph) string {
+	var b strings.Builder
+	b.WriteString("graph similarity {\n")
+	b.WriteString("  node [shape=box, fontname=\"monospace\", style=filled, colorscheme=set312];\n")
+	for gi, g := range graphs {
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n", gi)
+		fmt.Fprintf(&b, "    label=%s;\n", strconv.Quote(fmt.Sprintf("%016x: %d lines, %d occurrences, threshold %.2f", g.Hash, g.Lines, len(g.Locations), g.Threshold)))
+		for i, loc := range g.Locations {
+			style := "filled"
+			if !g.Reported[i] {
+				style = "filled,dashed"
+			}
+			fmt.Fprintf(&b, "    %s [label=%s, fillcolor=%d, style=%q];\n",
+				graphNodeID(gi, i), strconv.Quote(graphNodeLabel(loc)), g.Cluster[i]%12+1, style)
+		}
+		for _, e := range g.Edges {
+			attrs := fmt.Sprintf("label=\"%.2f\"", e.Similarity)
+			if e.Renamed {
+				attrs += ", style=dashed"
+			}
+			fmt.Fprintf(&b, "    %s -- %s [%s];\n", graphNodeID(gi, e.From), graphNodeID(gi, e.To), attrs)
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// GraphML document, as far as the similarity graph needs it
+type graphMLDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphML renders graphs as a single GraphML graph; each node carries its pattern and
+// cluster, so tools can partition by either
+func graphML(graphs []SimilarityGraph) ([]byte, error) {
+	doc := graphMLDoc{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "pattern", For: "node", Name: "pattern", Type: "string"},
+			{ID: "cluster", For: "node", Name: "cluster", Type: "int"},
+			{ID: "reported", For: "node", Name: "reported", Type: "boolean"},
+			{ID: "lines", For: "node", Name: "lines", Type: "int"},
+			{ID: "similarity", For: "edge", Name: "similarity", Type: "double"},
+			{ID: "renamed", For: "edge", Name: "renamed", Type: "boolean"},
+		},
+		Graph: graphMLGraph{ID: "similarity", EdgeDefault: "undirected"},
+	}
+	for gi, g := range graphs {
+		pattern := fmt.Sprintf("%016x", g.Hash)
+		for i, loc := range g.Locations {
+			doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+				ID: graphNodeID(gi, i),
+				Data: []graphMLData{
+					{Key: "label", Value: graphNodeLabel(loc)},
+					{Key: "pattern", Value: pattern},
+					{Key: "cluster", Value: strconv.Itoa(g.Cluster[i])},
+					{Key: "reported", Value: strconv.FormatBool(g.Reported[i])},
+					{Key: "lines", Value: strconv.Itoa(g.Lines)},
+				},
+			})
+		}
+		for _, e := range g.Edges {
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+				Source: graphNodeID(gi, e.From),
+				Target: graphNodeID(gi, e.To),
+				Data: []graphMLData{
+					{Key: "similarity", Value: strconv.FormatFloat(e.Similarity, 'f', 4, 64)},
+					{Key: "renamed", Value: strconv.FormatBool(e.Renamed)},
+				},
+			})
+		}
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"hash"
+	"hash/fnv"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Window hash algorithms (--hash). Pattern hashes shown in output and stored in
+// ignore.json depend on the algorithm, so switching invalidates ignore lists.
+const (
+	HashFNV    = "fnv"
+	HashXXHash = "xxhash"
+)
+
+// hashAlgorithm is the active window hash algorithm
+var hashAlgorithm = HashFNV
+
+// newWindowHash creates a hasher for the active algorithm
+var newWindowHash = func() hash.Hash64 { return fnv.New64a() }
+
+// setHashAlgorithm selects the window hash algorithm, reporting false if unknown
+func setHashAlgorithm(name string) bool {
+	switch name {
+	case HashFNV:
+		newWindowHash = func() hash.Hash64 { return fnv.New64a() }
+	case HashXXHash:
+		newWindowHash = func() hash.Hash64 { return xxhash.New() }
+	default:
+		return false
+	}
+	hashAlgorithm = name
+	return true
+}
+
+// ignoreFileHashAlgorithm is the algorithm of an ignore file's hashes: files written
+// before it was recorded hold fnv hashes, the only algorithm then
+func ignoreFileHashAlgorithm(f IgnoreFile) string {
+	if f.HashAlgorithm == "" {
+		return HashFNV
+	}
+	return f.HashAlgorithm
+}
+
+// hashEntries hashes the pre-computed hash bytes of a window of entries
+func hashEntries(entries []Entry) uint64 {
+	h := newWindowHash()
+	for _, e := range entries {
+		h.Write(e.HashBytes())
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// BenchmarkDetectPatterns times detection, growth included, over this package's own
+// source with each window hash algorithm
+func BenchmarkDetectPatterns(b *testing.B) {
+	useStrategy(b, "normalized-indent")
+	silence(b)
+
+	paths, err := filepath.Glob("*.go")
+	if err != nil {
+		b.Fatal(err)
+	}
+	fileData := make(map[string][]Entry, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		fileData[path] = parseSource(b, path, string(data))
+	}
+
+	for _, algorithm := range []string{HashFNV, HashXXHash} {
+		b.Run(algorithm, func(b *testing.B) {
+			setHashAlgorithm(algorithm)
+			b.Cleanup(func() { setHashAlgorithm(HashFNV) })
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				detectPatterns(fileData, len(fileData), 2, 3, 0, false)
+			}
+		})
+	}
+}
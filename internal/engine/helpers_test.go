@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// useStrategy makes the named strategy active for the rest of the test
+func useStrategy(tb testing.TB, name string) {
+	tb.Helper()
+	prev := activeStrategy
+	activeStrategy = newStrategies()[name]
+	tb.Cleanup(func() { activeStrategy = prev })
+}
+
+// writeFiles writes files (relative path to content) under a temporary directory and
+// returns the directory and the written paths, sorted
+func writeFiles(tb testing.TB, files map[string]string) (string, []string) {
+	tb.Helper()
+	dir := tb.TempDir()
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return dir, paths
+}
+
+// parseSource parses content as the file at path with the active strategy
+func parseSource(tb testing.TB, path, content string) []Entry {
+	tb.Helper()
+	entries, _, err := parseContent(path, []byte(content))
+	if err != nil {
+		tb.Fatalf("parse %s: %v", path, err)
+	}
+	return entries
+}
+
+// silence turns off progress output for the rest of the test
+func silence(tb testing.TB) {
+	prev := quiet
+	quiet = true
+	tb.Cleanup(func() { quiet = prev })
+}
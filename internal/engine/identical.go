@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// dedupIdenticalFiles drops all but the first of each set of byte-identical files
+// (build copies, vendored mirrors, symlinks), whose patterns would otherwise all be
+// reported as cross-file duplicates. It returns the files to scan and the sets found.
+// Unreadable files are kept; parsing reports them.
+func dedupIdenticalFiles(files []string) ([]string, []IdenticalFiles) {
+	hashes := make([]uint64, len(files))
+	readable := make([]bool, len(files))
+
+	work := make(chan int, len(files))
+	for i := range files {
+		work <- i
+	}
+	close(work)
+
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				data, err := os.ReadFile(files[i])
+				if err != nil {
+					continue
+				}
+				hashes[i] = xxhash.Sum64(data)
+				readable[i] = true
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Sets keep the walk order, so the first path found is the one scanned
+	first := make(map[uint64]int)
+	var sets []IdenticalFiles
+	setIndex := make(map[uint64]int)
+	unique := make([]string, 0, len(files))
+	for i, path := range files {
+		if !readable[i] {
+			unique = append(unique, path)
+			continue
+		}
+		kept, seen := first[hashes[i]]
+		if !seen {
+			first[hashes[i]] = i
+			unique = append(unique, path)
+			continue
+		}
+		idx, ok := setIndex[hashes[i]]
+		if !ok {
+			idx = len(sets)
+			setIndex[hashes[i]] = idx
+			sets = append(sets, IdenticalFiles{Kept: files[kept]})
+		}
+		sets[idx].Copies = append(sets[idx].Copies, path)
+	}
+	return unique, sets
+}
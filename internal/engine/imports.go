@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CommonImport is an import line shared by several files of one package (directory),
+// a candidate for a shared prelude or facade (--import-report)
+type CommonImport struct {
+	Dir        string `json:"dir"`
+	Import     string `json:"import"`
+	Files      int    `json:"files"`       // files in Dir with this import
+	TotalFiles int    `json:"total_files"` // files scanned in Dir
+}
+
+// maxCommonImports is how many import lines the report lists
+const maxCommonImports = 20
+
+// importKeywords are the skipped first words that open an import, as opposed to the
+// package and export declarations skipFirstWords also drops
+var importKeywords = map[string]bool{
+	"import": true, "using": true, "from": true, "use": true,
+}
+
+// findCommonImports tallies identical import lines across the files of each directory,
+// keeping those found in at least minFiles files, most widespread first. Unreadable
+// files are recorded as warnings.
+func findCommonImports(files []string, minFiles int, warnings *Warnings) []CommonImport {
+	type key struct{ dir, line string }
+	counts := make(map[key]int)
+	dirFiles := make(map[string]int)
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			warnings.Add("imports", path, err)
+			continue
+		}
+		dir := filepath.Dir(path)
+		dirFiles[dir]++
+		for _, line := range importLines(string(content), languageFor(path)) {
+			counts[key{dir, line}]++
+		}
+	}
+
+	var common []CommonImport
+	for k, n := range counts {
+		if n >= minFiles {
+			common = append(common, CommonImport{Dir: k.dir, Import: k.line, Files: n, TotalFiles: dirFiles[k.dir]})
+		}
+	}
+	sort.Slice(common, func(i, j int) bool {
+		if common[i].Files != common[j].Files {
+			return common[i].Files > common[j].Files
+		}
+		if common[i].Dir != common[j].Dir {
+			return common[i].Dir < common[j].Dir
+		}
+		return common[i].Import < common[j].Import
+	})
+	if len(common) > maxCommonImports {
+		common = common[:maxCommonImports]
+	}
+	return common
+}
+
+// importLines returns a file's distinct import lines, whitespace-normalized. Lines in
+// a Go-style `import (` block are prefixed with the keyword so they read like the
+// single-line form.
+func importLines(content string, lang Language) []string {
+	seen := make(map[string]bool)
+	var lines []string
+	add := func(line string) {
+		line = strings.Join(strings.Fields(strings.TrimSuffix(line, ";")), " ")
+		if !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+
+	block := "" // keyword of the open import block
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if block != "" {
+			if trimmed == ")" {
+				block = ""
+			} else if trimmed != "" && !isCommentOnly(trimmed, &lang) {
+				add(block + " " + trimmed)
+			}
+			continue
+		}
+		word := extractFirstWord(trimmed)
+		if !lang.SkipWords[word] || !importKeywords[word] {
+			continue
+		}
+		if strings.HasSuffix(trimmed, "(") && strings.TrimSpace(strings.TrimSuffix(trimmed, "(")) == word {
+			block = word
+			continue
+		}
+		add(trimmed)
+	}
+	return lines
+}
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+)
+
+// maxInterleavePeriod bounds the length of the repeating unit (A B) of an interleaved
+// region, in entries
+const maxInterleavePeriod = 200
+
+// interleavedRegion is a stretch of a file where a unit of two or more distinct
+// blocks repeats, A B A B ...
+type interleavedRegion struct {
+	start, end int   // entry range the period holds over
+	period     int   // unit length
+	blocks     []int // unit-relative offsets of the blocks, the first at the unit start
+	rotation   int   // offset from start to the first unit start
+}
+
+// closingWords start lines that end a block rather than begin one
+var closingWords = map[string]bool{
+	"}": true, ")": true, "]": true, "end": true, "fi": true, "done": true, "esac": true,
+}
+
+// splitInterleaved reports the blocks of interleaved regions as clean patterns.
+// When two templates alternate (A B A B A B), every window containing both is
+// periodic: growth merges them into patterns whose occurrences overlap each other,
+// and the overlap filter then drops them, leaving fragments or nothing. The repeating
+// unit is split at its top-level lines into its blocks, each block is reported with
+// one occurrence per repetition, and patterns with occurrences inside the region are
+// replaced by them.
+func splitInterleaved(fileData map[string][]Entry, patterns map[uint64][]PatternLocation, minSize, minOccur int) {
+	regions := make(map[string][]interleavedRegion)
+	clean := make(map[uint64][]PatternLocation)
+	for filename, entries := range fileData {
+		for _, r := range interleavedRegions(entries, minSize) {
+			regions[filename] = append(regions[filename], r)
+			for b, offset := range r.blocks {
+				length := r.period - offset
+				if b+1 < len(r.blocks) {
+					length = r.blocks[b+1] - offset
+				}
+				first := r.start + (r.rotation+offset)%r.period
+				for q := first; q+length <= r.end; q += r.period {
+					window := entries[q : q+length]
+					pattern := make([]Entry, length)
+					copy(pattern, window)
+					hash := activeStrategy.Hash(window)
+					clean[hash] = append(clean[hash], PatternLocation{
+						Filename:   filename,
+						LineStart:  window[0].GetLineNumber(),
+						EntryIndex: q,
+						Pattern:    pattern,
+					})
+				}
+			}
+		}
+	}
+	if len(clean) == 0 {
+		return
+	}
+
+	inRegion := func(loc PatternLocation) bool {
+		for _, r := range regions[loc.Filename] {
+			if loc.EntryIndex >= r.start && loc.EntryIndex+len(loc.Pattern) <= r.end {
+				return true
+			}
+		}
+		return false
+	}
+	for hash, locs := range patterns {
+		if _, ok := clean[hash]; ok {
+			continue
+		}
+		kept := make([]PatternLocation, 0, len(locs))
+		for _, loc := range locs {
+			if !inRegion(loc) {
+				kept = append(kept, loc)
+			}
+		}
+		if len(kept) < len(locs) {
+			if len(kept) >= minOccur {
+				patterns[hash] = kept
+			} else {
+				delete(patterns, hash)
+			}
+		}
+	}
+
+	for hash, locs := range clean {
+		seen := make(map[OccurrenceKey]bool)
+		merged := make([]PatternLocation, 0, len(patterns[hash])+len(locs))
+		for _, loc := range append(patterns[hash], locs...) {
+			key := OccurrenceKey{loc.Filename, loc.EntryIndex}
+			if !seen[key] {
+				seen[key] = true
+				merged = append(merged, loc)
+			}
+		}
+		if len(merged) >= minOccur {
+			patterns[hash] = merged
+		}
+	}
+}
+
+// interleavedRegions finds the interleaved regions of a file: runs where entries
+// repeat with a period of two or more blocks of at least minSize entries each
+func interleavedRegions(entries []Entry, minSize int) []interleavedRegion {
+	var regions []interleavedRegion
+	n := len(entries)
+	for i := 0; i < n; {
+		found := false
+		for p := 2 * minSize; p <= maxInterleavePeriod && i+2*p <= n; p++ {
+			k := 0
+			for i+k+p < n && bytes.Equal(entries[i+k].HashBytes(), entries[i+k+p].HashBytes()) {
+				k++
+			}
+			if k < p {
+				continue // not even two full repetitions
+			}
+			// The smallest period wins: a single block repeating (A A A) is a
+			// consecutive repeat, not an interleaving
+			rotation, blocks := unitBlocks(entries[i:i+p], minSize)
+			if len(blocks) >= 2 {
+				regions = append(regions, interleavedRegion{start: i, end: i + k + p, period: p, blocks: blocks, rotation: rotation})
+			}
+			i += k + p
+			found = true
+			break
+		}
+		if !found {
+			i++
+		}
+	}
+	return regions
+}
+
+// unitBlocks splits a repeating unit into blocks at its top-level lines: the least
+// indented ones that don't close a block. It returns the offset of the first block
+// and the block offsets relative to it; blocks under minSize entries join the next.
+func unitBlocks(unit []Entry, minSize int) (int, []int) {
+	p := len(unit)
+	indents := make([]int, p)
+	minIndent := -1
+	for i, e := range unit {
+		indents[i] = calculateIndent(e.GetRaw())
+		if minIndent < 0 || indents[i] < minIndent {
+			minIndent = indents[i]
+		}
+	}
+	var starts []int
+	for i, e := range unit {
+		if indents[i] == minIndent && !closingWords[firstWord(strings.TrimSpace(e.GetRaw()), false)] {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) < 2 {
+		return 0, nil
+	}
+
+	// Walk the unit from its first top-level line, merging blocks shorter than minSize
+	rotation := starts[0]
+	var blocks []int
+	for _, s := range starts {
+		offset := s - rotation
+		if len(blocks) > 0 && offset-blocks[len(blocks)-1] < minSize {
+			continue // previous block too short: it extends to here
+		}
+		blocks = append(blocks, offset)
+	}
+	// A short last block wraps around into the first
+	if len(blocks) >= 2 && p-blocks[len(blocks)-1] < minSize {
+		blocks = blocks[:len(blocks)-1]
+	}
+	if len(blocks) < 2 {
+		return 0, nil
+	}
+	return rotation, blocks
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInterleavedBlocksSplitIntoPatterns(t *testing.T) {
+	useStrategy(t, "normalized-indent")
+	silence(t)
+	alpha := "func alpha(x int) int {\n\ty := x * 2\n\treturn y\n}\n"
+	beta := "type Beta struct {\n\tName string\n\tAge  int\n\tTags []string\n}\n"
+	gamma := "var gamma = map[string]int{\n\t\"a\": 1,\n\t\"b\": 2,\n}\n"
+	tests := []struct {
+		name      string
+		templates []string
+		reps      int
+	}{
+		{"A B A B A B", []string{alpha, beta}, 3},
+		{"A B C A B C", []string{alpha, beta, gamma}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package p\n\n" + strings.Repeat(strings.Join(tt.templates, "\n"), tt.reps)
+			fileData := map[string][]Entry{"p.go": parseSource(t, "p.go", src)}
+			patterns := detectPatterns(fileData, 1, 2, 3, 0, false)
+			matches, _ := FilterPatterns(patterns, FilterConfig{MinOccur: 2})
+
+			for _, template := range tt.templates {
+				lines := strings.Split(strings.TrimSuffix(template, "\n"), "\n")
+				found := false
+				for _, m := range matches {
+					if strings.TrimSpace(m.Pattern[0].GetRaw()) == lines[0] && len(m.Pattern) == len(lines) {
+						found = true
+						if len(m.Locations) != tt.reps {
+							t.Errorf("%q: %d occurrences, want %d", lines[0], len(m.Locations), tt.reps)
+						}
+					}
+				}
+				if !found {
+					t.Errorf("no %d-line pattern starting %q", len(lines), lines[0])
+				}
+			}
+			for _, m := range matches {
+				if len(m.Pattern) > 5 {
+					t.Errorf("pattern of %d lines starting %q spans several blocks", len(m.Pattern), m.Pattern[0].GetRaw())
+				}
+			}
+		})
+	}
+}
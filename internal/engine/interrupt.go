@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ScanState is the in-progress scan, reachable from the Ctrl-C and -timeout handlers
+// so a scan cut short still saves its parse cache and writes what it has found,
+// marked partial. Safe for concurrent use.
+type ScanState struct {
+	mu         sync.Mutex
+	phase      string
+	cache      *CacheWriter
+	outputPath string // empty when the run doesn't write results
+	extras     ResultsExtras
+	matches    []PatternMatch
+	finished   bool // the full results are being written; nothing to salvage
+}
+
+// scan is the state of the current scan
+var scan = &ScanState{}
+
+// Phase records the phase the scan is in, reported in partial results
+func (s *ScanState) Phase(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase = phase
+}
+
+// SetCache sets the parse cache to flush when the scan is cut short
+func (s *ScanState) SetCache(w *CacheWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = w
+}
+
+// SetOutput sets where partial results go and the sections known up front
+func (s *ScanState) SetOutput(path string, extras ResultsExtras) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outputPath, s.extras = path, extras
+}
+
+// SetMatches records the filtered matches, the bulk of any partial results
+func (s *ScanState) SetMatches(matches []PatternMatch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matches = matches
+}
+
+// Finish marks the scan as writing its full results
+func (s *ScanState) Finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished = true
+}
+
+// Abort saves the parse cache and writes whatever results exist, marked partial
+// with the phase the scan was in
+func (s *ScanState) Abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache.Flush()
+	if s.finished || s.outputPath == "" {
+		return
+	}
+	extras := s.extras
+	extras.Interrupted = s.phase
+	if err := WriteJSONResults(s.matches, extras, s.outputPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Partial results written to: %s\n", s.outputPath)
+}
+
+// handleInterrupts aborts the scan on SIGINT or SIGTERM and exits with status 130
+func handleInterrupts() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		fmt.Fprintf(os.Stderr, "Interrupted (%s), saving parse cache and partial results\n", sig)
+		scan.Abort()
+		os.Exit(130)
+	}()
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// jsonStreamWriter writes a top-level JSON object one field at a time, producing the
+// same layout as json.MarshalIndent(v, "", "  ") without holding the whole document
+// in memory. Array fields can be streamed element by element.
+type jsonStreamWriter struct {
+	w      *bufio.Writer
+	buf    bytes.Buffer
+	enc    *json.Encoder
+	fields int
+	items  int
+	err    error
+}
+
+func newJSONStreamWriter(w io.Writer) *jsonStreamWriter {
+	s := &jsonStreamWriter{w: bufio.NewWriter(w)}
+	s.enc = json.NewEncoder(&s.buf)
+	s.w.WriteString("{")
+	return s
+}
+
+// encode renders v indented for the given nesting prefix, without the trailing newline
+func (s *jsonStreamWriter) encode(v any, prefix string) []byte {
+	s.buf.Reset()
+	s.enc.SetIndent(prefix, "  ")
+	if err := s.enc.Encode(v); err != nil && s.err == nil {
+		s.err = err
+	}
+	return bytes.TrimSuffix(s.buf.Bytes(), []byte("\n"))
+}
+
+// key writes the separator and quoted name of the next top-level field
+func (s *jsonStreamWriter) key(name string) {
+	if s.fields > 0 {
+		s.w.WriteString(",")
+	}
+	s.fields++
+	s.w.WriteString("\n  ")
+	s.w.Write(s.encode(name, ""))
+	s.w.WriteString(": ")
+}
+
+// Field writes a complete top-level field
+func (s *jsonStreamWriter) Field(name string, v any) {
+	s.key(name)
+	s.w.Write(s.encode(v, "  "))
+}
+
+// BeginArray starts a top-level array field whose elements are written with Item
+func (s *jsonStreamWriter) BeginArray(name string) {
+	s.key(name)
+	s.w.WriteString("[")
+	s.items = 0
+}
+
+// Item writes one element of the current array
+func (s *jsonStreamWriter) Item(v any) {
+	if s.items > 0 {
+		s.w.WriteString(",")
+	}
+	s.items++
+	s.w.WriteString("\n    ")
+	s.w.Write(s.encode(v, "    "))
+}
+
+// EndArray closes the current array
+func (s *jsonStreamWriter) EndArray() {
+	if s.items > 0 {
+		s.w.WriteString("\n  ")
+	}
+	s.w.WriteString("]")
+}
+
+// Close finishes the object and flushes, returning the first error encountered
+func (s *jsonStreamWriter) Close() error {
+	if s.fields > 0 {
+		s.w.WriteString("\n")
+	}
+	s.w.WriteString("}")
+	if err := s.w.Flush(); err != nil && s.err == nil {
+		s.err = err
+	}
+	return s.err
+}
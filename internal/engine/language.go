@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LanguageConfig overrides or extends the built-in rules of one language, keyed by
+// extension in the "languages" section of .quickdup.json. Set fields replace the
+// built-in value; an extension without built-in rules gets new ones.
+type LanguageConfig struct {
+	Comment   *string  `json:"comment,omitempty"`    // line comment prefix, "" for none
+	SkipWords []string `json:"skip_words,omitempty"` // first words of lines to skip (imports, package clauses)
+	Fence     string   `json:"fence,omitempty"`      // markdown code block language
+	Quotes    *string  `json:"quotes,omitempty"`     // characters quoting string literals, "" for none
+}
+
+// Language is the set of rules parsing applies to one file, resolved from its extension
+type Language struct {
+	Ext           string          // extension whose rules apply (see languageExt)
+	CommentPrefix string          // line comment prefix, "" for none
+	SkipWords     map[string]bool // first words of lines to skip
+	StringQuotes  string          // characters quoting string literals, "" when not known
+
+	BracketAttributes bool // a line like [Serializable] is an attribute, not an array literal
+	DoubledQuotes     bool // a doubled quote inside a string literal is an escaped quote: 'it''s'
+}
+
+// bracketAttributes are the extensions whose attributes are written in square brackets
+var bracketAttributes = map[string]bool{".cs": true, ".fs": true}
+
+// doubledQuotes are the extensions whose string literals escape a quote by doubling it
+var doubledQuotes = map[string]bool{".sql": true, ".vb": true, ".bas": true, ".vbs": true, ".ps1": true}
+
+// stringQuotes are the characters quoting string literals by extension. Languages where
+// a quote also means something else (Rust lifetimes, Lisp quoting, VB comments) only
+// list the unambiguous ones.
+var stringQuotes = map[string]string{
+	".go": "\"'`", ".js": "\"'`", ".jsx": "\"'`", ".ts": "\"'`", ".tsx": "\"'`", ".dart": "\"'",
+	".c": "\"'", ".h": "\"'", ".cpp": "\"'", ".hpp": "\"'", ".cc": "\"'", ".cxx": "\"'",
+	".java": "\"'", ".cs": "\"'", ".kt": "\"'", ".kts": "\"'", ".scala": "\"'", ".swift": "\"",
+	".rs": "\"", ".php": "\"'", ".m": "\"'", ".mm": "\"'", ".zig": "\"'",
+	".py": "\"'", ".rb": "\"'", ".sh": "\"'", ".bash": "\"'", ".zsh": "\"'", ".pl": "\"'", ".pm": "\"'",
+	".r": "\"'", ".R": "\"'", ".ps1": "\"'", ".jl": "\"", ".ex": "\"'", ".exs": "\"'", ".cr": "\"",
+	".yaml": "\"'", ".yml": "\"'", ".toml": "\"'", ".tf": "\"", ".json": "\"",
+	".sql": "'", ".lua": "\"'", ".hs": "\"", ".elm": "\"", ".erl": "\"'", ".hrl": "\"'",
+	".lisp": "\"", ".cl": "\"", ".scm": "\"", ".clj": "\"", ".cljs": "\"", ".el": "\"",
+	".vb": "\"", ".bas": "\"", ".vbs": "\"",
+}
+
+// defaultCommentPrefix is the comment prefix of extensions with no known one
+const defaultCommentPrefix = "//"
+
+// commentOverride replaces every language's comment prefix when set (from --comment)
+var commentOverride string
+
+// registeredLanguages are the configs registerLanguages applied, for cache keys
+var registeredLanguages map[string]LanguageConfig
+
+// registerLanguages applies language configs to the built-in tables (commentPrefixes,
+// skipFirstWords, langFromExt, stringQuotes). It runs once before scanning: parser workers read the
+// tables concurrently and nothing writes them afterwards.
+func registerLanguages(configs map[string]LanguageConfig) error {
+	for ext, c := range configs {
+		if ext != strings.ToLower(ext) || (!strings.HasPrefix(ext, ".") && ext != "makefile") {
+			return fmt.Errorf("languages: %q must be a lowercase extension like .foo", ext)
+		}
+		if c.Comment != nil {
+			commentPrefixes[ext] = *c.Comment
+		}
+		if c.SkipWords != nil {
+			words := make(map[string]bool, len(c.SkipWords))
+			for _, w := range c.SkipWords {
+				words[w] = true
+			}
+			skipFirstWords[ext] = words
+		}
+		if c.Fence != "" {
+			langFromExt[ext] = c.Fence
+		}
+		if c.Quotes != nil {
+			stringQuotes[ext] = *c.Quotes
+		}
+	}
+	registeredLanguages = configs
+	return nil
+}
+
+// languageFor resolves the rules for a file from its extension
+func languageFor(path string) Language {
+	return languageForExt(fileType(path))
+}
+
+// languageForExt resolves the rules for files of an extension (see fileType), following
+// its alias
+func languageForExt(ext string) Language {
+	ext = languageExt(ext)
+	prefix, ok := commentPrefixes[ext]
+	if !ok {
+		prefix = defaultCommentPrefix
+	}
+	if commentOverride != "" {
+		prefix = commentOverride
+	}
+	return Language{
+		Ext:               ext,
+		CommentPrefix:     prefix,
+		SkipWords:         skipFirstWords[ext],
+		StringQuotes:      stringQuotes[ext],
+		BracketAttributes: bracketAttributes[ext],
+		DoubledQuotes:     doubledQuotes[ext],
+	}
+}
+
+// languagesKey describes the registered language configs for cache keys
+func languagesKey() string {
+	var parts []string
+	for ext, c := range registeredLanguages {
+		part := ext
+		if c.Comment != nil {
+			part += " comment=" + *c.Comment
+		}
+		if c.SkipWords != nil {
+			words := append([]string(nil), c.SkipWords...)
+			sort.Strings(words)
+			part += " skip=" + strings.Join(words, "|")
+		}
+		if c.Quotes != nil {
+			part += " quotes=" + *c.Quotes
+		}
+		parts = append(parts, part)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
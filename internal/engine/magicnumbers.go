@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MagicNumberLocation is a line a magic number appears on
+type MagicNumberLocation struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+}
+
+// MagicNumber is a numeric literal repeated across files, a candidate for a named
+// constant (--magic-numbers)
+type MagicNumber struct {
+	Value     string                `json:"value"` // normalized literal: no digit separators or type suffix
+	Files     int                   `json:"files"`
+	Locations []MagicNumberLocation `json:"locations"`
+}
+
+// maxMagicNumbers is how many numbers the report lists
+const maxMagicNumbers = 20
+
+// maxTrivialNumber is the largest integer too common to report: 0, 1 and small loop
+// bounds or indexes
+const maxTrivialNumber = 10
+
+// numericLiteral matches decimal, float and hex literals, with digit separators and a
+// type suffix (100L, 1.5f, 1_000)
+var numericLiteral = regexp.MustCompile(`^(0[xX][0-9a-fA-F_]+|[0-9][0-9_]*(\.[0-9][0-9_]*)?([eE][0-9]+)?)([uU]?[lL]{0,2}|[fFdDmMn])$`)
+
+// constantKeywords open declarations that name a number, which is the fix rather than
+// the smell
+var constantKeywords = map[string]bool{
+	"const": true, "#define": true, "final": true, "readonly": true, "enum": true, "constexpr": true,
+}
+
+// constantName matches an upper-case assignment like MAX_RETRIES = 3, the convention
+// for constants in languages without a keyword for them
+var constantName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*\s*(:[^=]*)?=[^=]`)
+
+// findMagicNumbers tallies numeric literals outside constant declarations, strings and
+// comments, keeping those with at least minOccur occurrences across two or more files,
+// most repeated first. Unreadable files are recorded as warnings.
+func findMagicNumbers(files []string, minOccur int, warnings *Warnings) []MagicNumber {
+	byValue := make(map[string][]MagicNumberLocation)
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			warnings.Add("magic-numbers", path, err)
+			continue
+		}
+		for _, lit := range numberLiterals(string(content), languageFor(path)) {
+			byValue[lit.text] = append(byValue[lit.text], MagicNumberLocation{Filename: path, Line: lit.line})
+		}
+	}
+
+	var numbers []MagicNumber
+	for value, locs := range byValue {
+		if len(locs) < minOccur {
+			continue
+		}
+		files := make(map[string]bool)
+		for _, loc := range locs {
+			files[loc.Filename] = true
+		}
+		if len(files) < 2 {
+			continue // a file repeating its own number is a local matter
+		}
+		numbers = append(numbers, MagicNumber{Value: value, Files: len(files), Locations: locs})
+	}
+	sort.Slice(numbers, func(i, j int) bool {
+		if len(numbers[i].Locations) != len(numbers[j].Locations) {
+			return len(numbers[i].Locations) > len(numbers[j].Locations)
+		}
+		return numbers[i].Value < numbers[j].Value
+	})
+	if len(numbers) > maxMagicNumbers {
+		numbers = numbers[:maxMagicNumbers]
+	}
+	return numbers
+}
+
+// numberLiteral is a normalized numeric literal and its line
+type numberLiteral struct {
+	line int
+	text string
+}
+
+// numberLiterals returns the non-trivial numeric literals of a file's code, normalized,
+// once per line. Comment lines, skipped-word lines (imports, packages) and constant
+// declarations, including Go-style `const (` blocks, are left out.
+func numberLiterals(content string, lang Language) []numberLiteral {
+	var literals []numberLiteral
+	inConstBlock := false
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inConstBlock {
+			inConstBlock = trimmed != ")"
+			continue
+		}
+		word := firstWord(trimmed, true)
+		if trimmed == "" || isCommentOnly(trimmed, &lang) || strings.HasPrefix(trimmed, "*") || lang.SkipWords[word] {
+			continue
+		}
+		if constantKeywords[word] || constantName.MatchString(trimmed) {
+			inConstBlock = strings.HasSuffix(trimmed, "(")
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, field := range strings.FieldsFunc(codeOnly(trimmed, lang.CommentPrefix), func(r rune) bool {
+			return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r == '.')
+		}) {
+			if value, ok := magicValue(strings.TrimRight(field, ".")); ok && !seen[value] {
+				seen[value] = true
+				literals = append(literals, numberLiteral{line: i + 1, text: value})
+			}
+		}
+	}
+	return literals
+}
+
+// codeOnly blanks a line's string and character literals and cuts its trailing comment
+func codeOnly(line, commentPrefix string) string {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			b.WriteByte(' ')
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+			b.WriteByte(' ')
+		case commentPrefix != "" && strings.HasPrefix(line[i:], commentPrefix):
+			return b.String()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// magicValue normalizes a numeric literal, reporting false for anything else and for
+// trivial values
+func magicValue(field string) (string, bool) {
+	m := numericLiteral.FindStringSubmatch(field)
+	if m == nil {
+		return "", false
+	}
+	value := strings.ToLower(strings.ReplaceAll(m[1], "_", ""))
+	if strings.HasPrefix(value, "0x") {
+		if n, err := strconv.ParseUint(value[2:], 16, 64); err == nil && n <= maxTrivialNumber {
+			return "", false
+		}
+		return value, true
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", false
+	}
+	if f == math.Trunc(f) && f <= maxTrivialNumber {
+		return "", false
+	}
+	return value, true
+}
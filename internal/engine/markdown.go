@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stdoutFormats print the report; a run takes at most one of them
+var stdoutFormats = map[string]bool{"text": true, "terminal-wide": true, "grep": true}
+
+// fileFormats are written to .quickdup/ next to the report: json is the results file
+// (always written), markdown the detailed view of the top matches in patterns.md,
+// sarif a code scanning log in results.sarif
+var fileFormats = map[string]bool{"json": true, "markdown": true, "sarif": true}
+
+// parseFormats parses --format: a comma-separated list of at most one stdout format,
+// text when none is given, and any file formats
+func parseFormats(s string) (string, map[string]bool, error) {
+	stdout := ""
+	files := make(map[string]bool)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		switch {
+		case stdoutFormats[f]:
+			if stdout != "" && stdout != f {
+				return "", nil, fmt.Errorf("%s and %s both print to stdout; pick one", stdout, f)
+			}
+			stdout = f
+		case fileFormats[f]:
+			files[f] = true
+		default:
+			return "", nil, fmt.Errorf("unknown format %q (want text, terminal-wide, grep, json, markdown or sarif)", f)
+		}
+	}
+	if stdout == "" {
+		stdout = "text"
+	}
+	return stdout, files, nil
+}
+
+// codeBlock renders lines as a markdown code block in the file's language, cut to
+// detailMaxLines; it returns the number of lines cut
+func codeBlock(filename string, lines []string) (string, int) {
+	hidden := 0
+	if detailMaxLines > 0 && len(lines) > detailMaxLines {
+		hidden = len(lines) - detailMaxLines
+		lines = lines[:detailMaxLines]
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("```%s\n", langForFile(filename)))
+	for _, line := range lines {
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("```\n")
+	return sb.String(), hidden
+}
+
+// WriteMarkdownReport writes the detailed view of matches, code included, as a
+// markdown document (--format markdown)
+func WriteMarkdownReport(matches []PatternMatch, path string) error {
+	var b strings.Builder
+	b.WriteString("# Duplicate patterns\n")
+	for i, m := range matches {
+		fmt.Fprintf(&b, "\n## Pattern %d `%016x`\n\n", i+1, m.Hash)
+		fmt.Fprintf(&b, "Score %d · %.0f%% similar · %d lines · %d occurrences\n",
+			m.Score, m.Similarity*100, len(m.Pattern), len(m.Locations))
+		if len(m.Substitutions) > 0 {
+			fmt.Fprintf(&b, "\nParametrizable: %s\n", formatSubstitutions(m.Substitutions))
+		}
+		for j, loc := range shownOccurrences(m.Locations) {
+			fmt.Fprintf(&b, "\n### Occurrence %d: `%s:%s`\n\n", j+1, loc.Filename, locationLines(loc))
+			block, hidden := codeBlock(loc.Filename, normalizeIndent(loc.Pattern))
+			b.WriteString(block)
+			if hidden > 0 {
+				fmt.Fprintf(&b, "\n_... %d more lines_\n", hidden)
+			}
+		}
+		if detailMaxOccurrences > 0 && len(m.Locations) > detailMaxOccurrences {
+			fmt.Fprintf(&b, "\n_... %d more occurrences_\n", len(m.Locations)-detailMaxOccurrences)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return &ScanError{Op: "create output directory", Path: filepath.Dir(path), Err: err}
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return &ScanError{Op: "write markdown report", Path: path, Err: err}
+	}
+	return nil
+}
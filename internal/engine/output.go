@@ -0,0 +1,1565 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+)
+
+// Theme defines the color scheme for console output
+type Theme struct {
+	Score    lipgloss.Style
+	Hash     lipgloss.Style
+	Location lipgloss.Style
+	LineNum  lipgloss.Style
+	Summary  lipgloss.Style
+	Dim      lipgloss.Style
+}
+
+// One Dark palette (Atom One)
+const (
+	colorBranch = "#5C6370" // comment gray
+	colorGreen  = "#98C379" // green
+	colorPurple = "#C678DD" // purple
+	colorBlue   = "#61AFEF" // blue
+	colorText   = "#ABB2BF" // light gray
+	colorRed    = "#E06C75" // red
+	colorYellow = "#E5C07B" // yellow
+)
+
+// DefaultTheme is the default color scheme
+var DefaultTheme = Theme{
+	Score:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(colorYellow)),
+	Hash:     lipgloss.NewStyle().Foreground(lipgloss.Color(colorBranch)),
+	Location: lipgloss.NewStyle().Foreground(lipgloss.Color(colorBlue)),
+	LineNum:  lipgloss.NewStyle().Foreground(lipgloss.Color(colorPurple)),
+	Summary:  lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(colorGreen)),
+	Dim:      lipgloss.NewStyle().Foreground(lipgloss.Color(colorBranch)),
+}
+
+// Current theme (can be changed at runtime)
+var theme = DefaultTheme
+
+// Similarity color styles
+var (
+	simGreen       = lipgloss.NewStyle().Foreground(lipgloss.Color(colorGreen))  // 95%+
+	simYellowGreen = lipgloss.NewStyle().Foreground(lipgloss.Color(colorBlue))   // 85%+
+	simOrange      = lipgloss.NewStyle().Foreground(lipgloss.Color(colorPurple)) // 75%+
+	simRed         = lipgloss.NewStyle().Foreground(lipgloss.Color(colorRed))    // 60%+
+	simDim         = lipgloss.NewStyle().Foreground(lipgloss.Color(colorBranch)) // below 60%
+)
+
+// renderSimilarity returns a colorized similarity string
+func renderSimilarity(similarity float64) string {
+	pct := similarity * 100
+	text := fmt.Sprintf("%.0f%% similar", pct)
+	switch {
+	case pct >= 95:
+		return simGreen.Render(text)
+	case pct >= 85:
+		return simYellowGreen.Render(text)
+	case pct >= 75:
+		return simOrange.Render(text)
+	case pct >= 60:
+		return simRed.Render(text)
+	default:
+		return simDim.Render(text)
+	}
+}
+
+// PrintScanStart prints the initial scanning message
+func PrintScanStart(fileCount, workerCount int) {
+	fmt.Printf("Scanning %d files using %d workers...\n", fileCount, workerCount)
+}
+
+// PrintCacheSkipped prints why the parse cache isn't used, if it isn't
+func PrintCacheSkipped(reason string) {
+	if reason != "" {
+		fmt.Printf("%s\n", theme.Dim.Render("Parse cache: "+reason))
+	}
+}
+
+// PrintParseComplete prints parsing completion stats
+func PrintParseComplete(fileCount, cacheHits, cacheMisses, totalLines int, duration time.Duration) {
+	if cacheHits > 0 {
+		fmt.Printf("Parsed %d files (%d cached, %d parsed) in %s (%d lines of code)\n",
+			fileCount, cacheHits, cacheMisses, duration.Round(time.Millisecond), totalLines)
+	} else {
+		fmt.Printf("Parsed %d files in %s (%d lines of code)\n",
+			fileCount, duration.Round(time.Millisecond), totalLines)
+	}
+}
+
+// PrintSkippedBinary prints how many files were skipped for binary content
+func PrintSkippedBinary(count int) {
+	if count > 0 {
+		fmt.Printf("Skipped %d binary files (check -ext)\n", count)
+	}
+}
+
+// PrintSkippedIdentical prints how many byte-identical file copies weren't scanned
+func PrintSkippedIdentical(sets []IdenticalFiles) {
+	copies := 0
+	for _, set := range sets {
+		copies += len(set.Copies)
+	}
+	if copies > 0 {
+		fmt.Printf("Skipped %d identical copies of %d files (-keep-identical-files to scan them)\n", copies, len(sets))
+	}
+}
+
+// PrintIdenticalFiles lists the sets of byte-identical files, scanned once each
+func PrintIdenticalFiles(sets []IdenticalFiles) {
+	if len(sets) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", theme.Summary.Render("Identical files (scanned once):"))
+	for _, set := range sets {
+		fmt.Printf("  %s\n", theme.Location.Render(set.Kept))
+		for _, c := range set.Copies {
+			fmt.Printf("    %s %s\n", theme.Dim.Render("="), c)
+		}
+	}
+}
+
+// PrintSkippedOversized prints how many files were skipped as minified or too long
+func PrintSkippedOversized(minified, tooLong, maxLineLength, maxFileLines int) {
+	if minified > 0 {
+		fmt.Printf("Skipped %d minified files (mostly lines over %d bytes)\n", minified, maxLineLength)
+	}
+	if tooLong > 0 {
+		fmt.Printf("Skipped %d files over %d lines\n", tooLong, maxFileLines)
+	}
+}
+
+// PrintDetectStart prints pattern detection start message
+func PrintDetectStart() {
+	fmt.Printf("Detecting patterns...\n")
+}
+
+// PrintDetectComplete prints pattern detection completion
+func PrintDetectComplete(duration time.Duration) {
+	fmt.Printf("Pattern detection took %s\n", duration.Round(time.Millisecond))
+}
+
+// PrintFilterComplete prints filtering completion and stats
+func PrintFilterComplete(duration time.Duration, skippedBlocked, skippedLowScore, skippedLowSimilarity, skippedHighSimilarity, skippedSuppressed, skippedData, skippedUngrown, skippedFewDirs int, scoreThreshold string, minSimilarity, maxSimilarity float64, minDirs int) {
+	fmt.Printf("Filtering took %s\n", duration.Round(time.Millisecond))
+
+	if skippedBlocked > 0 {
+		fmt.Printf("Filtered %d common patterns\n", skippedBlocked)
+	}
+	if skippedLowScore > 0 {
+		fmt.Printf("Filtered %d low-score patterns (score < %s)\n", skippedLowScore, scoreThreshold)
+	}
+	if skippedLowSimilarity > 0 {
+		fmt.Printf("Filtered %d low-similarity patterns (similarity < %.0f%%)\n", skippedLowSimilarity, minSimilarity*100)
+	}
+	if skippedHighSimilarity > 0 {
+		fmt.Printf("Filtered %d near-identical patterns (similarity > %.0f%%)\n", skippedHighSimilarity, maxSimilarity*100)
+	}
+	if skippedSuppressed > 0 {
+		fmt.Printf("Filtered %d patterns suppressed by %s markers\n", skippedSuppressed, ignoreMarker)
+	}
+	if skippedData > 0 {
+		fmt.Printf("Filtered %d data-definition patterns\n", skippedData)
+	}
+	if skippedUngrown > 0 {
+		fmt.Printf("Filtered %d patterns that never grew past -min-size\n", skippedUngrown)
+	}
+	if skippedFewDirs > 0 {
+		fmt.Printf("Filtered %d patterns spanning fewer than %d top-level directories\n", skippedFewDirs, minDirs)
+	}
+}
+
+// PrintSimilarityReuse prints how many patterns reused cached similarities
+func PrintSimilarityReuse(hits int) {
+	if hits > 0 {
+		fmt.Printf("Reused cached similarities for %d patterns\n", hits)
+	}
+}
+
+// PrintIgnoredPatterns prints count of loaded ignored patterns
+func PrintIgnoredPatterns(count int) {
+	if count > 0 {
+		fmt.Printf("Loaded %d ignored patterns from ignore.json\n", count)
+	}
+}
+
+// PrintExpectedSnippets prints how many expected-duplication snippets were loaded
+func PrintExpectedSnippets(count int) {
+	if count > 0 {
+		fmt.Printf("Loaded %d expected duplication snippets from %s/\n", count, expectedDir)
+	}
+}
+
+// PrintBaselineUpdate reports the result of pruning ignore.json
+func PrintBaselineUpdate(kept, removed int) {
+	fmt.Printf("Baseline updated: removed %d fixed patterns from ignore.json, kept %d\n", removed, kept)
+}
+
+// terminalWidth returns the width of the terminal on stdout, falling back to $COLUMNS or 80
+func terminalWidth() int {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	var w int
+	if _, err := fmt.Sscanf(os.Getenv("COLUMNS"), "%d", &w); err == nil && w > 0 {
+		return w
+	}
+	return 80
+}
+
+// PrintMatchesWide prints matches like PrintMatches, laying locations out in as many
+// aligned columns (filename left, line right) as fit in width
+func PrintMatchesWide(matches []PatternMatch, width int) {
+	for i, m := range matches {
+		fmt.Printf("\n%s  %s  %s  %s  %s  %s\n",
+			theme.Summary.Render(fmt.Sprintf("Pattern %d", i+1)),
+			theme.Hash.Render(fmt.Sprintf("[%016x]", m.Hash)),
+			theme.Score.Render(fmt.Sprintf("Score %d", m.Score)),
+			renderSimilarity(m.Similarity),
+			theme.Dim.Render(fmt.Sprintf("%d lines", len(m.Pattern))),
+			theme.Dim.Render(fmt.Sprintf("%d occurrences", len(m.Locations))))
+		printSubstitutions(m.Substitutions)
+		printKind(m.Kind)
+		printSteps(m.Steps)
+		printDirs(m.Dirs)
+
+		// Size cells to the longest filename and line number in this match
+		nameWidth, lineWidth := 0, 0
+		for _, loc := range m.Locations {
+			nameWidth = max(nameWidth, lipgloss.Width(loc.Filename))
+			lineWidth = max(lineWidth, lipgloss.Width(locationLines(loc)))
+		}
+		const indent, gap = 2, 4
+		cellWidth := nameWidth + 1 + lineWidth
+		columns := max(1, (width-indent+gap)/(cellWidth+gap))
+
+		nameStyle := theme.Location.Width(nameWidth + 1)
+		lineStyle := theme.LineNum.Width(lineWidth).Align(lipgloss.Right)
+		var row []string
+		flush := func() {
+			fmt.Println(strings.Repeat(" ", indent) + lipgloss.JoinHorizontal(lipgloss.Top, row...))
+			row = row[:0]
+		}
+		for j, loc := range m.Locations {
+			cell := nameStyle.Render(loc.Filename) + lineStyle.Render(locationLines(loc))
+			if j%columns != columns-1 && j != len(m.Locations)-1 {
+				cell += strings.Repeat(" ", gap)
+			}
+			row = append(row, cell)
+			if len(row) == columns {
+				flush()
+			}
+		}
+		if len(row) > 0 {
+			flush()
+		}
+	}
+}
+
+// PrintGitHubAnnotations outputs GitHub Actions annotations for matches
+func PrintGitHubAnnotations(matches []PatternMatch, top int, githubLevel string, gitDiff string, changedFiles map[string]bool) {
+	annotationCount := 0
+	for _, m := range matches[:top] {
+		loc := m.Locations[0]
+		// Skip if --git-diff is set and file is not in changed files
+		if gitDiff != "" && !changedFiles[loc.Filename] {
+			continue
+		}
+		otherLocs := make([]string, 0, len(m.Locations)-1)
+		for _, other := range m.Locations[1:] {
+			otherLocs = append(otherLocs, fmt.Sprintf("%s:%d", filepath.Base(other.Filename), other.LineStart))
+		}
+		endLine := loc.LineStart + len(m.Pattern) - 1
+		msg := fmt.Sprintf("Duplicate code also at: %s", strings.Join(otherLocs, ", "))
+		fmt.Printf("::%s file=%s,line=%d,endLine=%d,title=Duplicate (%d lines, %.0f%% similar, score %d)::%s\n",
+			githubLevel, loc.Filename, loc.LineStart, endLine, len(m.Pattern), m.Similarity*100, m.Score, msg)
+		annotationCount++
+	}
+	if annotationCount > 0 {
+		fmt.Printf("\n")
+	}
+}
+
+// PrintGrepLocations writes one "file:start:end: message" line per occurrence,
+// a grep/quickfix-compatible format editors can jump to
+func PrintGrepLocations(w io.Writer, matches []PatternMatch) {
+	for _, m := range matches {
+		for _, loc := range m.Locations {
+			fmt.Fprintf(w, "%s:%d:%d: [%016x] duplicate (%d lines, %d occurrences, score %d)\n",
+				loc.Filename, loc.LineStart, locationEndLine(loc), m.Hash, len(m.Pattern), len(m.Locations), m.Score)
+		}
+	}
+}
+
+// PrintMatchSummary prints the summary of found patterns
+func PrintMatchSummary(matchCount, minOccur, top int) {
+	fmt.Printf("Found %s patterns with %d+ occurrences (showing top %d by score)\n\n",
+		theme.Summary.Render(fmt.Sprintf("%d", matchCount)), minOccur, top)
+}
+
+// PrintMatches prints the top matches with their locations
+func PrintMatches(matches []PatternMatch, top int) {
+	for i, m := range matches[:top] {
+		fmt.Printf("\n%s  %s  %s  %s  %s  %s\n",
+			theme.Summary.Render(fmt.Sprintf("Pattern %d", i+1)),
+			theme.Hash.Render(fmt.Sprintf("[%016x]", m.Hash)),
+			theme.Score.Render(fmt.Sprintf("Score %d", m.Score)),
+			renderSimilarity(m.Similarity),
+			theme.Dim.Render(fmt.Sprintf("%d lines", len(m.Pattern))),
+			theme.Dim.Render(fmt.Sprintf("%d occurrences", len(m.Locations))))
+		printSubstitutions(m.Substitutions)
+		printKind(m.Kind)
+		printSteps(m.Steps)
+		printDirs(m.Dirs)
+		for _, loc := range m.Locations {
+			fmt.Printf("  %s%s%s\n",
+				theme.Location.Render(loc.Filename),
+				theme.Dim.Render(":"),
+				theme.LineNum.Render(locationLines(loc)))
+		}
+	}
+}
+
+// PrintMatchesCompact prints one line per match (--compact): score, lines, occurrences,
+// similarity and the representative location
+func PrintMatchesCompact(matches []PatternMatch) {
+	for _, m := range matches {
+		loc := m.Locations[m.Representative]
+		more := ""
+		if len(m.Locations) > 1 {
+			more = theme.Dim.Render(fmt.Sprintf(" (+%d more)", len(m.Locations)-1))
+		}
+		fmt.Printf("%s %s %s %s %s%s%s%s\n",
+			theme.Score.Render(fmt.Sprintf("%5d", m.Score)),
+			theme.Dim.Render(fmt.Sprintf("%4d", len(m.Pattern))),
+			theme.Dim.Render(fmt.Sprintf("%3d", len(m.Locations))),
+			renderSimilarity(m.Similarity),
+			theme.Location.Render(loc.Filename),
+			theme.Dim.Render(":"),
+			theme.LineNum.Render(fmt.Sprintf("%d", loc.LineStart)),
+			more)
+	}
+}
+
+// tierTitles are the section headings of PrintTieredMatches
+var tierTitles = map[string]string{
+	TierActionable:  "Actionable",
+	TierReview:      "Review",
+	TierLikelyNoise: "Likely noise",
+}
+
+// PrintTieredMatches prints the top matches of each triage tier in its own section,
+// using printMatches for the matches of a section
+func PrintTieredMatches(matches []PatternMatch, top int, printMatches func([]PatternMatch)) {
+	byTier := matchesByTier(matches)
+	for _, tier := range tierOrder {
+		tierMatches := byTier[tier]
+		fmt.Printf("\n%s %s\n",
+			theme.Summary.Render(tierTitles[tier]),
+			theme.Dim.Render(fmt.Sprintf("(%d patterns, showing top %d)", len(tierMatches), min(top, len(tierMatches)))))
+		printMatches(TopN(tierMatches, top))
+	}
+}
+
+// Hotspot metrics (--hotspot-metric)
+const (
+	HotspotLines = "lines" // duplicated lines per file
+	HotspotScore = "score" // summed match scores per file, once per occurrence, data definitions excluded
+)
+
+// PrintHotspots prints the duplication hotspots, ranked by metric: duplicated line
+// count, or refactoring value (a file's occurrences of each match weighted by its score)
+func PrintHotspots(matches []PatternMatch, metric string) {
+	// Sum the metric per file
+	fileTotals := make(map[string]int)
+	for _, m := range matches {
+		weight := len(m.Pattern)
+		if metric == HotspotScore {
+			if m.Kind == KindDataDefinition {
+				continue // long tables score high but there's nothing to refactor
+			}
+			weight = m.Score
+		}
+		for _, loc := range m.Locations {
+			fileTotals[loc.Filename] += weight
+		}
+	}
+
+	// Sort files by total
+	type fileHotspot struct {
+		filename string
+		total    int
+	}
+	var hotspots []fileHotspot
+	for f, total := range fileTotals {
+		hotspots = append(hotspots, fileHotspot{f, total})
+	}
+	sort.Slice(hotspots, func(i, j int) bool {
+		return hotspots[i].total > hotspots[j].total
+	})
+
+	// Show top 5 hotspots
+	if len(hotspots) > 0 {
+		fmt.Printf("\n%s\n", theme.Summary.Render("Duplication hotspots ("+metric+"):"))
+		showHotspots := 5
+		if len(hotspots) < showHotspots {
+			showHotspots = len(hotspots)
+		}
+		for i := 0; i < showHotspots; i++ {
+			fmt.Printf("  %s %s\n",
+				theme.LineNum.Render(fmt.Sprintf("%4d", hotspots[i].total)),
+				theme.Location.Render(hotspots[i].filename))
+		}
+	}
+}
+
+// patternLengthBuckets are the histogram buckets by pattern line count (max 0 = open-ended).
+// Patterns under 3 lines only occur with -min-size < 3 and get a bucket of their own.
+var patternLengthBuckets = []struct {
+	label string
+	max   int
+}{{"1-2", 2}, {"3-5", 5}, {"6-10", 10}, {"11-20", 20}, {"21+", 0}}
+
+// lengthHistogram counts matches per pattern-length bucket, omitting an empty 1-2 bucket
+func lengthHistogram(matches []PatternMatch) []LengthBucket {
+	counts := make([]int, len(patternLengthBuckets))
+	for _, m := range matches {
+		for i, b := range patternLengthBuckets {
+			if b.max == 0 || len(m.Pattern) <= b.max {
+				counts[i]++
+				break
+			}
+		}
+	}
+	var histogram []LengthBucket
+	for i, b := range patternLengthBuckets {
+		if i == 0 && counts[i] == 0 {
+			continue
+		}
+		histogram = append(histogram, LengthBucket{Lines: b.label, Patterns: counts[i]})
+	}
+	return histogram
+}
+
+// PrintLengthHistogram prints how many patterns fall in each length bucket
+func PrintLengthHistogram(matches []PatternMatch) {
+	if len(matches) == 0 {
+		return
+	}
+	histogram := lengthHistogram(matches)
+	fmt.Printf("\n%s\n", theme.Summary.Render("Pattern lengths (lines):"))
+	for _, b := range histogram {
+		bar := strings.Repeat("█", (b.Patterns*30+len(matches)-1)/len(matches))
+		fmt.Printf("  %6s %s %s\n", b.Lines, theme.LineNum.Render(fmt.Sprintf("%4d", b.Patterns)), theme.Dim.Render(bar))
+	}
+}
+
+// maxFilePairsShown is how many file pairs the console lists; results.json has all
+const maxFilePairsShown = 10
+
+// PrintLongestPerFilePair prints the file pairs sharing the longest blocks, with that
+// block's occurrence in each file
+func PrintLongestPerFilePair(blocks []FilePairBlock) {
+	if len(blocks) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", theme.Summary.Render(fmt.Sprintf("Longest shared block per file pair (%d pairs):", len(blocks))))
+	for _, b := range blocks[:min(len(blocks), maxFilePairsShown)] {
+		x, y := b.Regions[0], b.Regions[1]
+		fmt.Printf("  %s %s %s\n",
+			theme.LineNum.Render(fmt.Sprintf("%4d lines", b.Lines)),
+			theme.Location.Render(fmt.Sprintf("%s:%d-%d", x.Filename, x.LineStart, x.LineEnd)),
+			theme.Location.Render(fmt.Sprintf("%s:%d-%d", y.Filename, y.LineStart, y.LineEnd)))
+	}
+	if len(blocks) > maxFilePairsShown {
+		fmt.Printf("  %s\n", theme.Dim.Render(fmt.Sprintf("... %d more in results.json", len(blocks)-maxFilePairsShown)))
+	}
+}
+
+// PrintBlameAges prints matches whose newest change falls within the --blame-age window
+func PrintBlameAges(matches []PatternMatch, maxAgeDays int, now time.Time) {
+	fmt.Printf("\n%s\n", theme.Summary.Render(fmt.Sprintf("Recently changed duplication (within %d days):", maxAgeDays)))
+	if len(matches) == 0 {
+		fmt.Printf("  %s\n", theme.Dim.Render("none"))
+		return
+	}
+	for _, m := range matches {
+		fmt.Printf("  %s  %s  %s  %s\n",
+			theme.Hash.Render(fmt.Sprintf("[%016x]", m.Hash)),
+			theme.Score.Render(fmt.Sprintf("Score %d", m.Score)),
+			theme.Dim.Render(fmt.Sprintf("%d lines", len(m.Pattern))),
+			theme.Summary.Render(formatAge(now.Sub(m.NewestChange))))
+		for _, loc := range m.Locations {
+			fmt.Printf("    %s%s%s\n",
+				theme.Location.Render(loc.Filename),
+				theme.Dim.Render(":"),
+				theme.LineNum.Render(locationLines(loc)))
+		}
+	}
+}
+
+// PrintTotalSummary prints the final summary line
+func PrintTotalSummary(matchCount, fileCount, totalLines int, elapsed time.Duration) {
+	fmt.Printf("\nTotal: %s duplicate patterns in %s files (%s lines) in %s\n",
+		theme.Summary.Render(fmt.Sprintf("%d", matchCount)),
+		theme.Summary.Render(fmt.Sprintf("%d", fileCount)),
+		theme.Summary.Render(fmt.Sprintf("%d", totalLines)),
+		theme.Summary.Render(elapsed.Round(time.Millisecond).String()))
+	fmt.Printf("\n%s\n", theme.Dim.Render("Tip: Even partial matches may contain extractable sub-sections. Look for common logic that could be refactored into shared helpers, base classes, modules or using generics functuins / types where supported."))
+}
+
+// PrintDumpHeader prints the heading of a --dump-entries listing
+func PrintDumpHeader(path, strategy string, entries int) {
+	fmt.Printf("%s  %s\n\n",
+		theme.Location.Render(path),
+		theme.Dim.Render(fmt.Sprintf("%s, %d entries (indent delta|word)", strategy, entries)))
+}
+
+// PrintDumpEntry prints a source line with the entry it parsed to
+func PrintDumpEntry(lineNum int, entry, line string) {
+	fmt.Printf("%s  %-24s %s\n", theme.LineNum.Render(fmt.Sprintf("%5d", lineNum)), entry, line)
+}
+
+// PrintDumpSkipped prints a source line that produced no entry, with the reason
+func PrintDumpSkipped(lineNum int, reason, line string) {
+	fmt.Printf("%s  %s %s\n", theme.LineNum.Render(fmt.Sprintf("%5d", lineNum)),
+		theme.Dim.Render(fmt.Sprintf("%-24s", "- ("+reason+")")), theme.Dim.Render(line))
+}
+
+// PrintSummaryOnly writes the bare summary line for --summary-only, or the same
+// numbers as a single JSON object
+func PrintSummaryOnly(w io.Writer, asJSON bool, matchCount, fileCount, totalLines int, elapsed time.Duration) {
+	if asJSON {
+		json.NewEncoder(w).Encode(JSONSummary{
+			Patterns:  matchCount,
+			Files:     fileCount,
+			Lines:     totalLines,
+			ElapsedMs: elapsed.Milliseconds(),
+		})
+		return
+	}
+	fmt.Fprintf(w, "Total: %d duplicate patterns in %d files (%d lines) in %s\n",
+		matchCount, fileCount, totalLines, elapsed.Round(time.Millisecond))
+}
+
+// PrintStrategyComparison prints a side-by-side table of strategy results
+func PrintStrategyComparison(summaries []StrategySummary) {
+	fmt.Printf("\n%s\n", theme.Summary.Render("Strategy comparison:"))
+	fmt.Printf("  %-20s %9s %10s %8s %9s %9s %9s %10s\n",
+		"strategy", "patterns", "dup lines", "median", "blocked", "low-score", "low-sim", "time")
+	for _, s := range summaries {
+		fmt.Printf("  %s %s %10d %7.0f%% %9d %9d %9d %10s\n",
+			theme.Location.Render(fmt.Sprintf("%-20s", s.Name)),
+			theme.Score.Render(fmt.Sprintf("%9d", s.Patterns)),
+			s.DuplicatedLines,
+			s.MedianSimilarity*100,
+			s.Stats.SkippedBlocked,
+			s.Stats.SkippedLowScore,
+			s.Stats.SkippedLowSimilarity,
+			s.Duration.Round(time.Millisecond))
+	}
+}
+
+// PrintShowingPatterns prints the footer showing selected patterns range
+func PrintShowingPatterns(skip, limit int) {
+	fmt.Printf("Showing pattern %s to %s\n",
+		theme.Summary.Render(fmt.Sprintf("%d", skip)),
+		theme.Summary.Render(fmt.Sprintf("%d", limit)))
+}
+
+// langFromExt maps file extensions to markdown code block language hints
+var langFromExt = map[string]string{
+	".go":    "go",
+	".c":     "c",
+	".h":     "c",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cc":    "cpp",
+	".cxx":   "cpp",
+	".java":  "java",
+	".js":    "javascript",
+	".jsx":   "jsx",
+	".ts":    "typescript",
+	".tsx":   "tsx",
+	".cs":    "csharp",
+	".swift": "swift",
+	".kt":    "kotlin",
+	".kts":   "kotlin",
+	".scala": "scala",
+	".rs":    "rust",
+	".php":   "php",
+	".py":    "python",
+	".rb":    "ruby",
+	".sh":    "bash",
+	".bash":  "bash",
+	".zsh":   "zsh",
+	".sql":   "sql",
+	".lua":   "lua",
+	".hs":    "haskell",
+	".elm":   "elm",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".toml":  "toml",
+	".json":  "json",
+	".xml":   "xml",
+	".html":  "html",
+	".css":   "css",
+	".scss":  "scss",
+	".dart":  "dart",
+	".r":     "r",
+	".R":     "r",
+	".jl":    "julia",
+	".ex":    "elixir",
+	".exs":   "elixir",
+	".clj":   "clojure",
+	".cljs":  "clojure",
+	".v":     "v",
+	".zig":   "zig",
+	".nim":   "nim",
+}
+
+// langForFile returns the code block language hint for a file, based on its own extension
+// so that mixed-extension results are highlighted per occurrence; aliased extensions
+// (see languageExt) take their language's hint
+func langForFile(filename string) string {
+	ext := filepath.Ext(filename)
+	if lang, ok := langFromExt[ext]; ok && languageExt(ext) == ext {
+		return lang
+	}
+	ext = languageExt(strings.ToLower(ext))
+	if lang, ok := langFromExt[ext]; ok {
+		return lang
+	}
+	return strings.TrimPrefix(ext, ".")
+}
+
+// normalizeIndent removes common leading whitespace from lines
+func normalizeIndent(entries []Entry) []string {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Find minimum indent across all non-empty lines
+	minIndent := -1
+	for _, e := range entries {
+		line := e.GetRaw()
+		indent := 0
+		for _, r := range line {
+			if r == ' ' {
+				indent++
+			} else if r == '\t' {
+				indent += 4
+			} else {
+				break
+			}
+		}
+		if minIndent < 0 || indent < minIndent {
+			minIndent = indent
+		}
+	}
+
+	// Strip minimum indent from each line
+	result := make([]string, len(entries))
+	for i, e := range entries {
+		line := e.GetRaw()
+		stripped := 0
+		start := 0
+		for j, r := range line {
+			if stripped >= minIndent {
+				start = j
+				break
+			}
+			if r == ' ' {
+				stripped++
+			} else if r == '\t' {
+				stripped += 4
+			} else {
+				start = j
+				break
+			}
+			start = j + 1
+		}
+		result[i] = line[start:]
+	}
+
+	return result
+}
+
+// PrintDetailedMatches prints detailed pattern matches with source code using glow
+func PrintDetailedMatches(matches []PatternMatch) {
+	// Group matches by hash to detect multiple clusters
+	hashCounts := make(map[uint64]int)
+	for _, m := range matches {
+		hashCounts[m.Hash]++
+	}
+
+	// Track cluster number per hash
+	hashClusterNum := make(map[uint64]int)
+
+	for i, m := range matches {
+		// Determine if this hash has multiple clusters
+		clusterInfo := ""
+		if hashCounts[m.Hash] > 1 {
+			hashClusterNum[m.Hash]++
+			clusterInfo = fmt.Sprintf(" (Cluster %d/%d)", hashClusterNum[m.Hash], hashCounts[m.Hash])
+		}
+
+		// Print header with colorized similarity
+		fmt.Printf("\n%s%s  %s  %s  %s  %s  %s\n",
+			theme.Summary.Render(fmt.Sprintf("Pattern %d", i+1)),
+			theme.Dim.Render(clusterInfo),
+			theme.Hash.Render(fmt.Sprintf("[%016x]", m.Hash)),
+			theme.Score.Render(fmt.Sprintf("Score %d", m.Score)),
+			renderSimilarity(m.Similarity),
+			theme.Dim.Render(fmt.Sprintf("%d lines", len(m.Pattern))),
+			theme.Dim.Render(fmt.Sprintf("%d occurrences", len(m.Locations))))
+		printSubstitutions(m.Substitutions)
+		printKind(m.Kind)
+		printSteps(m.Steps)
+		printDirs(m.Dirs)
+		repSims := representativeSimilarities(m)
+
+		// Render each occurrence with styled header + code block
+		for j, loc := range shownOccurrences(m.Locations) {
+			fmt.Printf("\n  %s %s%s\n",
+				theme.LineNum.Render(fmt.Sprintf("Occurrence %d", j+1)),
+				theme.Location.Render(fmt.Sprintf("%s:%s", loc.Filename, locationLines(loc))),
+				occurrenceSimilarityLabel(repSims, j, j == m.Representative))
+			renderCodeBlock(loc.Filename, normalizeIndent(loc.Pattern))
+		}
+		printHiddenOccurrences(len(m.Locations))
+		fmt.Println(theme.Dim.Render("───────────────────────────────────────────────────────────────────────────────"))
+	}
+}
+
+// detailMaxOccurrences and detailMaxLines bound the detailed pattern view (set from
+// --md-max-occurrences and --md-max-lines), 0 for no limit
+var detailMaxOccurrences, detailMaxLines int
+
+// shownOccurrences returns the occurrences the detailed view renders
+func shownOccurrences[L any](locs []L) []L {
+	if detailMaxOccurrences > 0 && len(locs) > detailMaxOccurrences {
+		return locs[:detailMaxOccurrences]
+	}
+	return locs
+}
+
+// printHiddenOccurrences notes how many occurrences shownOccurrences left out
+func printHiddenOccurrences(total int) {
+	if detailMaxOccurrences > 0 && total > detailMaxOccurrences {
+		fmt.Printf("\n  %s\n", theme.Dim.Render(fmt.Sprintf("... %d more occurrences (-md-max-occurrences)", total-detailMaxOccurrences)))
+	}
+}
+
+// renderCodeBlock renders an occurrence's lines as a highlighted markdown code block,
+// cut to detailMaxLines
+func renderCodeBlock(filename string, lines []string) {
+	block, hidden := codeBlock(filename, lines)
+	renderWithGlow(block)
+	if hidden > 0 {
+		fmt.Printf("  %s\n", theme.Dim.Render(fmt.Sprintf("... %d more lines (-md-max-lines)", hidden)))
+	}
+}
+
+// representativeSimilarities returns each occurrence's token similarity to the
+// representative, or nil when all copies are equally similar (nothing stands out)
+func representativeSimilarities(m PatternMatch) []float64 {
+	if len(m.Locations) < 3 || m.Representative >= len(m.Locations) {
+		return nil // with two copies each is the other's only reference
+	}
+	repLang := languageFor(m.Locations[m.Representative].Filename)
+	repTokens := similarityTokens(m.Locations[m.Representative].Pattern, &repLang)
+	sims := make([]float64, len(m.Locations))
+	varying := false
+	for i, loc := range m.Locations {
+		if i == m.Representative {
+			sims[i] = 1.0
+			continue
+		}
+		lang := languageFor(loc.Filename)
+		sims[i] = tokenSimilarity(repTokens, similarityTokens(loc.Pattern, &lang))
+		if i > 0 && sims[i] != sims[0] && (m.Representative != 0 || i > 1 && sims[i] != sims[1]) {
+			varying = true
+		}
+	}
+	if !varying {
+		return nil
+	}
+	return sims
+}
+
+// occurrenceSimilarityLabel renders an occurrence's similarity to the representative,
+// pointing out the outlier copy that diverged
+func occurrenceSimilarityLabel(sims []float64, i int, representative bool) string {
+	if sims == nil {
+		return ""
+	}
+	if representative {
+		return "  " + theme.Dim.Render("representative")
+	}
+	return "  " + renderSimilarity(sims[i]) + theme.Dim.Render(" to representative")
+}
+
+// printSubstitutions notes that a match is parametrizable and how occurrences differ
+func printSubstitutions(subs []Substitution) {
+	if len(subs) == 0 {
+		return
+	}
+	fmt.Printf("  %s %s\n", theme.Score.Render("Parametrizable:"), formatSubstitutions(subs))
+}
+
+// printKind notes a match's kind, e.g. that it is only a data definition
+func printKind(kind string) {
+	if kind != "" {
+		fmt.Printf("  %s\n", theme.Dim.Render("Kind: "+kind))
+	}
+}
+
+// printSteps lists the CI steps and Makefile targets a duplicated stanza consists of
+func printSteps(steps []string) {
+	if len(steps) > 0 {
+		fmt.Printf("  %s %s\n", theme.Score.Render("Steps:"), strings.Join(steps, ", "))
+	}
+}
+
+// printDirs lists the top-level directories a match spans (--min-dirs)
+func printDirs(dirs []string) {
+	if len(dirs) > 0 {
+		fmt.Printf("  %s %s\n", theme.Score.Render("Directories:"), strings.Join(dirs, ", "))
+	}
+}
+
+// renderWithGlow pipes markdown content through glow for rendering
+const glowOneDarkJSON = `{
+  "document": { "color": "#ABB2BF", "backgroundColor": "#282C34" },
+  "blockQuote": { "color": "#5C6370", "italic": true },
+  "heading": { "color": "#61AFEF", "bold": true },
+  "h1": { "color": "#61AFEF", "bold": true },
+  "h2": { "color": "#E5C07B", "bold": true },
+  "h3": { "color": "#98C379", "bold": true },
+  "strong": { "color": "#E5C07B", "bold": true },
+  "emph": { "color": "#E06C75", "italic": true },
+  "link": { "color": "#61AFEF", "underline": true },
+  "item": { "color": "#ABB2BF" },
+  "table": { "header": { "color": "#61AFEF", "bold": true }, "row": { "color": "#ABB2BF" }, "cellPadding": 1 },
+  "horizontalRule": { "format": "─" },
+  "chroma": { "style": "dracula" }
+}`
+
+const glamOneDark = `{
+  "document": {
+    "block_prefix": "",
+    "block_suffix": "",
+    "color": "#ABB2BF",
+    "margin": 2
+  },
+  "block_quote": {
+    "indent": 1,
+    "indent_token": "│ ",
+    "color": "#5C6370"
+  },
+  "paragraph": {},
+  "list": {
+    "color": "#ABB2BF",
+    "level_indent": 2
+  },
+  "heading": {
+    "block_suffix": "\n",
+    "color": "#C678DD",
+    "bold": true
+  },
+  "h1": { "prefix": "# ", "bold": true },
+  "h2": { "prefix": "## " },
+  "h3": { "prefix": "### " },
+  "text": {},
+  "strikethrough": { "crossed_out": true },
+  "emph": { "italic": true, "color": "#E06C75" },
+  "strong": { "bold": true, "color": "#E5C07B" },
+  "hr": { "color": "#5C6370", "format": "\n────────\n" },
+  "item": { "block_prefix": "• ", "color": "#98C379" },
+  "enumeration": { "block_prefix": ". ", "color": "#61AFEF" },
+  "link": { "color": "#61AFEF", "underline": true },
+  "link_text": { "color": "#56B6C2" },
+  "code": { "color": "#61AFEF" },
+  "code_block": {
+    "color": "#ABB2BF",
+    "margin": 2,
+    "chroma": {
+      "text": { "color": "#ABB2BF" },
+      "error": { "color": "#ABB2BF", "background_color": "#E06C75" },
+      "comment": { "color": "#5C6370" },
+      "comment_preproc": { "color": "#C678DD" },
+      "keyword": { "color": "#C678DD" },
+      "keyword_reserved": { "color": "#C678DD" },
+      "keyword_namespace": { "color": "#C678DD" },
+      "keyword_type": { "color": "#E5C07B" },
+      "operator": { "color": "#56B6C2" },
+      "punctuation": { "color": "#ABB2BF" },
+      "name": { "color": "#ABB2BF" },
+      "name_builtin": { "color": "#56B6C2" },
+      "name_tag": { "color": "#E06C75" },
+      "name_attribute": { "color": "#E5C07B" },
+      "name_class": { "color": "#E5C07B" },
+      "name_constant": { "color": "#D19A66" },
+      "name_decorator": { "color": "#E5C07B" },
+      "name_exception": {},
+      "name_function": { "color": "#61AFEF" },
+      "name_other": {},
+      "literal": {},
+      "literal_number": { "color": "#D19A66" },
+      "literal_date": {},
+      "literal_string": { "color": "#98C379" },
+      "literal_string_escape": { "color": "#56B6C2" },
+      "generic_deleted": { "color": "#E06C75" },
+      "generic_emph": { "italic": true },
+      "generic_inserted": { "color": "#98C379" },
+      "generic_strong": { "bold": true },
+      "generic_subheading": { "color": "#C678DD" }
+    }
+  },
+  "table": {},
+  "definition_list": {},
+  "definition_term": {},
+  "definition_description": { "block_prefix": "\n→ " }
+}`
+
+func renderWithGlow(markdown string) {
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStylesFromJSONBytes([]byte(glamOneDark)),
+		glamour.WithWordWrap(0),
+	)
+	if err != nil {
+		fmt.Print(markdown)
+		return
+	}
+	out, err := r.Render(markdown)
+	if err != nil {
+		fmt.Print(markdown)
+		return
+	}
+	fmt.Print(out)
+}
+
+// ReadJSONResults reads results from a JSON file
+func ReadJSONResults(path string) ([]JSONPattern, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var output JSONOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, err
+	}
+
+	return output.Patterns, nil
+}
+
+// PrintDetailedMatchesFromJSON prints detailed pattern matches from JSON results
+func PrintDetailedMatchesFromJSON(patterns []JSONPattern) {
+	// Group patterns by hash to detect multiple clusters
+	hashCounts := make(map[string]int)
+	for _, p := range patterns {
+		hashCounts[p.Hash]++
+	}
+
+	// Track cluster number per hash
+	hashClusterNum := make(map[string]int)
+
+	for i, p := range patterns {
+		// Determine if this hash has multiple clusters
+		clusterInfo := ""
+		if hashCounts[p.Hash] > 1 {
+			hashClusterNum[p.Hash]++
+			clusterInfo = fmt.Sprintf(" (Cluster %d/%d)", hashClusterNum[p.Hash], hashCounts[p.Hash])
+		}
+
+		// Print header with colorized similarity
+		fmt.Printf("\n%s%s  %s  %s  %s  %s  %s\n",
+			theme.Summary.Render(fmt.Sprintf("Pattern %d", i+1)),
+			theme.Dim.Render(clusterInfo),
+			theme.Hash.Render(fmt.Sprintf("[%s]", p.Hash)),
+			theme.Score.Render(fmt.Sprintf("Score %d", p.Score)),
+			renderSimilarity(p.Similarity),
+			theme.Dim.Render(fmt.Sprintf("%d lines", p.Lines)),
+			theme.Dim.Render(fmt.Sprintf("%d occurrences", p.Occurrences)))
+		printSubstitutions(p.Substitutions)
+		printKind(p.Kind)
+		printSteps(p.Steps)
+		printDirs(p.Dirs)
+		var repSims []float64
+		if len(p.Locations) > 0 && p.Locations[0].Similarity > 0 {
+			repSims = make([]float64, len(p.Locations))
+			for j, loc := range p.Locations {
+				repSims[j] = loc.Similarity
+			}
+		}
+
+		// Render each occurrence with styled header + code block
+		for j, loc := range shownOccurrences(p.Locations) {
+			fmt.Printf("\n  %s %s%s\n",
+				theme.LineNum.Render(fmt.Sprintf("Occurrence %d", j+1)),
+				theme.Location.Render(fmt.Sprintf("%s:%s", loc.Filename, jsonLocationLines(loc))),
+				occurrenceSimilarityLabel(repSims, j, loc.Canonical))
+
+			// Read source lines from file
+			renderCodeBlock(loc.Filename, readSourceLines(loc.Filename, loc.LineStart, p.Lines))
+		}
+		printHiddenOccurrences(len(p.Locations))
+		fmt.Println(theme.Dim.Render("───────────────────────────────────────────────────────────────────────────────"))
+	}
+}
+
+// readSourceLines reads specific lines from a file and normalizes indent
+func readSourceLines(filename string, startLine, count int) []string {
+	data, err := os.ReadFile(sourcePath(filename))
+	if err != nil {
+		return []string{fmt.Sprintf("// Error reading file: %v", err)}
+	}
+
+	allLines := strings.Split(string(data), "\n")
+	var result []string
+
+	// Find minimum indent for normalization
+	minIndent := -1
+	for i := startLine - 1; i < startLine-1+count && i < len(allLines); i++ {
+		if i < 0 {
+			continue
+		}
+		line := allLines[i]
+		indent := 0
+		for _, r := range line {
+			if r == ' ' {
+				indent++
+			} else if r == '\t' {
+				indent += 4
+			} else {
+				break
+			}
+		}
+		if len(strings.TrimSpace(line)) > 0 && (minIndent < 0 || indent < minIndent) {
+			minIndent = indent
+		}
+	}
+	if minIndent < 0 {
+		minIndent = 0
+	}
+
+	// Extract and normalize lines
+	for i := startLine - 1; i < startLine-1+count && i < len(allLines); i++ {
+		if i < 0 {
+			continue
+		}
+		line := allLines[i]
+		// Strip minimum indent
+		stripped := 0
+		start := 0
+		for j, r := range line {
+			if stripped >= minIndent {
+				start = j
+				break
+			}
+			if r == ' ' {
+				stripped++
+			} else if r == '\t' {
+				stripped += 4
+			} else {
+				start = j
+				break
+			}
+			start = j + 1
+		}
+		result = append(result, line[start:])
+	}
+
+	return result
+}
+
+// locationEndLine returns the source line number of the last entry at a location
+func locationEndLine(loc PatternLocation) int {
+	if loc.Repeats > 1 {
+		return loc.SpanEndLine
+	}
+	if len(loc.Pattern) == 0 {
+		return loc.LineStart
+	}
+	return loc.Pattern[len(loc.Pattern)-1].GetLineNumber()
+}
+
+// locationLines renders an occurrence's line for listings: "42", or "42-97 (3x)" for a
+// span of back-to-back copies
+func locationLines(loc PatternLocation) string {
+	if loc.Repeats > 1 {
+		return fmt.Sprintf("%d-%d (%dx)", loc.LineStart, loc.SpanEndLine, loc.Repeats)
+	}
+	return fmt.Sprint(loc.LineStart)
+}
+
+// jsonLocationLines is locationLines for locations read back from results.json
+func jsonLocationLines(loc JSONLocation) string {
+	if loc.Repeats > 1 {
+		return fmt.Sprintf("%d-%d (%dx)", loc.LineStart, loc.LineEnd, loc.Repeats)
+	}
+	return fmt.Sprint(loc.LineStart)
+}
+
+// patternLines returns the trimmed source lines of a pattern
+func patternLines(pattern []Entry) []string {
+	lines := make([]string, len(pattern))
+	for i, e := range pattern {
+		lines[i] = strings.TrimSpace(e.GetRaw())
+	}
+	return lines
+}
+
+// toJSONPattern converts a match to its JSON representation
+func toJSONPattern(m PatternMatch) JSONPattern {
+	repSims := representativeSimilarities(m)
+	locs := make([]JSONLocation, len(m.Locations))
+	for i, loc := range m.Locations {
+		locs[i] = JSONLocation{
+			Filename:  loc.Filename,
+			LineStart: loc.LineStart,
+			Canonical: i == m.Representative,
+		}
+		if repSims != nil {
+			locs[i].Similarity = repSims[i]
+		}
+		if loc.Repeats > 1 {
+			locs[i].LineEnd = loc.SpanEndLine
+			locs[i].Repeats = loc.Repeats
+		}
+	}
+
+	jp := JSONPattern{
+		Hash:        fmt.Sprintf("%016x", m.Hash),
+		Score:       m.Score,
+		Lines:       len(m.Pattern),
+		Similarity:  m.Similarity,
+		Occurrences: len(m.Locations),
+		Locations:   locs,
+		Pattern:     patternLines(m.Pattern),
+
+		Substitutions: m.Substitutions,
+		Kind:          m.Kind,
+		Tier:          m.Tier,
+		Steps:         m.Steps,
+		Dirs:          m.Dirs,
+	}
+	if !m.NewestChange.IsZero() {
+		jp.NewestChange = m.NewestChange.UTC().Format(time.RFC3339)
+	}
+	return jp
+}
+
+// ResultsExtras holds the optional sections written after the patterns
+type ResultsExtras struct {
+	Warnings       []Warning
+	Repeats        []RepeatedRun
+	Fuzzy          []FuzzyDuplicate
+	TokenClones    []TokenClone
+	SQLQueries     []SQLDuplicate
+	MagicNumbers   []MagicNumber
+	CommonImports  []CommonImport
+	Accessors      []AccessorClass
+	TestMatches    []PatternMatch
+	ScannedFiles   []ScannedFile
+	IdenticalFiles []IdenticalFiles
+	Histogram      []LengthBucket
+	FilePairs      []FilePairBlock
+	Timings        *Timings
+	Config         *JSONConfig
+	Interrupted    string // phase a cut-short scan stopped in; marks the results partial
+}
+
+// WriteJSONResults writes the results to a JSON file
+// Patterns are streamed one at a time to keep peak memory low on huge result sets
+func WriteJSONResults(matches []PatternMatch, extras ResultsExtras, outputPath string) error {
+	// Create output directory
+	outputDir := filepath.Dir(outputPath)
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return &ScanError{Op: "create output directory", Path: outputDir, Err: err}
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return &ScanError{Op: "write results", Path: outputPath, Err: err}
+	}
+	defer file.Close()
+
+	if err := writeJSONResults(file, matches, extras); err != nil {
+		return &ScanError{Op: "write results", Path: outputPath, Err: err}
+	}
+	return file.Close()
+}
+
+// writeJSONResults streams the results document to w
+func writeJSONResults(w io.Writer, matches []PatternMatch, extras ResultsExtras) error {
+	// Field order mirrors JSONOutput
+	out := newJSONStreamWriter(w)
+	out.Field("total_patterns", len(matches))
+	if extras.Interrupted != "" {
+		out.Field("partial", true)
+		out.Field("interrupted_phase", extras.Interrupted)
+	}
+	out.Field("hash_algorithm", hashAlgorithm)
+	if extras.Config != nil {
+		out.Field("config", extras.Config)
+	}
+	if extras.Timings != nil {
+		out.Field("timings", extras.Timings)
+	}
+	out.BeginArray("patterns")
+	for _, m := range matches {
+		out.Item(toJSONPattern(m))
+	}
+	out.EndArray()
+	if len(extras.Histogram) > 0 {
+		out.Field("length_histogram", extras.Histogram)
+	}
+	if len(extras.FilePairs) > 0 {
+		out.Field("file_pairs", extras.FilePairs)
+	}
+	if len(extras.Repeats) > 0 {
+		repeats := make([]JSONRepeat, len(extras.Repeats))
+		for i, r := range extras.Repeats {
+			repeats[i] = JSONRepeat{
+				Hash:       fmt.Sprintf("%016x", r.Hash),
+				Filename:   r.Filename,
+				LineStart:  r.LineStart,
+				LineEnd:    r.LineEnd,
+				BlockLines: r.BlockLines,
+				Repeats:    r.Repeats,
+			}
+		}
+		out.Field("repeats", repeats)
+	}
+	if len(extras.Fuzzy) > 0 {
+		out.Field("fuzzy_duplicates", extras.Fuzzy)
+	}
+	if len(extras.TokenClones) > 0 {
+		out.Field("token_clones", extras.TokenClones)
+	}
+	if len(extras.SQLQueries) > 0 {
+		queries := make([]JSONSQLQuery, len(extras.SQLQueries))
+		for i, q := range extras.SQLQueries {
+			queries[i] = JSONSQLQuery{
+				Hash:        fmt.Sprintf("%016x", q.Hash),
+				Query:       q.Query,
+				Occurrences: len(q.Locations),
+				Locations:   q.Locations,
+			}
+		}
+		out.Field("sql_queries", queries)
+	}
+	if len(extras.MagicNumbers) > 0 {
+		out.Field("magic_numbers", extras.MagicNumbers)
+	}
+	if len(extras.CommonImports) > 0 {
+		out.Field("common_imports", extras.CommonImports)
+	}
+	if len(extras.Accessors) > 0 {
+		out.Field("accessor_classes", extras.Accessors)
+	}
+	if len(extras.TestMatches) > 0 {
+		out.BeginArray("test_patterns")
+		for _, m := range extras.TestMatches {
+			out.Item(toJSONPattern(m))
+		}
+		out.EndArray()
+	}
+	if len(extras.ScannedFiles) > 0 {
+		out.Field("scanned_files", extras.ScannedFiles)
+	}
+	if len(extras.IdenticalFiles) > 0 {
+		out.Field("identical_files", extras.IdenticalFiles)
+	}
+	if len(extras.Warnings) > 0 {
+		out.Field("warnings", extras.Warnings)
+	}
+	return out.Close()
+}
+
+// maxRepeatsShown is how many repeated runs the console lists; results.json has all
+const maxRepeatsShown = 20
+
+// PrintRepeatedRuns prints blocks repeated back-to-back, one line per run
+func PrintRepeatedRuns(runs []RepeatedRun) {
+	if len(runs) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", theme.Summary.Render("Consecutive repeats:"))
+	for _, r := range runs[:min(len(runs), maxRepeatsShown)] {
+		loc := fmt.Sprintf("%s:%d-%d", r.Filename, r.LineStart, r.LineEnd)
+		fmt.Printf("  %s %s\n", theme.Location.Render(loc),
+			theme.Dim.Render(fmt.Sprintf("%d-line block repeated %d times [%016x]", r.BlockLines, r.Repeats, r.Hash)))
+	}
+	if len(runs) > maxRepeatsShown {
+		fmt.Printf("  %s\n", theme.Dim.Render(fmt.Sprintf("... %d more in results.json", len(runs)-maxRepeatsShown)))
+	}
+}
+
+// maxFuzzyShown is how many fuzzy duplicates the console lists; results.json has all
+const maxFuzzyShown = 20
+
+// PrintFuzzyDuplicates prints region pairs with reordered or edited statements
+func PrintFuzzyDuplicates(duplicates []FuzzyDuplicate) {
+	if len(duplicates) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", theme.Summary.Render(fmt.Sprintf("Fuzzy duplicates (reordered or edited, %d):", len(duplicates))))
+	for _, d := range duplicates[:min(len(duplicates), maxFuzzyShown)] {
+		a, b := d.Regions[0], d.Regions[1]
+		fmt.Printf("  %s %s %s\n",
+			theme.Score.Render(fmt.Sprintf("%3.0f%%", d.Overlap*100)),
+			theme.Location.Render(fmt.Sprintf("%s:%d-%d", a.Filename, a.LineStart, a.LineEnd)),
+			theme.Location.Render(fmt.Sprintf("%s:%d-%d", b.Filename, b.LineStart, b.LineEnd)))
+	}
+	if len(duplicates) > maxFuzzyShown {
+		fmt.Printf("  %s\n", theme.Dim.Render(fmt.Sprintf("... %d more in results.json", len(duplicates)-maxFuzzyShown)))
+	}
+}
+
+// PrintTokenClones prints the longest token runs found regardless of line breaks,
+// with their first two occurrences
+func PrintTokenClones(clones []TokenClone) {
+	if len(clones) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", theme.Summary.Render(fmt.Sprintf("Token clones (across line breaks, %d):", len(clones))))
+	for _, c := range clones[:min(len(clones), maxFuzzyShown)] {
+		a, b := c.Spans[0], c.Spans[1]
+		more := ""
+		if len(c.Spans) > 2 {
+			more = theme.Dim.Render(fmt.Sprintf(" (+%d more)", len(c.Spans)-2))
+		}
+		fmt.Printf("  %s %s %s%s\n",
+			theme.Score.Render(fmt.Sprintf("%4d tokens", c.Tokens)),
+			theme.Location.Render(fmt.Sprintf("%s:%d:%d-%d:%d", a.Filename, a.LineStart, a.Column, a.LineEnd, a.EndColumn)),
+			theme.Location.Render(fmt.Sprintf("%s:%d:%d-%d:%d", b.Filename, b.LineStart, b.Column, b.LineEnd, b.EndColumn)),
+			more)
+	}
+	if len(clones) > maxFuzzyShown {
+		fmt.Printf("  %s\n", theme.Dim.Render(fmt.Sprintf("... %d more in results.json", len(clones)-maxFuzzyShown)))
+	}
+}
+
+// scannedFiles lists every parsed file with its line count, sorted by path
+func scannedFiles(fileData map[string][]Entry) []ScannedFile {
+	files := make([]ScannedFile, 0, len(fileData))
+	for filename, entries := range fileData {
+		files = append(files, ScannedFile{Filename: filename, Lines: len(entries)})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Filename < files[j].Filename
+	})
+	return files
+}
+
+// PrintDuplicateQueries prints SQL queries found in several string literals
+func PrintDuplicateQueries(queries []SQLDuplicate) {
+	if len(queries) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", theme.Summary.Render("Duplicated SQL queries:"))
+	for _, q := range queries {
+		fmt.Printf("\n%s %s\n", theme.Hash.Render(fmt.Sprintf("[%016x]", q.Hash)),
+			theme.Score.Render(fmt.Sprintf("%d occurrences", len(q.Locations))))
+		fmt.Printf("  %s\n", truncate(q.Query, 120))
+		for _, loc := range q.Locations {
+			fmt.Printf("  %s\n", theme.Location.Render(fmt.Sprintf("%s:%d", loc.Filename, loc.Line)))
+		}
+	}
+}
+
+// PrintMagicNumbers lists numeric literals repeated across files, with their first
+// locations
+func PrintMagicNumbers(numbers []MagicNumber) {
+	if len(numbers) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", theme.Summary.Render("Magic numbers (named constant candidates):"))
+	for _, n := range numbers {
+		var locs []string
+		for _, loc := range n.Locations[:min(len(n.Locations), 3)] {
+			locs = append(locs, theme.Location.Render(fmt.Sprintf("%s:%d", loc.Filename, loc.Line)))
+		}
+		more := ""
+		if len(n.Locations) > 3 {
+			more = theme.Dim.Render(fmt.Sprintf(" +%d more", len(n.Locations)-3))
+		}
+		fmt.Printf("  %s %s %s%s\n",
+			theme.Score.Render(fmt.Sprintf("%10s", n.Value)),
+			theme.Dim.Render(fmt.Sprintf("%3dx in %d files", len(n.Locations), n.Files)),
+			strings.Join(locs, " "), more)
+	}
+}
+
+// PrintCommonImports lists import lines shared by many files of a package
+func PrintCommonImports(imports []CommonImport) {
+	if len(imports) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", theme.Summary.Render("Common imports (shared prelude candidates):"))
+	for _, imp := range imports {
+		fmt.Printf("  %s %s %s\n",
+			theme.LineNum.Render(fmt.Sprintf("%4d/%-4d", imp.Files, imp.TotalFiles)),
+			theme.Location.Render(imp.Dir+string(filepath.Separator)),
+			imp.Import)
+	}
+}
+
+// PrintAccessorClasses lists classes made up of boilerplate getters and setters
+func PrintAccessorClasses(classes []AccessorClass) {
+	if len(classes) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", theme.Summary.Render("Accessor boilerplate (consider records, Lombok or auto-properties):"))
+	for _, c := range classes {
+		fmt.Printf("  %s %s %s\n",
+			theme.Location.Render(fmt.Sprintf("%s:%d", c.Filename, c.Line)),
+			c.Class,
+			theme.Dim.Render(fmt.Sprintf("%d getters, %d setters, %d get/set pairs", c.Getters, c.Setters, c.Pairs)))
+	}
+}
+
+// testKindAdvice names the refactoring each kind of duplicated test code calls for
+var testKindAdvice = map[string]string{
+	KindTestAssertions: "assertions → custom assertion helper",
+	KindTestSetup:      "setup → fixture, builder or helper",
+	KindDataDefinition: "test data → shared fixture data",
+}
+
+// PrintTestDuplicates prints the top duplication among test files (--tests separate),
+// one line per match with the refactoring its kind suggests
+func PrintTestDuplicates(matches []PatternMatch, total, top int) {
+	if total == 0 {
+		return
+	}
+	fmt.Printf("\n%s %s\n", theme.Summary.Render("Duplicated test code:"),
+		theme.Dim.Render(fmt.Sprintf("(%d patterns, showing top %d)", total, min(top, total))))
+	for _, m := range matches {
+		loc := m.Locations[m.Representative]
+		fmt.Printf("  %s %s %s%s%s %s\n",
+			theme.Score.Render(fmt.Sprintf("%5d", m.Score)),
+			theme.Dim.Render(fmt.Sprintf("%4d lines %3d×", len(m.Pattern), len(m.Locations))),
+			theme.Location.Render(loc.Filename),
+			theme.Dim.Render(":"),
+			theme.LineNum.Render(fmt.Sprintf("%d", loc.LineStart)),
+			theme.Dim.Render(testKindAdvice[m.Kind]))
+	}
+}
+
+// PrintExecResults prints the output of each --exec command, flagging failures
+func PrintExecResults(results []ExecResult) {
+	if len(results) == 0 {
+		return
+	}
+	failed := 0
+	fmt.Printf("\n%s\n", theme.Summary.Render("Exec:"))
+	for _, r := range results {
+		status := theme.Dim.Render("ok")
+		if r.Err != nil {
+			failed++
+			status = theme.Score.Render(r.Err.Error())
+		}
+		fmt.Printf("  %s %s %s\n", theme.Hash.Render(fmt.Sprintf("[%016x]", r.Hash)), strings.Join(r.Args, " "), status)
+		for _, line := range strings.Split(strings.TrimRight(string(r.Output), "\n"), "\n") {
+			if line != "" {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+	fmt.Printf("  %d commands run, %d failed\n", len(results), failed)
+}
+
+// PrintSeedMatches lists the copies --find found of a snippet
+func PrintSeedMatches(seed SeedSpec, lines int, found []SeedMatch) {
+	fmt.Printf("\n%s\n", theme.Summary.Render(fmt.Sprintf("Copies of %s:%d-%d (%d lines): %d", seed.File, seed.Start, seed.End, lines, len(found))))
+	for _, m := range found {
+		fmt.Printf("  %s %s\n",
+			renderSimilarity(m.Similarity),
+			theme.Location.Render(fmt.Sprintf("%s:%d-%d", m.Filename, m.LineStart, m.LineEnd)))
+	}
+}
+
+// PrintSeedGrep writes --find results as "file:start:end: message" lines
+func PrintSeedGrep(w io.Writer, found []SeedMatch, lines int) {
+	for _, m := range found {
+		fmt.Fprintf(w, "%s:%d:%d: copy of the --find snippet (%d lines, %.0f%% similar)\n",
+			m.Filename, m.LineStart, m.LineEnd, lines, m.Similarity*100)
+	}
+}
+
+// PrintRepoResultsDir prints where the results of a --repo scan were kept
+func PrintRepoResultsDir(dir string) {
+	fmt.Printf("Repository results kept in: %s\n", dir)
+}
+
+// PrintGateFailure prints why the run fails the CI gate (--fail-on-score, --fail-on-count)
+func PrintGateFailure(reason string) {
+	fmt.Fprintf(os.Stderr, "\nFailed: %s\n", reason)
+}
+
+// PrintWarnings prints non-fatal issues collected during the scan
+func PrintWarnings(warnings []Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", theme.Score.Render(fmt.Sprintf("Warnings (%d):", len(warnings))))
+	for _, w := range warnings {
+		if w.Path != "" {
+			fmt.Printf("  %s %s: %s\n", theme.Dim.Render("["+w.Kind+"]"), theme.Location.Render(w.Path), w.Message)
+		} else {
+			fmt.Printf("  %s %s\n", theme.Dim.Render("["+w.Kind+"]"), w.Message)
+		}
+	}
+}
+
+// PrintServing announces quickdup serve's endpoint
+func PrintServing(listen, root string) {
+	fmt.Printf("Serving scans of %s on %s\n", theme.Location.Render(root), theme.Location.Render("http://"+listen+"/scan"))
+	fmt.Printf("%s\n", theme.Dim.Render("  ?path=DIR scans a directory, ?ref=REF a git ref of the repository"))
+}
+
+// PrintWorklistPath prints the path to the refactoring worklist
+func PrintWorklistPath(path string) {
+	fmt.Printf("Worklist written to: %s\n", theme.Location.Render(path))
+}
+
+// PrintBadgePath prints the path to the duplication badge and its percentage
+func PrintBadgePath(path string, percent float64) {
+	fmt.Printf("Badge written to: %s %s\n", theme.Location.Render(path), theme.Dim.Render(fmt.Sprintf("(%.1f%% duplicated)", percent)))
+}
+
+// PrintMarkdownPath prints the path to the markdown report
+func PrintMarkdownPath(path string) {
+	fmt.Printf("Markdown report written to: %s\n", theme.Location.Render(path))
+}
+
+// PrintSARIFPath prints the path to the SARIF log
+func PrintSARIFPath(path string) {
+	fmt.Printf("SARIF written to: %s\n", theme.Location.Render(path))
+}
+
+// PrintSimilarityGraphPath prints the path to the similarity graph
+func PrintSimilarityGraphPath(path string) {
+	fmt.Printf("Similarity graph written to: %s\n", theme.Location.Render(path))
+}
+
+// PrintResultsPath prints the path to the results file
+func PrintResultsPath(outputPath string) {
+	fmt.Printf("Results written to: %s\n", theme.Location.Render(outputPath))
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FilePairBlock is the longest block two files share: the occurrences in each of the
+// longest match found in both
+type FilePairBlock struct {
+	Hash    string         `json:"hash"`
+	Lines   int            `json:"lines"` // pattern lines
+	Regions [2]FuzzyRegion `json:"regions"`
+}
+
+// longestPerFilePair returns, for each pair of files sharing a match, the longest one
+// with its first occurrence in each file, longest first. Ties keep the earlier (higher
+// ranked) match.
+func longestPerFilePair(matches []PatternMatch) []FilePairBlock {
+	type filePair struct{ a, b string }
+	longest := make(map[filePair]FilePairBlock)
+	for _, m := range matches {
+		// First occurrence per file
+		var files []string
+		first := make(map[string]PatternLocation)
+		for _, loc := range m.Locations {
+			if _, ok := first[loc.Filename]; !ok {
+				first[loc.Filename] = loc
+				files = append(files, loc.Filename)
+			}
+		}
+		sort.Strings(files)
+		for i, a := range files {
+			for _, b := range files[i+1:] {
+				key := filePair{a, b}
+				if block, ok := longest[key]; ok && block.Lines >= len(m.Pattern) {
+					continue
+				}
+				la, lb := first[a], first[b]
+				longest[key] = FilePairBlock{
+					Hash:  fmt.Sprintf("%016x", m.Hash),
+					Lines: len(m.Pattern),
+					Regions: [2]FuzzyRegion{
+						{a, la.LineStart, locationEndLine(la)},
+						{b, lb.LineStart, locationEndLine(lb)},
+					},
+				}
+			}
+		}
+	}
+
+	blocks := make([]FilePairBlock, 0, len(longest))
+	for _, block := range longest {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		if blocks[i].Lines != blocks[j].Lines {
+			return blocks[i].Lines > blocks[j].Lines
+		}
+		a, b := blocks[i].Regions, blocks[j].Regions
+		if a[0].Filename != b[0].Filename {
+			return a[0].Filename < b[0].Filename
+		}
+		return a[1].Filename < b[1].Filename
+	})
+	return blocks
+}
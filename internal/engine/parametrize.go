@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxSubstitutions bounds the distinct renamings for a parametrizable duplicate;
+// beyond that two blocks merely share a shape
+const maxSubstitutions = 3
+
+// Substitution is an identifier consistently renamed between occurrences (User -> Order)
+type Substitution struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (s Substitution) String() string {
+	return s.From + " → " + s.To
+}
+
+// consistentRenaming reports whether token sequence b is a with a few identifiers
+// renamed 1:1 throughout, returning the renamings. Renamings inside compound names
+// (GetUser -> GetOrder, users -> orders) count as the same substitution as the bare
+// identifier, compared case-insensitively.
+func consistentRenaming(a, b []string) ([]Substitution, bool) {
+	if len(a) != len(b) {
+		return nil, false
+	}
+	first := 0
+	for first < len(a) && a[first] == b[first] {
+		first++
+	}
+	if first == len(a) {
+		return nil, false // identical
+	}
+
+	forward := make(map[string]string)  // core of a -> core of b (lowercase)
+	backward := make(map[string]string) // core of b -> core of a
+	shortest := make(map[string]Substitution)
+	var order []string
+
+	for i := first; i < len(a); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		coreA, coreB := renamedCore(a[i], b[i])
+		if trimSymbols(coreA) == "" || trimSymbols(coreB) == "" {
+			return nil, false // insertion or changed operator, not a renaming
+		}
+		keyA, keyB := strings.ToLower(coreA), strings.ToLower(coreB)
+		if to, ok := forward[keyA]; ok && to != keyB {
+			return nil, false
+		}
+		if from, ok := backward[keyB]; ok && from != keyA {
+			return nil, false
+		}
+		if _, ok := forward[keyA]; !ok {
+			order = append(order, keyA)
+			if len(order) > maxSubstitutions {
+				return nil, false
+			}
+		}
+		forward[keyA], backward[keyB] = keyB, keyA
+
+		// Report the shortest full identifiers, e.g. User -> Order rather than &GetUserById
+		from, to := trimSymbols(a[i]), trimSymbols(b[i])
+		if s, ok := shortest[keyA]; !ok || len(from) < len(s.From) {
+			shortest[keyA] = Substitution{From: from, To: to}
+		}
+	}
+	// A renamed identifier must not also appear unrenamed
+	for i := range a {
+		if a[i] == b[i] {
+			if _, ok := forward[strings.ToLower(a[i])]; ok {
+				return nil, false
+			}
+		}
+	}
+
+	subs := make([]Substitution, len(order))
+	for i, key := range order {
+		subs[i] = shortest[key]
+	}
+	return subs, true
+}
+
+// renamedCore strips the common prefix and suffix of two tokens, leaving the renamed part
+func renamedCore(a, b string) (string, string) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	return a[prefix : len(a)-suffix], b[prefix : len(b)-suffix]
+}
+
+// trimSymbols strips operator characters (&, *, -, ...) tokenizing leaves around identifiers
+func trimSymbols(token string) string {
+	return strings.TrimFunc(token, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+// mergeSubstitutions adds subs to a cluster's set, skipping duplicates
+func mergeSubstitutions(set []Substitution, subs []Substitution) []Substitution {
+	for _, s := range subs {
+		found := false
+		for _, existing := range set {
+			if existing == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			set = append(set, s)
+		}
+	}
+	return set
+}
+
+// sortSubstitutions orders substitutions by From, then To, for stable output
+func sortSubstitutions(subs []Substitution) {
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].From != subs[j].From {
+			return subs[i].From < subs[j].From
+		}
+		return subs[i].To < subs[j].To
+	})
+}
+
+// formatSubstitutions renders substitutions for display: "User → Order, Users → Orders"
+func formatSubstitutions(subs []Substitution) string {
+	parts := make([]string, len(subs))
+	for i, s := range subs {
+		parts[i] = s.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,333 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Separators for word extraction
+const separators = " \t:.;{}()[]#!<>=,\n\r"
+
+// skipFirstWords defines first-word tokens to skip by file extension
+var skipFirstWords = map[string]map[string]bool{
+	".cs": {
+		"using":  true,
+		"#":      true, // #region, #endregion, #pragma, etc.
+	},
+	".go": {
+		"import": true,
+		"package": true,
+	},
+	".java": {
+		"import":  true,
+		"package": true,
+	},
+	".ts": {
+		"import": true,
+		"export": true,
+	},
+	".tsx": {
+		"import": true,
+		"export": true,
+	},
+	".js": {
+		"import": true,
+		"export": true,
+	},
+	".jsx": {
+		"import": true,
+		"export": true,
+	},
+	".py": {
+		"import": true,
+		"from":   true,
+	},
+	".rs": {
+		"use": true,
+		"mod": true,
+	},
+	".kt": {
+		"import":  true,
+		"package": true,
+	},
+	".scala": {
+		"import":  true,
+		"package": true,
+	},
+}
+
+// caseInsensitive lowercases first words and similarity tokens (set from --case-insensitive)
+// for languages like SQL and Pascal where keyword case doesn't matter
+var caseInsensitive bool
+
+// ignoreModifiers skips leading access modifiers when taking a line's first word (set
+// from --ignore-modifiers), so methods differing only in visibility match
+var ignoreModifiers bool
+
+// maxLineLength skips lines longer than this many bytes, 0 for no limit (set from
+// --max-line-length); such lines are minified or generated, never hand-written structure
+var maxLineLength int
+
+// tabWidth is how many spaces of indentation a tab counts as (set from --tab-width)
+var tabWidth = 4
+
+// maxFileLines skips files with more lines than this, 0 for no limit (set from --max-file-lines)
+var maxFileLines int
+
+// fileType returns a file's lowercased extension, or "makefile" for Makefiles, which
+// have none
+func fileType(path string) string {
+	switch base := filepath.Base(path); base {
+	case "Makefile", "makefile", "GNUmakefile":
+		return "makefile"
+	}
+	return strings.ToLower(filepath.Ext(path))
+}
+
+// parseFile parses a file into entries, also returning the lines suppressed by
+// quickdup:ignore markers
+func parseFile(path string) ([]Entry, []int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseContent(path, data)
+}
+
+// parseContent parses the content of a file like parseFile; path picks the language
+func parseContent(path string, data []byte) ([]Entry, []int, error) {
+	if isBinary(data) {
+		return nil, nil, errBinaryFile
+	}
+	if entries, suppressed, ok := memoryCache.Get(path, data); ok {
+		return entries, suppressed, nil
+	}
+
+	// Resolved per file: workers parse files of different extensions concurrently
+	lang := languageFor(path)
+
+	content := activeStrategy.Preparse(string(data), &lang)
+	if debugEnabled {
+		if err := checkPreparse(activeStrategy.Name(), string(data), content); err != nil {
+			return nil, nil, err
+		}
+	}
+	lines := strings.Split(content, "\n")
+	if maxFileLines > 0 && len(lines) > maxFileLines {
+		return nil, nil, errTooManyLines
+	}
+	if isMinified(lines, len(content)) {
+		return nil, nil, errMinifiedFile
+	}
+
+	var entries []Entry
+	var prevEntry Entry
+	var grouper annotationGrouper
+
+	for lineNumber, line := range lines {
+		lineNumber++ // 1-based line numbers
+
+		if groupAnnotations {
+			if grouper.add(lineNumber, line, &lang) {
+				continue
+			}
+			if !isWhitespaceOnly(line) && !isCommentOnly(line, &lang) {
+				lineNumber, line = grouper.attach(lineNumber, line)
+			}
+		}
+
+		if maxLineLength > 0 && len(line) > maxLineLength {
+			continue
+		}
+
+		entry, skip := activeStrategy.ParseLine(lineNumber, line, prevEntry, &lang)
+		if skip {
+			continue
+		}
+
+		prevEntry = entry
+		entries = append(entries, entry)
+	}
+
+	// Markers are found in the raw source: preparsing blanks out block comments
+	var suppressed []int
+	if strings.Contains(string(data), ignoreMarker) {
+		suppressed = suppressedLines(strings.Split(string(data), "\n"), entries, &lang)
+	}
+
+	memoryCache.Put(path, data, entries, suppressed)
+	return entries, suppressed, nil
+}
+
+// checkPreparse verifies a preparser kept every line: entries take their line numbers
+// from the preparsed content, so a dropped or added newline shifts every reported
+// location after it. Checked under --debug.
+func checkPreparse(strategy, original, preparsed string) error {
+	if want, got := strings.Count(original, "\n"), strings.Count(preparsed, "\n"); got != want {
+		return fmt.Errorf("%s preparse changed the line count from %d to %d", strategy, want+1, got+1)
+	}
+	return nil
+}
+
+// errBinaryFile is returned by parseFile for content that isn't text
+var errBinaryFile = errors.New("binary file skipped")
+
+// errMinifiedFile is returned by parseFile for files made up mostly of over-long lines
+var errMinifiedFile = errors.New("minified file skipped")
+
+// errTooManyLines is returned by parseFile for files over --max-file-lines
+var errTooManyLines = errors.New("file too long, skipped")
+
+// isMinified reports whether most of the content sits on lines over maxLineLength
+func isMinified(lines []string, size int) bool {
+	if maxLineLength <= 0 {
+		return false
+	}
+	long := 0
+	for _, line := range lines {
+		if len(line) > maxLineLength {
+			long += len(line)
+		}
+	}
+	return long*2 > size
+}
+
+// binarySniffLen is how much of a file is checked for NUL bytes (the same heuristic git uses)
+const binarySniffLen = 8000
+
+// isBinary reports whether data looks like binary content rather than source text
+func isBinary(data []byte) bool {
+	if len(data) > binarySniffLen {
+		data = data[:binarySniffLen]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// parseOptionsKey describes the global options that change how files are parsed,
+// so cached entries produced under different options aren't reused
+func parseOptionsKey() string {
+	var opts []string
+	if groupAnnotations {
+		opts = append(opts, "group-annotations")
+	}
+	if caseInsensitive {
+		opts = append(opts, "case-insensitive")
+	}
+	if ignoreModifiers {
+		opts = append(opts, "ignore-modifiers")
+	}
+	if maxLineLength > 0 {
+		opts = append(opts, fmt.Sprintf("max-line-length=%d", maxLineLength))
+	}
+	if maxFileLines > 0 {
+		opts = append(opts, fmt.Sprintf("max-file-lines=%d", maxFileLines))
+	}
+	if tabWidth != 4 {
+		opts = append(opts, fmt.Sprintf("tab-width=%d", tabWidth))
+	}
+	if len(extAliases) > 0 {
+		opts = append(opts, "ext-alias="+extAliasesKey())
+	}
+	if commentOverride != "" {
+		opts = append(opts, "comment="+commentOverride)
+	}
+	if len(registeredLanguages) > 0 {
+		opts = append(opts, "languages="+languagesKey())
+	}
+	return strings.Join(opts, ",")
+}
+
+func isWhitespaceOnly(line string) bool {
+	for _, r := range line {
+		if r != ' ' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// isCommentOnly reports whether a line holds only a line comment of the file's language
+func isCommentOnly(line string, lang *Language) bool {
+	if lang.CommentPrefix == "" {
+		return false
+	}
+	trimmed := strings.TrimLeft(line, " \t")
+	return strings.HasPrefix(trimmed, lang.CommentPrefix)
+}
+
+// shouldSkipByFirstWord checks if the line should be skipped based on its first word
+// and the skip words of the file's language
+func shouldSkipByFirstWord(line string, lang *Language) bool {
+	if lang.SkipWords == nil {
+		return false
+	}
+
+	word := extractFirstWord(line)
+	return lang.SkipWords[word]
+}
+
+func calculateIndent(line string) int {
+	indent := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			indent++
+		case '\t':
+			indent += tabWidth
+		default:
+			return indent
+		}
+	}
+	return indent
+}
+
+// extractFirstWord returns the word a line is matched by, skipping leading access
+// modifiers under --ignore-modifiers
+func extractFirstWord(line string) string {
+	return firstWord(line, ignoreModifiers)
+}
+
+// firstWord returns a line's first word. With stripModifiers, leading access
+// modifiers (public, private, ...) are skipped so the word after them is returned.
+func firstWord(line string, stripModifiers bool) string {
+	// Skip leading whitespace
+	start := 0
+	for i, r := range line {
+		if r != ' ' && r != '\t' {
+			start = i
+			break
+		}
+	}
+
+	// Find end of word (first separator)
+	trimmed := line[start:]
+	end := len(trimmed)
+	for i, r := range trimmed {
+		if strings.ContainsRune(separators, r) {
+			end = i
+			break
+		}
+	}
+
+	// If no word found (line starts with separator), use the first character
+	if end == 0 && len(trimmed) > 0 {
+		return string(trimmed[0])
+	}
+
+	// `public void` and `private void` start the same method; `public:` labels stay
+	if stripModifiers && end < len(trimmed) && (trimmed[end] == ' ' || trimmed[end] == '\t') && accessModifiers[strings.ToLower(trimmed[:end])] {
+		if rest := strings.TrimLeft(trimmed[end:], " \t"); rest != "" {
+			return firstWord(rest, true)
+		}
+	}
+
+	if caseInsensitive {
+		return strings.ToLower(trimmed[:end])
+	}
+	return trimmed[:end]
+}
+
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPreparseKeepsLineCount(t *testing.T) {
+	inputs := []struct {
+		name, path, content string
+	}{
+		{"block comment over lines", "a.go", "x := 1 /* start\nmiddle\nend */ y := 2\n"},
+		{"comment markers in strings", "a.go", "s := \"/* not a comment\"\nt := `multi\nline */ raw`\n"},
+		{"unterminated block comment", "a.go", "a()\n/* never closed\nb()\n"},
+		{"CRLF line endings", "a.cs", "class A\r\n{\r\n    /* c */\r\n}\r\n"},
+		{"docstring", "a.py", "def f():\n    \"\"\"Doc\n    string\"\"\"\n    return 1\n"},
+		{"CI config", ".github/workflows/ci.yml", "jobs:\n  build:\n    steps:\n      - run: make\n"},
+		{"no trailing newline", "a.go", "a()\nb()"},
+		{"empty", "a.go", ""},
+	}
+	for name, strategy := range newStrategies() {
+		for _, in := range inputs {
+			t.Run(name+"/"+in.name, func(t *testing.T) {
+				lang := languageFor(in.path)
+				got := strategy.Preparse(in.content, &lang)
+				if err := checkPreparse(name, in.content, got); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+func TestCheckPreparseRejectsChangedLineCount(t *testing.T) {
+	original := "a\nb\nc\n"
+	for _, preparsed := range []string{"a\nc\n", "a\nb\n\nc\n", strings.ReplaceAll(original, "\n", " ")} {
+		if err := checkPreparse("test", original, preparsed); err == nil {
+			t.Errorf("checkPreparse accepted %q for %q", preparsed, original)
+		}
+	}
+}
+
+func TestParseMixedExtensionsConcurrently(t *testing.T) {
+	useStrategy(t, "normalized-indent")
+	// Each line's first word is a skip word in some of the languages only
+	content := "package main\nimport os\nfrom x import y\nusing System\nvalue = 1\n"
+	kept := map[string][]string{
+		".go": {"from", "using", "value"},
+		".py": {"package", "using", "value"},
+		".cs": {"package", "import", "from", "value"},
+	}
+	files := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		for ext := range kept {
+			files[fmt.Sprintf("f%02d%s", i, ext)] = content
+		}
+	}
+	_, paths := writeFiles(t, files)
+
+	// Several scans at once, so files of different languages are parsed concurrently
+	// however many CPUs there are
+	const scans = 4
+	results := make([]map[string][]Entry, scans)
+	var wg sync.WaitGroup
+	for s := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[s], _, _ = parseFilesWithCache(paths, nil, nil, &Warnings{}, &Suppressions{})
+		}()
+	}
+	wg.Wait()
+
+	for _, fileData := range results {
+		if len(fileData) != len(paths) {
+			t.Fatalf("parsed %d of %d files", len(fileData), len(paths))
+		}
+		for path, entries := range fileData {
+			var words []string
+			for _, e := range entries {
+				words = append(words, firstWord(e.GetRaw(), false))
+			}
+			if want := kept[filepath.Ext(path)]; !slices.Equal(words, want) {
+				t.Errorf("%s: kept lines starting %v, want %v", filepath.Base(path), words, want)
+			}
+		}
+	}
+}
+
+func TestCommentPrefixPerFile(t *testing.T) {
+	useStrategy(t, "normalized-indent")
+	// The same lines in both files: only each language's own comments are skipped
+	content := "// slash comment\n# hash comment\nvalue = 1\n"
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"a.go", []string{"# hash comment", "value = 1"}},
+		{"b.py", []string{"// slash comment", "value = 1"}},
+	}
+	files := make(map[string]string)
+	for _, tt := range tests {
+		files[tt.name] = content
+	}
+	dir, paths := writeFiles(t, files)
+	fileData, _, _ := parseFilesWithCache(paths, nil, nil, &Warnings{}, &Suppressions{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lines []string
+			for _, e := range fileData[filepath.Join(dir, tt.name)] {
+				lines = append(lines, e.GetRaw())
+			}
+			if !slices.Equal(lines, tt.want) {
+				t.Errorf("kept %q, want %q", lines, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package engine
+
+import "strings"
+
+// Pattern kinds reported in results.json ("" for ordinary code)
+const (
+	KindDataDefinition = "data-definition" // struct/enum/DTO fields, often acceptable duplication
+	KindTestAssertions = "test-assertions" // repeated assertions in tests (--tests separate)
+	KindTestSetup      = "test-setup"      // repeated fixture or setup code in tests (--tests separate)
+)
+
+// controlFlowWords mark a line as logic rather than a field or enum member
+var controlFlowWords = map[string]bool{
+	"if": true, "else": true, "elif": true, "for": true, "foreach": true, "while": true,
+	"do": true, "switch": true, "case": true, "match": true, "when": true, "return": true,
+	"break": true, "continue": true, "goto": true, "try": true, "catch": true, "except": true,
+	"finally": true, "throw": true, "raise": true, "yield": true, "await": true, "defer": true,
+	"go": true, "select": true, "loop": true, "unless": true, "func": true, "def": true, "fn": true,
+}
+
+// statementOperators only appear in statements; a plain = may be a field initializer or enum value
+var statementOperators = []string{":=", "+=", "-=", "*=", "/=", "|=", "&=", "++", "--", "<-"}
+
+// dataLineMaxTokens bounds the tokens on a field line (name, type, modifiers, tags)
+const dataLineMaxTokens = 8
+
+// classifyPattern tags patterns whose lines are dominated by declarations like
+// `Name string`, `name: Type`, `public int Id { get; set; }` or enum members, with no
+// control flow or calls. lang tells attribute lines from array literals.
+func classifyPattern(pattern []Entry, lang *Language) string {
+	data, body := 0, 0
+	for _, e := range pattern {
+		raw := trimTrailingPunctuation(strings.TrimSpace(e.GetRaw()))
+		if raw == "" || strings.Trim(raw, "{}()[]") == "" {
+			continue // braces alone say nothing either way
+		}
+		body++
+		if isDataLine(raw, lang) {
+			data++
+		}
+	}
+	if body > 0 && data*5 >= body*4 {
+		return KindDataDefinition
+	}
+	return ""
+}
+
+// isDataLine reports whether a line reads like a field, property or enum member
+func isDataLine(line string, lang *Language) bool {
+	// Property accessors are the only parentheses/braces-with-content allowed
+	line = strings.NewReplacer("{ get; set; }", "", "{ get; }", "", "{get;set;}", "").Replace(line)
+	if strings.Contains(line, "(") && !isAnnotationLine(line, lang) {
+		return false // call, method or constructor
+	}
+	for _, op := range statementOperators {
+		if strings.Contains(line, op) {
+			return false
+		}
+	}
+	tokens := tokenizeLine(line)
+	if len(tokens) == 0 || len(tokens) > dataLineMaxTokens {
+		return false
+	}
+	for _, t := range tokens {
+		if controlFlowWords[strings.ToLower(t)] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"encoding/csv"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FileProfile records per-file parse and base-pattern timings for --profile-output,
+// to pinpoint the file dragging down a scan. A nil profile is a no-op; safe for
+// concurrent use by the worker pools.
+type FileProfile struct {
+	mu    sync.Mutex
+	files map[string]*fileTiming
+}
+
+type fileTiming struct {
+	parse   time.Duration
+	entries int
+	cached  bool
+	base    time.Duration // base pattern generation
+	windows int
+}
+
+// profile is the active per-file profile (set from --profile-output, nil when disabled)
+var profile *FileProfile
+
+func (p *FileProfile) timing(path string) *fileTiming {
+	if p.files == nil {
+		p.files = make(map[string]*fileTiming)
+	}
+	t := p.files[path]
+	if t == nil {
+		t = &fileTiming{}
+		p.files[path] = t
+	}
+	return t
+}
+
+// Parsed records how long a file took to parse (or load from cache) and its entry count
+func (p *FileProfile) Parsed(path string, duration time.Duration, entries int, cached bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t := p.timing(path)
+	t.parse, t.entries, t.cached = duration, entries, cached
+}
+
+// BasePatterns records how long generating a file's base pattern windows took
+func (p *FileProfile) BasePatterns(path string, duration time.Duration, windows int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t := p.timing(path)
+	t.base, t.windows = duration, windows
+}
+
+// WriteCSV writes one row per file, slowest first:
+// file,parse_ms,entries,cached,base_ms,windows
+func (p *FileProfile) WriteCSV(path string) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	files := make([]string, 0, len(p.files))
+	for f := range p.files {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		a, b := p.files[files[i]], p.files[files[j]]
+		if a.parse+a.base != b.parse+b.base {
+			return a.parse+a.base > b.parse+b.base
+		}
+		return files[i] < files[j]
+	})
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := csv.NewWriter(out)
+	w.Write([]string{"file", "parse_ms", "entries", "cached", "base_ms", "windows"})
+	for _, f := range files {
+		t := p.files[f]
+		w.Write([]string{
+			f,
+			formatMs(t.parse),
+			strconv.Itoa(t.entries),
+			strconv.FormatBool(t.cached),
+			formatMs(t.base),
+			strconv.Itoa(t.windows),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// formatMs renders a duration in milliseconds with microsecond precision
+func formatMs(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', 3, 64)
+}
@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProgressEvent is one line of the --progress-json event stream
+//
+// Events:
+//
+//	phase_start  {phase}                     a phase (parse, detect, filter, output) began
+//	phase_end    {phase, duration_ms}        a phase finished
+//	files_parsed {files, total}              periodic parse progress
+//	generation   {generation, survivors}     a growth generation finished
+//	summary      {patterns, files, lines, duration_ms}
+//
+// Numeric fields that are zero are omitted.
+type ProgressEvent struct {
+	Time       string `json:"time"`
+	Event      string `json:"event"`
+	Phase      string `json:"phase,omitempty"`
+	Files      int    `json:"files,omitempty"`
+	Total      int    `json:"total,omitempty"`
+	Lines      int    `json:"lines,omitempty"`
+	Generation int    `json:"generation,omitempty"`
+	Survivors  int    `json:"survivors,omitempty"`
+	Patterns   int    `json:"patterns,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+}
+
+// ProgressEmitter writes progress events as JSON lines. A nil emitter is a no-op.
+type ProgressEmitter struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+	phases map[string]time.Time
+}
+
+// progress is the active event stream (set from --progress-json, nil when disabled)
+var progress *ProgressEmitter
+
+// progressFilesInterval controls how often files_parsed events are emitted
+const progressFilesInterval = 100
+
+// openProgress opens the event stream target: a file path, "-" for stderr, or "fd:N"
+func openProgress(target string) (*ProgressEmitter, error) {
+	var w io.Writer
+	var closer io.Closer
+	switch {
+	case target == "-":
+		w = os.Stderr
+	case strings.HasPrefix(target, "fd:"):
+		fd, err := strconv.Atoi(strings.TrimPrefix(target, "fd:"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid file descriptor: %s", target)
+		}
+		f := os.NewFile(uintptr(fd), target)
+		if f == nil {
+			return nil, fmt.Errorf("invalid file descriptor: %s", target)
+		}
+		w, closer = f, f
+	default:
+		f, err := os.Create(target)
+		if err != nil {
+			return nil, err
+		}
+		w, closer = f, f
+	}
+	return &ProgressEmitter{
+		enc:    json.NewEncoder(w),
+		closer: closer,
+		phases: make(map[string]time.Time),
+	}, nil
+}
+
+func (p *ProgressEmitter) emit(e ProgressEvent) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	p.enc.Encode(e)
+}
+
+// PhaseStart records the start of a phase
+func (p *ProgressEmitter) PhaseStart(phase string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.phases[phase] = time.Now()
+	p.mu.Unlock()
+	p.emit(ProgressEvent{Event: "phase_start", Phase: phase})
+}
+
+// PhaseEnd records the end of a phase started with PhaseStart
+func (p *ProgressEmitter) PhaseEnd(phase string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	elapsed := time.Since(p.phases[phase])
+	p.mu.Unlock()
+	p.emit(ProgressEvent{Event: "phase_end", Phase: phase, DurationMs: elapsed.Milliseconds()})
+}
+
+// FilesParsed reports parse progress
+func (p *ProgressEmitter) FilesParsed(done, total int) {
+	p.emit(ProgressEvent{Event: "files_parsed", Files: done, Total: total})
+}
+
+// Generation reports a finished growth generation
+func (p *ProgressEmitter) Generation(length, survivors int) {
+	p.emit(ProgressEvent{Event: "generation", Generation: length, Survivors: survivors})
+}
+
+// Summary reports the final scan totals
+func (p *ProgressEmitter) Summary(patterns, files, lines int, elapsed time.Duration) {
+	p.emit(ProgressEvent{Event: "summary", Patterns: patterns, Files: files, Lines: lines, DurationMs: elapsed.Milliseconds()})
+}
+
+// Close closes the underlying file, if any
+func (p *ProgressEmitter) Close() {
+	if p == nil || p.closer == nil {
+		return
+	}
+	p.closer.Close()
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"bytes"
+	"sort"
+)
+
+// maxRepeatPeriod bounds the block length considered when looking for consecutive repeats
+const maxRepeatPeriod = 50
+
+// RepeatedRun is a block repeated back-to-back within one file, e.g. generated tables.
+// Listing each copy as an occurrence is noise; one "repeated N times" finding is actionable.
+type RepeatedRun struct {
+	Hash       uint64
+	Filename   string
+	LineStart  int
+	LineEnd    int
+	BlockLines int // entries per repeated block
+	Repeats    int
+}
+
+// findRepeatedRuns finds blocks of minSize..maxRepeatPeriod entries repeated at least
+// minRepeats times at consecutive entry positions. Each region is reported once, using
+// the shortest period that explains it. Entries only match structurally, so a copy
+// counts only while its tokens are at least minSimilarity similar to the first block's:
+// a run of validations sharing a shape is distinct code, not one block repeated.
+func findRepeatedRuns(fileData map[string][]Entry, minSize, minRepeats int, minSimilarity float64, blocked map[uint64]bool) []RepeatedRun {
+	var runs []RepeatedRun
+	for filename, entries := range fileData {
+		lang := languageFor(filename)
+		n := len(entries)
+		for i := 0; i < n; {
+			advanced := false
+			for p := minSize; p <= maxRepeatPeriod && i+2*p <= n; p++ {
+				// Count how far entries keep matching the entry one period later
+				k := 0
+				for i+k+p < n && bytes.Equal(entries[i+k].HashBytes(), entries[i+k+p].HashBytes()) {
+					k++
+				}
+				if k/p+1 < minRepeats {
+					continue
+				}
+				first := similarityTokens(entries[i:i+p], &lang)
+				repeats := 1
+				for r := 1; r <= k/p; r++ {
+					tokens := similarityTokens(entries[i+r*p:i+(r+1)*p], &lang)
+					if tokenSimilarity(first, tokens) < minSimilarity {
+						break
+					}
+					repeats++
+				}
+				if repeats < minRepeats {
+					continue
+				}
+				hash := activeStrategy.Hash(entries[i : i+p])
+				end := i + repeats*p
+				if !blocked[hash] {
+					runs = append(runs, RepeatedRun{
+						Hash:       hash,
+						Filename:   filename,
+						LineStart:  entries[i].GetLineNumber(),
+						LineEnd:    entries[end-1].GetLineNumber(),
+						BlockLines: p,
+						Repeats:    repeats,
+					})
+				}
+				i = end
+				advanced = true
+				break
+			}
+			if !advanced {
+				i++
+			}
+		}
+	}
+
+	// Largest runs first, then by location for deterministic output
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].Repeats != runs[j].Repeats {
+			return runs[i].Repeats > runs[j].Repeats
+		}
+		if runs[i].Filename != runs[j].Filename {
+			return runs[i].Filename < runs[j].Filename
+		}
+		return runs[i].LineStart < runs[j].LineStart
+	})
+	return runs
+}
@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindRepeatedRunsRequiresSimilarCopies(t *testing.T) {
+	useStrategy(t, "normalized-indent")
+	tests := []struct {
+		name    string
+		block   func(i int) string
+		wantRun bool
+	}{
+		{
+			"generated copies of one block",
+			func(i int) string {
+				return "\tif err := step(); err != nil {\n\t\treturn err\n\t}\n"
+			},
+			true,
+		},
+		{
+			"distinct validations sharing a shape",
+			func(i int) string {
+				checks := []string{
+					"\tif *maxSize > 0 && *maxSize < *minSize {\n\t\tfmt.Fprintf(os.Stderr, \"Error: --max-size must be >= --min-size\\n\")\n\t\tos.Exit(1)\n\t}\n",
+					"\tif tabWidth < 1 {\n\t\tfmt.Fprintf(os.Stderr, \"Error: --tab-width must be >= 1\\n\")\n\t\tos.Exit(1)\n\t}\n",
+					"\tif similarityFloor < 0 || similarityFloor >= 1 {\n\t\tfmt.Fprintf(os.Stderr, \"Error: --similarity-floor must be below 1.0\\n\")\n\t\tos.Exit(1)\n\t}\n",
+					"\tif *noiseScore > *actionableScore {\n\t\tfmt.Fprintf(os.Stderr, \"Error: --noise-score must be <= --actionable-score\\n\")\n\t\tos.Exit(1)\n\t}\n",
+				}
+				return checks[i]
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			b.WriteString("func f() error {\n")
+			for i := range 4 {
+				b.WriteString(tt.block(i))
+			}
+			b.WriteString("\treturn nil\n}\n")
+			fileData := map[string][]Entry{"a.go": parseSource(t, "a.go", b.String())}
+
+			runs := findRepeatedRuns(fileData, 3, 3, 0.75, nil)
+			if got := len(runs) > 0; got != tt.wantRun {
+				t.Errorf("runs = %+v, want a run: %v", runs, tt.wantRun)
+			}
+		})
+	}
+}
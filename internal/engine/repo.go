@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// runRepo scans a remote repository (--repo): it fetches ref (the default branch when
+// empty) shallowly into a temporary directory, scans it with the other flags in a child
+// quickdup, as runCompare does, and removes the directory whatever the outcome. Results
+// are kept in .quickdup/repos/<name> of the working directory. It returns the child's
+// exit status.
+func runRepo(url, ref, subdir string, analyze bool) int {
+	dir, err := os.MkdirTemp("", "quickdup-repo-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating temp dir: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	// The child handles Ctrl-C itself; stay alive to clean up after it
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	display := url
+	if ref != "" {
+		display += "@" + ref
+	}
+	fmt.Printf("Fetching %s...\n", display)
+	if err := shallowFetch(url, ref, dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --repo: %v\n", err)
+		return 1
+	}
+
+	scanPath := filepath.Join(dir, subdir)
+	args := []string{"-path", scanPath}
+	if analyze {
+		args = append([]string{"analyze"}, args...)
+	}
+	pathBaseSet := false
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "repo", "ref", "path":
+			return
+		case "path-base":
+			pathBaseSet = true
+		}
+		args = append(args, fmt.Sprintf("-%s=%s", f.Name, f.Value.String()))
+	})
+	if !pathBaseSet {
+		// Report paths relative to the repository, not the temporary directory
+		args = append(args, "-path-base", dir)
+	}
+	// Paths differ per clone, so a parse cache would never hit
+	args = append(args, "-no-cache")
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	status := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			fmt.Fprintf(os.Stderr, "Error: running quickdup on %s: %v\n", display, err)
+			return 1
+		}
+		status = exitErr.ExitCode()
+	}
+
+	resultsDir := filepath.Join(".quickdup", "repos", repoName(url, ref))
+	if err := keepResults(filepath.Join(scanPath, ".quickdup"), resultsDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: keeping results of %s: %v\n", display, err)
+		return 1
+	}
+	PrintRepoResultsDir(resultsDir)
+	return status
+}
+
+// shallowFetch checks out ref of the repository at url into dir with just its latest
+// commit. Fetching instead of cloning takes commit hashes as well as branches and tags.
+func shallowFetch(url, ref, dir string) error {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", url, ref},
+		{"checkout", "--quiet", "--detach", "FETCH_HEAD"},
+	}
+	for _, step := range steps {
+		args := append([]string{"-C", dir}, step...)
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s: %v\n%s", step[0], err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// repoName names a repository's results directory: the last element of its URL
+// without .git, with the ref when given
+func repoName(url, ref string) string {
+	name := strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	if ref != "" {
+		name += "@" + strings.ReplaceAll(ref, "/", "-")
+	}
+	if name == "" || name == "." || name == ".." {
+		name = "repo"
+	}
+	return name
+}
+
+// keepResults copies the report files a scan wrote to from (not its caches) into to
+func keepResults(from, to string) error {
+	entries, err := os.ReadDir(from)
+	if os.IsNotExist(err) {
+		return nil // the scan stopped before writing any
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(to, 0o755); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) == ".gob" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(from, e.Name()))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(to, e.Name()), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"path/filepath"
+)
+
+// reportBase is the absolute directory reported paths are relative to (set from
+// --path-base); empty reports paths as they were scanned
+var reportBase string
+
+// reportPath returns a file path as it's reported: relative to reportBase, then
+// anonymized when --anonymize is set
+func reportPath(path string) string {
+	return anonymizer.Name(rebase(path))
+}
+
+// reportDir is reportPath for directories
+func reportDir(path string) string {
+	return anonymizer.Dir(rebase(path))
+}
+
+// rebase returns path relative to reportBase, or unchanged when there's no base or
+// no relative path to it
+func rebase(path string) string {
+	if reportBase == "" {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(reportBase, abs)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+// sourcePath returns the file behind a reported path, for reading code and blame
+func sourcePath(name string) string {
+	name = anonymizer.Original(name)
+	if reportBase != "" && !filepath.IsAbs(name) {
+		return filepath.Join(reportBase, name)
+	}
+	return name
+}
+
+// reportFindings rewrites the paths of every finding to their reported form, in place
+func reportFindings(matches []PatternMatch, repeats []RepeatedRun, fuzzy []FuzzyDuplicate, tokenClones []TokenClone, queries []SQLDuplicate, numbers []MagicNumber, imports []CommonImport, accessors []AccessorClass) {
+	for _, m := range matches {
+		for i := range m.Locations {
+			m.Locations[i].Filename = reportPath(m.Locations[i].Filename)
+		}
+	}
+	for i := range repeats {
+		repeats[i].Filename = reportPath(repeats[i].Filename)
+	}
+	for i := range fuzzy {
+		for j := range fuzzy[i].Regions {
+			fuzzy[i].Regions[j].Filename = reportPath(fuzzy[i].Regions[j].Filename)
+		}
+	}
+	for _, c := range tokenClones {
+		for i := range c.Spans {
+			c.Spans[i].Filename = reportPath(c.Spans[i].Filename)
+		}
+	}
+	for _, q := range queries {
+		for i := range q.Locations {
+			q.Locations[i].Filename = reportPath(q.Locations[i].Filename)
+		}
+	}
+	for _, n := range numbers {
+		for i := range n.Locations {
+			n.Locations[i].Filename = reportPath(n.Locations[i].Filename)
+		}
+	}
+	for i := range imports {
+		imports[i].Dir = reportDir(imports[i].Dir)
+	}
+	for i := range accessors {
+		accessors[i].Filename = reportPath(accessors[i].Filename)
+	}
+}
+
+// reportIdenticalFiles rewrites the paths of identical file sets to their reported form, in place
+func reportIdenticalFiles(identical []IdenticalFiles) {
+	for i := range identical {
+		identical[i].Kept = reportPath(identical[i].Kept)
+		for j := range identical[i].Copies {
+			identical[i].Copies[j] = reportPath(identical[i].Copies[j])
+		}
+	}
+}
+
+// reportWarnings returns warnings with their paths in reported form
+func reportWarnings(items []Warning) []Warning {
+	for i := range items {
+		if items[i].Path != "" {
+			items[i].Path = reportPath(items[i].Path)
+		}
+	}
+	return items
+}
+
+// reportScannedFiles returns scanned files with their paths in reported form
+func reportScannedFiles(files []ScannedFile) []ScannedFile {
+	for i := range files {
+		files[i].Filename = reportPath(files[i].Filename)
+	}
+	return files
+}
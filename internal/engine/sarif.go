@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SARIF 2.1.0 log, as far as code scanning dashboards read it
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          sarifProperties   `json:"properties"`
+}
+
+type sarifProperties struct {
+	Score       int     `json:"score"`
+	Similarity  float64 `json:"similarity"`
+	Occurrences int     `json:"occurrences"`
+	Lines       int     `json:"lines"`
+	Tier        string  `json:"tier,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Properties       *sarifLocationProps   `json:"properties,omitempty"`
+}
+
+type sarifLocationProps struct {
+	Canonical bool `json:"canonical"` // the representative occurrence
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// WriteSARIF writes matches as a SARIF 2.1.0 log for code scanning dashboards: one
+// result per match, ruled by the strategy, with every occurrence as a location and the
+// representative first
+func WriteSARIF(matches []PatternMatch, strategy, path string) error {
+	results := make([]sarifResult, 0, len(matches))
+	for _, m := range matches {
+		level := "note"
+		if m.Tier == TierActionable {
+			level = "warning"
+		}
+		locations := []sarifLocation{sarifLocationOf(m.Locations[m.Representative], true)}
+		for i, loc := range m.Locations {
+			if i != m.Representative {
+				locations = append(locations, sarifLocationOf(loc, false))
+			}
+		}
+		results = append(results, sarifResult{
+			RuleID: strategy,
+			Level:  level,
+			Message: sarifMessage{Text: fmt.Sprintf("Duplicate code: %d lines in %d places (score %d, %.0f%% similar)",
+				len(m.Pattern), len(m.Locations), m.Score, m.Similarity*100)},
+			Locations:           locations,
+			PartialFingerprints: map[string]string{"quickdupHash/v1": fmt.Sprintf("%016x", m.Hash)},
+			Properties: sarifProperties{
+				Score:       m.Score,
+				Similarity:  m.Similarity,
+				Occurrences: len(m.Locations),
+				Lines:       len(m.Pattern),
+				Tier:        m.Tier,
+			},
+		})
+	}
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "quickdup",
+				Version:        toolVersion(),
+				InformationURI: "https://github.com/asynkron/Asynkron.QuickDup",
+				Rules: []sarifRule{{
+					ID:               strategy,
+					ShortDescription: sarifMessage{Text: fmt.Sprintf("Duplicated code (%s strategy)", strategy)},
+				}},
+			}},
+			Results: results,
+		}},
+	}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return &ScanError{Op: "create output directory", Path: filepath.Dir(path), Err: err}
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return &ScanError{Op: "write SARIF", Path: path, Err: err}
+	}
+	return nil
+}
+
+// sarifLocationOf maps an occurrence to a SARIF location
+func sarifLocationOf(loc PatternLocation, canonical bool) sarifLocation {
+	l := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: sarifURI(loc.Filename)},
+		Region:           sarifRegion{StartLine: loc.LineStart, EndLine: locationEndLine(loc)},
+	}}
+	if canonical {
+		l.Properties = &sarifLocationProps{Canonical: true}
+	}
+	return l
+}
+
+// sarifURI returns a reported path as a relative, slash-separated URI, so uploads line
+// up with repository files: absolute paths are made relative to the working directory,
+// where the repository root normally is
+func sarifURI(path string) string {
+	if filepath.IsAbs(path) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, path); err == nil {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
@@ -0,0 +1,242 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// MemoryCache keeps parsed files in memory across the scans of quickdup serve, keyed
+// by extension and content hash, so unchanged files (in any directory or checkout)
+// are parsed once. Files no scan used in the last memoryCacheScans are dropped, so
+// scanning many refs or directories doesn't grow it without bound.
+type MemoryCache struct {
+	mu    sync.Mutex
+	files map[uint64]memoryCachedFile
+	scan  int // scans begun so far
+}
+
+type memoryCachedFile struct {
+	entries    []Entry
+	suppressed []int
+	lastScan   int // the last scan that used the file
+}
+
+// memoryCacheScans is how many recent scans keep a file in the memory cache
+const memoryCacheScans = 8
+
+// NewMemoryCache creates an empty MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{files: make(map[uint64]memoryCachedFile)}
+}
+
+// memoryCache is the cache parseFile consults, nil outside quickdup serve
+var memoryCache *MemoryCache
+
+// memoryCacheKey hashes a file's language extension with its content: the same
+// content parses differently under another language's rules
+func memoryCacheKey(path string, data []byte) uint64 {
+	h := xxhash.New()
+	h.WriteString(languageFor(path).Ext)
+	h.Write([]byte{0})
+	h.Write(data)
+	return h.Sum64()
+}
+
+// Get returns the cached parse of a file's content
+func (c *MemoryCache) Get(path string, data []byte) ([]Entry, []int, bool) {
+	if c == nil {
+		return nil, nil, false
+	}
+	key := memoryCacheKey(path, data)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, ok := c.files[key]
+	if ok {
+		f.lastScan = c.scan
+		c.files[key] = f
+	}
+	return f.entries, f.suppressed, ok
+}
+
+// Put caches the parse of a file's content
+func (c *MemoryCache) Put(path string, data []byte, entries []Entry, suppressed []int) {
+	if c == nil {
+		return
+	}
+	key := memoryCacheKey(path, data)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files[key] = memoryCachedFile{entries, suppressed, c.scan}
+}
+
+// BeginScan starts a scan, dropping the files none of the last memoryCacheScans used
+func (c *MemoryCache) BeginScan() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scan++
+	for key, f := range c.files {
+		if c.scan-f.lastScan > memoryCacheScans {
+			delete(c.files, key)
+		}
+	}
+}
+
+// ScanConfig holds the settings of scans run outside the main pipeline (every scan of
+// quickdup serve, --stdin): the flags quickdup was started with
+type ScanConfig struct {
+	Root         string // repository scanned without ?path, and checked out for ?ref
+	Strategy     string
+	Extension    string
+	Exclude      []string
+	MinSize      int
+	MaxSize      int
+	DetectOccur  int // occurrences detection keeps (see lowestMinOccur)
+	KeepOverlaps bool
+	Filter       FilterConfig
+	Tiers        TierConfig
+	Config       *JSONConfig
+}
+
+// scanServer answers scan requests; scans share the package-level parse and detection
+// state, so they run one at a time
+type scanServer struct {
+	mu     sync.Mutex
+	config ScanConfig
+}
+
+// runServe serves GET /scan until the listener fails. ?path=DIR scans a directory,
+// ?ref=REF a git ref of the root repository (checked out in a temporary worktree);
+// without either the root is scanned. The response is the results.json document.
+func runServe(listen string, config ScanConfig) error {
+	memoryCache = NewMemoryCache()
+	s := &scanServer{config: config}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /scan", s.handleScan)
+	PrintServing(listen, config.Root)
+	return http.ListenAndServe(listen, mux)
+}
+
+func (s *scanServer) handleScan(w http.ResponseWriter, r *http.Request) {
+	path, ref := r.URL.Query().Get("path"), r.URL.Query().Get("ref")
+	if path != "" && ref != "" {
+		http.Error(w, "path and ref are exclusive", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	root := s.config.Root
+	switch {
+	case path != "":
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			http.Error(w, fmt.Sprintf("%s is not a directory", path), http.StatusBadRequest)
+			return
+		}
+		root = path
+	case ref != "":
+		dir, remove, err := addWorktree(root, ref)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer remove()
+		root = dir
+	}
+
+	var body bytes.Buffer
+	if err := s.scan(root, &body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body.Bytes())
+}
+
+// scan runs detection over root and writes the results document to body, with paths
+// relative to root
+func (s *scanServer) scan(root string, body *bytes.Buffer) error {
+	start := time.Now()
+	c := s.config
+	warnings := &Warnings{}
+	files, err := collectFiles(root, c.Extension, c.Exclude, warnings)
+	if err != nil {
+		return err
+	}
+
+	suppressions := &Suppressions{}
+	parseStart := time.Now()
+	memoryCache.BeginScan()
+	fileData, _, _ := parseFilesWithCache(files, nil, nil, warnings, suppressions)
+	parseTime := time.Since(parseStart)
+
+	filter := c.Filter
+	expected, _ := LoadExpectedHashes(root, c.MinSize, warnings)
+	filter.UserIgnored = mergeIgnored(readIgnoredHashes(root, c.Strategy, warnings), expected)
+	filter.Suppressed = suppressions
+	filter.Root = root
+	matches, detectTime, filterTime := c.detectAndFilter(fileData, filter)
+
+	defer func(base string) { reportBase = base }(reportBase)
+	if reportBase, err = filepath.Abs(root); err != nil {
+		return err
+	}
+	reportFindings(matches, nil, nil, nil, nil, nil, nil, nil)
+	totalLines := 0
+	for _, entries := range fileData {
+		totalLines += len(entries)
+	}
+	return writeJSONResults(body, matches, ResultsExtras{
+		Warnings: reportWarnings(warnings.Items()),
+		Config:   c.Config,
+		Timings: &Timings{
+			ParseMs:  parseTime.Milliseconds(),
+			DetectMs: detectTime.Milliseconds(),
+			FilterMs: filterTime.Milliseconds(),
+			TotalMs:  time.Since(start).Milliseconds(),
+			Files:    len(fileData),
+			Lines:    totalLines,
+		},
+	})
+}
+
+// detectAndFilter runs pattern detection over parsed files and filters and tiers the
+// patterns with filter, returning the matches and how long each step took
+func (c ScanConfig) detectAndFilter(fileData map[string][]Entry, filter FilterConfig) ([]PatternMatch, time.Duration, time.Duration) {
+	detectStart := time.Now()
+	patterns := detectPatterns(fileData, len(fileData), c.DetectOccur, c.MinSize, c.MaxSize, c.KeepOverlaps)
+	detectTime := time.Since(detectStart)
+
+	filterStart := time.Now()
+	matches, _ := FilterPatterns(patterns, filter)
+	assignTiers(matches, c.Tiers)
+	return matches, detectTime, time.Since(filterStart)
+}
+
+// addWorktree checks out ref of the repository at repo into a temporary worktree,
+// returning its directory and a function removing it. ref comes from the request, so
+// it is passed after --end-of-options: a value like --help is a ref, not an option.
+func addWorktree(repo, ref string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "quickdup-serve-")
+	if err != nil {
+		return "", nil, err
+	}
+	if output, err := exec.Command("git", "-C", repo, "worktree", "add", "--detach", dir, "--end-of-options", ref).CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("checking out %s: %v\n%s", ref, err, output)
+	}
+	return dir, func() {
+		exec.Command("git", "-C", repo, "worktree", "remove", "--force", dir).Run()
+		os.RemoveAll(dir)
+	}, nil
+}
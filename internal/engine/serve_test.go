@@ -0,0 +1,23 @@
+package engine
+
+import "testing"
+
+func TestMemoryCacheDropsFilesUnusedByRecentScans(t *testing.T) {
+	c := NewMemoryCache()
+	stale, used := []byte("package stale\n"), []byte("package used\n")
+	c.BeginScan()
+	c.Put("stale.go", stale, nil, nil)
+	c.Put("used.go", used, nil, nil)
+
+	// The stale file is never read again: it stays for memoryCacheScans scans, then goes
+	for scan := 1; scan <= memoryCacheScans+1; scan++ {
+		c.BeginScan()
+		if _, _, ok := c.Get("used.go", used); !ok {
+			t.Fatalf("scan %d: file used by every scan was dropped", scan)
+		}
+		_, cached := c.files[memoryCacheKey("stale.go", stale)]
+		if want := scan < memoryCacheScans+1; cached != want {
+			t.Fatalf("scan %d: unused file cached = %v, want %v", scan, cached, want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// SimilarityMatrix holds the pairwise token similarities and renamings between a
+// pattern's occurrences, in location order. It doesn't depend on any threshold.
+type SimilarityMatrix struct {
+	Locations    []string               // "file:line" per occurrence, to validate the cached entry
+	Similarities []float64              // upper triangle, row-major (see pairIndex)
+	Renamings    map[int][]Substitution // consistent renamings, by pair index
+}
+
+// pairIndex locates pair i < j of n occurrences in SimilarityMatrix.Similarities
+func pairIndex(i, j, n int) int {
+	return i*n - i*(i+1)/2 + (j - i - 1)
+}
+
+// SimilarityCache stores similarity matrices per pattern hash across runs, so a re-run
+// that only changes thresholds (-min-similarity, -max-similarity, -min-score, ...)
+// re-clusters without re-tokenizing or recomputing Jaccard similarity.
+// Safe for concurrent use by filter workers.
+type SimilarityCache struct {
+	Version int
+	Key     string // strategy, parse options and file states the matrices were computed for
+	Matrix  map[uint64]*SimilarityMatrix
+
+	mu    sync.Mutex
+	used  map[uint64]*SimilarityMatrix // matrices needed this run; the rest are pruned on save
+	hits  int
+	dirty bool
+}
+
+const similarityCacheVersion = 3
+
+// similarityCacheKey fingerprints everything the matrices depend on besides the
+// occurrences themselves: any file change, strategy or metric switch invalidates the
+// cache
+func similarityCacheKey(strategyName string, metric string, fileData map[string][]Entry) string {
+	files := make([]string, 0, len(fileData))
+	for f := range fileData {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	h := newWindowHash()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", strategyName, metric, parseOptionsKey(), cacheKeyMode)
+	for _, f := range files {
+		var stamp uint64
+		var size int64
+		if info, err := os.Stat(f); err == nil {
+			size = info.Size()
+			stamp, _ = fileStamp(f)
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\x00", f, stamp, size)
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// loadSimilarityCache reads the strategy's similarity cache from cacheDir, returning an
+// empty cache for key if it is missing or was computed for other files or options
+func loadSimilarityCache(cacheDir string, strategyName string, key string) *SimilarityCache {
+	empty := &SimilarityCache{Version: similarityCacheVersion, Key: key, Matrix: make(map[uint64]*SimilarityMatrix)}
+
+	file, err := os.Open(filepath.Join(cacheDir, strategyName+"-similarity.gob"))
+	if err != nil {
+		return empty
+	}
+	defer file.Close()
+
+	var cache SimilarityCache
+	if err := gob.NewDecoder(file).Decode(&cache); err != nil {
+		return empty
+	}
+	if cache.Version != similarityCacheVersion || cache.Key != key || cache.Matrix == nil {
+		return empty
+	}
+	return &cache
+}
+
+// Lookup returns the cached matrix for a pattern's occurrences (in location order),
+// or nil if there is none for exactly these occurrences; a nil cache never hits
+func (c *SimilarityCache) Lookup(hash uint64, locations []PatternLocation) *SimilarityMatrix {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := c.Matrix[hash]
+	if m == nil || len(m.Locations) != len(locations) {
+		return nil
+	}
+	for i, loc := range locations {
+		if m.Locations[i] != locationKey(loc) {
+			return nil
+		}
+	}
+	c.markUsed(hash, m)
+	c.hits++
+	return m
+}
+
+// Store records a freshly computed matrix
+func (c *SimilarityCache) Store(hash uint64, m *SimilarityMatrix) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.markUsed(hash, m)
+	c.dirty = true
+}
+
+func (c *SimilarityCache) markUsed(hash uint64, m *SimilarityMatrix) {
+	if c.used == nil {
+		c.used = make(map[uint64]*SimilarityMatrix)
+	}
+	c.used[hash] = m
+}
+
+// Hits returns how many patterns reused a cached matrix
+func (c *SimilarityCache) Hits() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+// save writes the matrices used this run to cacheDir, if any had to be computed
+func (c *SimilarityCache) save(cacheDir string, strategyName string) {
+	if c == nil || !c.dirty {
+		return
+	}
+	c.Matrix = c.used
+
+	os.MkdirAll(cacheDir, 0755)
+	file, err := os.Create(filepath.Join(cacheDir, strategyName+"-similarity.gob"))
+	if err != nil {
+		return // silently fail
+	}
+	defer file.Close()
+	gob.NewEncoder(file).Encode(c)
+}
+
+// locationKey identifies an occurrence in a SimilarityMatrix
+func locationKey(loc PatternLocation) string {
+	return loc.Filename + ":" + strconv.Itoa(loc.LineStart)
+}
@@ -0,0 +1,438 @@
+package engine
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// UnionFind implements a disjoint-set data structure for clustering
+type UnionFind struct {
+	parent []int
+	rank   []int
+}
+
+// NewUnionFind creates a new UnionFind with n elements
+func NewUnionFind(n int) *UnionFind {
+	uf := &UnionFind{
+		parent: make([]int, n),
+		rank:   make([]int, n),
+	}
+	for i := range uf.parent {
+		uf.parent[i] = i
+	}
+	return uf
+}
+
+// Find returns the root of the set containing x (with path compression)
+func (uf *UnionFind) Find(x int) int {
+	if uf.parent[x] != x {
+		uf.parent[x] = uf.Find(uf.parent[x])
+	}
+	return uf.parent[x]
+}
+
+// Union merges the sets containing x and y
+func (uf *UnionFind) Union(x, y int) {
+	px, py := uf.Find(x), uf.Find(y)
+	if px == py {
+		return
+	}
+	if uf.rank[px] < uf.rank[py] {
+		px, py = py, px
+	}
+	uf.parent[py] = px
+	if uf.rank[px] == uf.rank[py] {
+		uf.rank[px]++
+	}
+}
+
+// operatorChars form tokens of their own, so spacing around operators is cosmetic:
+// "a+b" and "a + b" both tokenize to a, +, b
+const operatorChars = "+-*/%&|^~?"
+
+// tokenizeLine extracts all tokens from a source line. Whitespace only separates
+// tokens, so alignment, indentation and spacing never affect similarity.
+func tokenizeLine(line string) []string {
+	if caseInsensitive {
+		line = strings.ToLower(line)
+	}
+	var tokens []string
+	var current strings.Builder
+	inOperator := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range line {
+		switch {
+		case strings.ContainsRune(separators, r) || r == '"' || r == '\'' || r == '`':
+			flush()
+		case strings.ContainsRune(operatorChars, r):
+			if !inOperator {
+				flush()
+			}
+			inOperator = true
+			current.WriteRune(r)
+		default:
+			if inOperator {
+				flush()
+			}
+			inOperator = false
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// trailingPunctuation is stripped from line ends before classifying or normalizing
+// them, so the last element of a list or enum reads like the others. Similarity needs
+// no trimming: tokenizeLine drops commas and semicolons as separators.
+const trailingPunctuation = ",; \t\r"
+
+// trimTrailingPunctuation removes trailing commas, semicolons and whitespace from a line
+func trimTrailingPunctuation(line string) string {
+	return strings.TrimRight(line, trailingPunctuation)
+}
+
+// tokenizeCode tokenizes a source line of lang like tokenizeLine, except that each of
+// its string literals is one opaque token, quotes and escaped quotes included: unrelated
+// strings sharing a word don't make lines similar, while equal strings still match.
+// Lines of languages with no known quotes are tokenized by tokenizeLine.
+func tokenizeCode(line string, lang *Language) []string {
+	if lang == nil || lang.StringQuotes == "" {
+		return tokenizeLine(line)
+	}
+	var tokens []string
+	for {
+		start := strings.IndexAny(line, lang.StringQuotes)
+		if start < 0 {
+			return append(tokens, tokenizeLine(line)...)
+		}
+		tokens = append(tokens, tokenizeLine(line[:start])...)
+		end := stringLiteralEnd(line, start, lang)
+		tokens = append(tokens, literalToken(line[start:end]))
+		line = line[end:]
+	}
+}
+
+// literalToken is a string literal as a token: verbatim, but lowercased with
+// --case-insensitive like the code around it
+func literalToken(literal string) string {
+	if caseInsensitive {
+		return strings.ToLower(literal)
+	}
+	return literal
+}
+
+// tokenizePattern extracts all tokens from a pattern's source lines, in lang
+func tokenizePattern(pattern []Entry, lang *Language) []string {
+	var tokens []string
+	for _, entry := range pattern {
+		tokens = append(tokens, tokenizeCode(entry.GetRaw(), lang)...)
+	}
+	return tokens
+}
+
+// similarityTokens returns the tokens a pattern's occurrences are compared by: the
+// active strategy's, when it has its own, otherwise those of the source lines, in lang
+func similarityTokens(pattern []Entry, lang *Language) []string {
+	if t, ok := activeStrategy.(SimilarityTokenizer); ok {
+		return t.SimilarityTokens(pattern)
+	}
+	return tokenizePattern(pattern, lang)
+}
+
+// Similarity metrics (--similarity-metric)
+const (
+	SimilarityJaccard = "jaccard" // shared distinct tokens: a token counts once however often it appears
+	SimilarityCosine  = "cosine"  // token frequency vectors: repeating a token more often lowers similarity
+)
+
+// SimilarityMetric compares the tokens of two occurrences, from 0 (nothing in common)
+// to 1 (the same tokens)
+type SimilarityMetric func(a, b []string) float64
+
+var similarityMetrics = map[string]SimilarityMetric{
+	SimilarityJaccard: jaccardSimilarity,
+	SimilarityCosine:  cosineSimilarity,
+}
+
+// tokenSimilarity is the metric occurrences are compared and clustered by
+var tokenSimilarity SimilarityMetric = jaccardSimilarity
+
+// jaccardSimilarity computes Jaccard similarity between two token sets
+func jaccardSimilarity(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1.0
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0.0
+	}
+
+	setA := make(map[string]bool)
+	for _, t := range a {
+		setA[t] = true
+	}
+
+	setB := make(map[string]bool)
+	for _, t := range b {
+		setB[t] = true
+	}
+
+	intersection := 0
+	for t := range setA {
+		if setB[t] {
+			intersection++
+		}
+	}
+
+	union := len(setA) + len(setB) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+// cosineSimilarity computes the cosine of the angle between two token frequency vectors
+func cosineSimilarity(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1.0
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0.0
+	}
+
+	countA := make(map[string]int)
+	for _, t := range a {
+		countA[t]++
+	}
+	countB := make(map[string]int)
+	for _, t := range b {
+		countB[t]++
+	}
+
+	dot, normA, normB := 0, 0, 0
+	for t, n := range countA {
+		dot += n * countB[t]
+		normA += n * n
+	}
+	for _, n := range countB {
+		normB += n * n
+	}
+	return min(float64(dot)/math.Sqrt(float64(normA)*float64(normB)), 1.0)
+}
+
+// computeAverageTokenSimilarity computes the average pairwise token similarity across all occurrences
+func computeAverageTokenSimilarity(locations []PatternLocation) float64 {
+	if len(locations) < 2 {
+		return 1.0 // Single occurrence = 100% similar to itself
+	}
+
+	// Tokenize all patterns
+	tokenized := make([][]string, len(locations))
+	for i, loc := range locations {
+		lang := languageFor(loc.Filename)
+		tokenized[i] = similarityTokens(loc.Pattern, &lang)
+	}
+
+	// Compute average pairwise similarity
+	totalSim := 0.0
+	pairs := 0
+	for i := 0; i < len(tokenized); i++ {
+		for j := i + 1; j < len(tokenized); j++ {
+			totalSim += tokenSimilarity(tokenized[i], tokenized[j])
+			pairs++
+		}
+	}
+
+	if pairs == 0 {
+		return 1.0
+	}
+	return totalSim / float64(pairs)
+}
+
+// ClusterResult holds a cluster of similar locations and their average similarity
+type ClusterResult struct {
+	Locations        []PatternLocation // sorted by filename, then line
+	Similarity       float64
+	MemberSimilarity []float64      // per location: average similarity to the other members
+	Substitutions    []Substitution // consistent renamings between members (parametrizable duplicate)
+}
+
+// sortedLocations returns a copy of locations ordered by filename, then line: the
+// order of clustering, so renamings read from the earlier occurrence to the later
+func sortedLocations(locations []PatternLocation) []PatternLocation {
+	sorted := append([]PatternLocation(nil), locations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return locationLess(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// similarityMatrix computes the pairwise similarities and renamings of locations
+func similarityMatrix(locations []PatternLocation) *SimilarityMatrix {
+	n := len(locations)
+	m := &SimilarityMatrix{
+		Locations:    make([]string, n),
+		Similarities: make([]float64, n*(n-1)/2),
+	}
+
+	// Tokenize all patterns; renamings are always read from the source tokens
+	tokenized := make([][]string, n)
+	compared := tokenized
+	_, ownTokens := activeStrategy.(SimilarityTokenizer)
+	if ownTokens {
+		compared = make([][]string, n)
+	}
+	for i, loc := range locations {
+		m.Locations[i] = locationKey(loc)
+		lang := languageFor(loc.Filename)
+		tokenized[i] = tokenizePattern(loc.Pattern, &lang)
+		if ownTokens {
+			compared[i] = similarityTokens(loc.Pattern, &lang)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			p := pairIndex(i, j, n)
+			m.Similarities[p] = tokenSimilarity(compared[i], compared[j])
+			// The same code modulo renaming belongs together even when token overlap is low
+			if subs, renamed := consistentRenaming(tokenized[i], tokenized[j]); renamed {
+				if m.Renamings == nil {
+					m.Renamings = make(map[int][]Substitution)
+				}
+				m.Renamings[p] = subs
+			}
+		}
+	}
+	return m
+}
+
+// clusterBySimilarity groups locations (in sortedLocations order, with their matrix)
+// into clusters where all members have >= threshold similarity, or are the same code
+// with a few identifiers consistently renamed (see consistentRenaming)
+// Returns clusters sorted by size (largest first)
+func clusterBySimilarity(locations []PatternLocation, matrix *SimilarityMatrix, threshold float64) []ClusterResult {
+	n := len(locations)
+	if n < 2 {
+		return []ClusterResult{{Locations: locations, Similarity: 1.0, MemberSimilarity: []float64{1.0}}}
+	}
+
+	// Build clusters using Union-Find
+	uf := NewUnionFind(n)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			p := pairIndex(i, j, n)
+			if _, renamed := matrix.Renamings[p]; renamed || matrix.Similarities[p] >= threshold {
+				uf.Union(i, j)
+			}
+		}
+	}
+
+	// Group locations by cluster root
+	clusterMap := make(map[int][]int)
+	for i := 0; i < n; i++ {
+		root := uf.Find(i)
+		clusterMap[root] = append(clusterMap[root], i)
+	}
+
+	// Build cluster results with similarity scores
+	var results []ClusterResult
+	for _, indices := range clusterMap {
+		// Order members deterministically so output is stable run to run
+		sort.Slice(indices, func(i, j int) bool {
+			return locationLess(locations[indices[i]], locations[indices[j]])
+		})
+		cluster := make([]PatternLocation, len(indices))
+		for i, idx := range indices {
+			cluster[i] = locations[idx]
+		}
+
+		// Compute average similarity within cluster, and per member
+		var totalSim float64
+		var pairs int
+		var substitutions []Substitution
+		memberSim := make([]float64, len(indices))
+		for i := 0; i < len(indices); i++ {
+			for j := i + 1; j < len(indices); j++ {
+				a, b := indices[i], indices[j]
+				if a > b {
+					a, b = b, a
+				}
+				p := pairIndex(a, b, n)
+				substitutions = mergeSubstitutions(substitutions, matrix.Renamings[p])
+				pairSim := matrix.Similarities[p]
+				totalSim += pairSim
+				memberSim[i] += pairSim
+				memberSim[j] += pairSim
+				pairs++
+			}
+		}
+
+		sortSubstitutions(substitutions)
+
+		sim := 1.0
+		if pairs > 0 {
+			sim = totalSim / float64(pairs)
+		}
+		for i := range memberSim {
+			if len(indices) > 1 {
+				memberSim[i] /= float64(len(indices) - 1)
+			} else {
+				memberSim[i] = 1.0
+			}
+		}
+
+		results = append(results, ClusterResult{
+			Locations:        cluster,
+			Similarity:       sim,
+			MemberSimilarity: memberSim,
+			Substitutions:    substitutions,
+		})
+	}
+
+	// Sort by cluster size (largest first), then by first location for stability
+	sort.Slice(results, func(i, j int) bool {
+		if len(results[i].Locations) != len(results[j].Locations) {
+			return len(results[i].Locations) > len(results[j].Locations)
+		}
+		return locationLess(results[i].Locations[0], results[j].Locations[0])
+	})
+
+	return results
+}
+
+// locationLess orders locations by filename, then line
+func locationLess(a, b PatternLocation) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	return a.LineStart < b.LineStart
+}
+
+// Representative selection modes (--representative)
+const (
+	RepresentativeMedoid = "medoid" // occurrence most similar to the rest of its cluster
+	RepresentativeFirst  = "first"  // lexicographically first by filename, then line
+)
+
+// representativeIndex picks the representative occurrence of a cluster.
+// Ties in medoid mode resolve to the earliest location, so the choice is deterministic.
+func representativeIndex(cluster ClusterResult, mode string) int {
+	if mode == RepresentativeFirst {
+		return 0
+	}
+	best := 0
+	for i, sim := range cluster.MemberSimilarity {
+		if sim > cluster.MemberSimilarity[best] {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReformattedCodeIsFullySimilar(t *testing.T) {
+	useStrategy(t, "normalized-indent")
+	tests := []struct {
+		name      string
+		original  string
+		reformed  string
+		identical bool
+	}{
+		{
+			"spacing around operators",
+			"func f() {\n\tx:=a+b*c\n\ty=x<<2|mask\n}\n",
+			"func f() {\n\tx := a + b * c\n\ty = x << 2 | mask\n}\n",
+			true,
+		},
+		{
+			"aligned assignments",
+			"func f() {\n\tshort    = 1\n\tmuchLonger = 2\n}\n",
+			"func f() {\n\tshort = 1\n\tmuchLonger = 2\n}\n",
+			true,
+		},
+		{
+			"spacing inside calls and brackets",
+			"func f() {\n\tcall(a,b,items[i])\n}\n",
+			"func f() {\n\tcall( a, b, items[ i ] )\n}\n",
+			true,
+		},
+		{
+			"trailing whitespace and blank lines",
+			"func f() {\n\tx := 1\n\treturn x\n}\n",
+			"func f() {   \n\n\tx := 1\t\n\n\treturn x  \n}\n",
+			true,
+		},
+		{
+			"different code (control)",
+			"func f() {\n\tx := a + b\n}\n",
+			"func f() {\n\tx := a - c\n}\n",
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locations := []PatternLocation{
+				{Filename: "a.go", Pattern: parseSource(t, "a.go", tt.original)},
+				{Filename: "b.go", Pattern: parseSource(t, "b.go", tt.reformed)},
+			}
+			for name, metric := range similarityMetrics {
+				prev := tokenSimilarity
+				tokenSimilarity = metric
+				got := computeAverageTokenSimilarity(locations)
+				tokenSimilarity = prev
+				if (got == 1.0) != tt.identical {
+					t.Errorf("%s similarity = %v, want 1.0 only for identical tokens (identical: %v)", name, got, tt.identical)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenizeCodeStringLiterals(t *testing.T) {
+	tests := []struct {
+		name            string
+		file            string
+		line            string
+		caseInsensitive bool
+		want            []string
+	}{
+		{"doubled quote escapes in SQL", "q.sql", "x = 'it''s' + y", false, []string{"x", "'it''s'", "+", "y"}},
+		{"doubled quote closes in Go", "a.go", `x = "a""b"`, false, []string{"x", `"a"`, `"b"`}},
+		{"literal kept verbatim", "a.go", `X = "Hello"`, false, []string{"X", `"Hello"`}},
+		{"literal lowercased with -case-insensitive", "a.go", `X = "Hello"`, true, []string{"x", `"hello"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := caseInsensitive
+			caseInsensitive = tt.caseInsensitive
+			defer func() { caseInsensitive = prev }()
+			lang := languageFor(tt.file)
+			got := tokenizeCode(tt.line, &lang)
+			if strings.Join(got, " | ") != strings.Join(tt.want, " | ") {
+				t.Errorf("tokenizeCode(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
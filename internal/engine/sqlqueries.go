@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// SQLLocation is where a query literal starts
+type SQLLocation struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+}
+
+// SQLDuplicate is a query embedded as a string literal in several places. Queries are
+// compared after normalizeSQL, so formatting differences don't hide duplicates.
+type SQLDuplicate struct {
+	Hash      uint64
+	Query     string // normalized query text
+	Locations []SQLLocation
+}
+
+// sqlLiteral is a string literal, with "a" + "b" concatenations joined
+type sqlLiteral struct {
+	line int
+	text string
+}
+
+// sqlStatementKeywords maps statement-opening keywords to a keyword that must also
+// appear, which keeps prose like "Update the record" from looking like SQL
+var sqlStatementKeywords = map[string]string{
+	"SELECT": "FROM",
+	"INSERT": "INTO",
+	"UPDATE": "SET",
+	"DELETE": "FROM",
+	"WITH":   "AS",
+}
+
+// sqlKeywords are uppercased during normalization
+var sqlKeywords = map[string]bool{
+	"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "WITH": true,
+	"FROM": true, "WHERE": true, "INTO": true, "VALUES": true, "SET": true, "AS": true,
+	"AND": true, "OR": true, "NOT": true, "NULL": true, "IS": true, "IN": true,
+	"LIKE": true, "BETWEEN": true, "EXISTS": true, "DISTINCT": true, "ALL": true,
+	"JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "OUTER": true,
+	"CROSS": true, "ON": true, "USING": true, "GROUP": true, "ORDER": true, "BY": true,
+	"HAVING": true, "LIMIT": true, "OFFSET": true, "ASC": true, "DESC": true,
+	"UNION": true, "CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true,
+	"RETURNING": true, "COUNT": true, "SUM": true, "MIN": true, "MAX": true, "AVG": true,
+}
+
+// findDuplicateQueries extracts SQL-looking string literals from files and groups
+// those whose normalized text is identical, keeping groups with at least minOccur
+// occurrences. Unreadable files are recorded as warnings.
+func findDuplicateQueries(files []string, minOccur int, warnings *Warnings) []SQLDuplicate {
+	byQuery := make(map[string][]SQLLocation)
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			warnings.Add("sql", path, err)
+			continue
+		}
+		for _, lit := range extractStringLiterals(string(content)) {
+			query := normalizeSQL(lit.text)
+			if !looksLikeSQL(query) {
+				continue
+			}
+			byQuery[query] = append(byQuery[query], SQLLocation{Filename: path, Line: lit.line})
+		}
+	}
+
+	var dups []SQLDuplicate
+	for query, locs := range byQuery {
+		if len(locs) < minOccur {
+			continue
+		}
+		h := newWindowHash()
+		h.Write([]byte(query))
+		dups = append(dups, SQLDuplicate{Hash: h.Sum64(), Query: query, Locations: locs})
+	}
+	sort.Slice(dups, func(i, j int) bool {
+		if len(dups[i].Locations) != len(dups[j].Locations) {
+			return len(dups[i].Locations) > len(dups[j].Locations)
+		}
+		return dups[i].Query < dups[j].Query
+	})
+	return dups
+}
+
+// extractStringLiterals scans source for "..", '..', `..` and """..""" literals,
+// skipping // and /* */ comments. Single-line quotes end at a newline so stray
+// apostrophes (e.g. in # comments) can't swallow the rest of the file.
+func extractStringLiterals(content string) []sqlLiteral {
+	var literals []sqlLiteral
+	line := 1
+	lastEnd := -1 // end offset of the previous literal, for joining concatenations
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			i-- // let the newline be counted
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				return literals
+			}
+			line += strings.Count(content[i:i+2+end], "\n")
+			i += end + 3
+		case c == '"' || c == '\'' || c == '`':
+			start, startLine := i, line
+			text, next, ok := scanLiteral(content, i)
+			line += strings.Count(content[i:next], "\n")
+			i = next - 1
+			if !ok {
+				continue
+			}
+			if lastEnd >= 0 && len(literals) > 0 && strings.TrimSpace(content[lastEnd:start]) == "+" {
+				literals[len(literals)-1].text += text
+			} else {
+				literals = append(literals, sqlLiteral{line: startLine, text: text})
+			}
+			lastEnd = next
+		}
+	}
+	return literals
+}
+
+// scanLiteral reads the literal opening at content[i], returning its text, the offset
+// just past it, and whether it was properly terminated
+func scanLiteral(content string, i int) (string, int, bool) {
+	quote := content[i]
+	if quote == '"' && strings.HasPrefix(content[i:], `"""`) {
+		end := strings.Index(content[i+3:], `"""`)
+		if end < 0 {
+			return "", len(content), false
+		}
+		return content[i+3 : i+3+end], i + 6 + end, true
+	}
+
+	var b strings.Builder
+	for j := i + 1; j < len(content); j++ {
+		c := content[j]
+		switch {
+		case c == quote:
+			return b.String(), j + 1, true
+		case c == '\n' && quote != '`':
+			return "", j, false
+		case c == '\\' && quote != '`' && j+1 < len(content):
+			j++
+			switch content[j] {
+			case 'n', 't', 'r':
+				b.WriteByte(' ')
+			default:
+				b.WriteByte(content[j])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", len(content), false
+}
+
+// normalizeSQL collapses whitespace, spaces out punctuation, uppercases keywords and
+// drops a trailing semicolon, so differently formatted copies compare equal
+func normalizeSQL(query string) string {
+	query = strings.NewReplacer("(", " ( ", ")", " ) ", ",", " , ", "=", " = ", ";", " ; ").Replace(query)
+	fields := strings.Fields(query)
+	for len(fields) > 0 && fields[len(fields)-1] == ";" {
+		fields = fields[:len(fields)-1]
+	}
+	for i, f := range fields {
+		if upper := strings.ToUpper(f); sqlKeywords[upper] {
+			fields[i] = upper
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// looksLikeSQL reports whether a normalized literal starts like a SQL statement
+func looksLikeSQL(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) < 3 {
+		return false
+	}
+	required, ok := sqlStatementKeywords[fields[0]]
+	if !ok {
+		return false
+	}
+	for _, f := range fields[1:] {
+		if f == required {
+			return true
+		}
+	}
+	return false
+}
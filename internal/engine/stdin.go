@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// stdinName is the filename content read with --stdin is reported under; its extension,
+// the first scanned one, picks the language rules
+func stdinName(extension string) string {
+	name := "<stdin>"
+	if exts := splitExtensions(extension); len(exts) > 0 {
+		name += exts[0]
+	}
+	return name
+}
+
+// runStdin finds duplicates within the content of standard input (--stdin), for editors
+// checking an unsaved buffer, and writes the results document to w. Nothing is cached
+// or written to .quickdup.
+func runStdin(w io.Writer, c ScanConfig) error {
+	start := time.Now()
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return &ScanError{Op: "read", Path: "stdin", Err: err}
+	}
+
+	name := stdinName(c.Extension)
+	parseStart := time.Now()
+	entries, suppressed, err := parseContent(name, data)
+	if err != nil {
+		return &ScanError{Op: "parse", Path: name, Err: err}
+	}
+	parseTime := time.Since(parseStart)
+	fileData := map[string][]Entry{name: entries}
+
+	suppressions := &Suppressions{}
+	suppressions.Add(name, suppressed)
+	filter := c.Filter
+	filter.Suppressed = suppressions
+	matches, detectTime, filterTime := c.detectAndFilter(fileData, filter)
+
+	return writeJSONResults(w, matches, ResultsExtras{
+		Config: c.Config,
+		Timings: &Timings{
+			ParseMs:  parseTime.Milliseconds(),
+			DetectMs: detectTime.Milliseconds(),
+			FilterMs: filterTime.Milliseconds(),
+			TotalMs:  time.Since(start).Milliseconds(),
+			Files:    1,
+			Lines:    len(entries),
+		},
+	})
+}
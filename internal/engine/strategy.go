@@ -0,0 +1,92 @@
+package engine
+
+import "math"
+
+// Strategy defines how patterns are detected and scored
+type Strategy interface {
+	Name() string
+	Preparse(content string, lang *Language) string
+	ParseLine(lineNum int, line string, prevEntry Entry, lang *Language) (Entry, bool) // returns entry and whether to skip; lang is the file's language
+	Hash(entries []Entry) uint64
+	Signature(entries []Entry) string
+	Score(entries []Entry, similarity float64) int // 0 rejects the pattern regardless of -min-score
+	BlockedHashes() map[uint64]bool                // returns hashes of patterns to ignore
+}
+
+// SimilarityTokenizer is implemented by strategies whose occurrences are compared by
+// other tokens than those of their source lines (see similarityTokens)
+type SimilarityTokenizer interface {
+	SimilarityTokens(pattern []Entry) []string
+}
+
+// newStrategies returns an instance of every strategy, by name
+func newStrategies() map[string]Strategy {
+	return map[string]Strategy{
+		"word-indent":       &WordIndentStrategy{},
+		"normalized-indent": &NormalizedIndentStrategy{},
+		"word-only":         &WordOnlyStrategy{},
+		"inlineable":        &InlineableStrategy{},
+		"error-handling":    &ErrorHandlingStrategy{},
+		"ci-config":         &CIConfigStrategy{},
+		"comments":          &CommentsStrategy{},
+		"token-normalized":  &TokenNormalizedStrategy{},
+	}
+}
+
+// Preparser transforms file content before parsing
+type Preparser interface {
+	Preparse(content string) string
+}
+
+// similarityFloor is the similarity treated as noise by scoring (set from
+// --similarity-floor); see adjustedSimilarity
+var similarityFloor = 0.5
+
+// adjustedSimilarity rescales similarity so the noise floor maps to 0 and a verbatim
+// copy to 1, clamping below the floor: with the default floor 0.5, 75% becomes 0.5
+func adjustedSimilarity(similarity float64) float64 {
+	adjusted := (similarity - similarityFloor) / (1 - similarityFloor)
+	if adjusted < 0 {
+		return 0
+	}
+	return adjusted
+}
+
+// Score presets (--score-preset)
+const (
+	ScorePresetConservative = "conservative" // near-verbatim copies only
+	ScorePresetBalanced     = "balanced"     // the strategies' own formulas
+	ScorePresetAggressive   = "aggressive"   // looser copies, and code repeated in many places
+)
+
+// ScorePreset tunes the parts of scoring every strategy shares
+type ScorePreset struct {
+	SimilarityExponent float64 // power of the adjusted similarity (see similarityFactor)
+	OccurrenceWeight   float64 // points per occurrence beyond the second (see weightOccurrences)
+}
+
+// scorePresets by name
+var scorePresets = map[string]ScorePreset{
+	ScorePresetConservative: {SimilarityExponent: 5},
+	ScorePresetBalanced:     {SimilarityExponent: 3},
+	ScorePresetAggressive:   {SimilarityExponent: 1.5, OccurrenceWeight: 1},
+}
+
+// scorePreset is the active preset (set from --score-preset)
+var scorePreset = scorePresets[ScorePresetBalanced]
+
+// similarityFactor is the share of its words a pattern scores at a similarity: the
+// adjusted similarity raised to the preset's exponent. Balanced cubes it, heavily
+// rewarding high similarity: 100%=1.0, 95%=0.73, 90%=0.51, 85%=0.34 (default floor)
+func similarityFactor(similarity float64) float64 {
+	return math.Pow(adjustedSimilarity(similarity), scorePreset.SimilarityExponent)
+}
+
+// weightOccurrences adds the preset's bonus for occurrences beyond the second to a
+// strategy's score; a 0 score stays a rejection
+func weightOccurrences(score, occurrences int) int {
+	if score <= 0 || occurrences <= 2 {
+		return score
+	}
+	return score + int(scorePreset.OccurrenceWeight*float64(occurrences-2))
+}
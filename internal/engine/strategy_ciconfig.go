@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CIConfigStrategy finds duplicated build and CI configuration: repeated GitHub
+// Actions/GitLab steps in .yml/.yaml files and repeated Makefile targets. Lines are
+// keyed by their YAML key (`- name`, `run`, `with`) or Makefile role (target, recipe
+// command) instead of their first word, so stanzas match whatever their names and
+// values; similarity then tells near-copies from merely same-shaped ones.
+type CIConfigStrategy struct {
+	NormalizedIndentStrategy
+}
+
+// makeTargetWord is the word every Makefile target line parses to, so targets with
+// different names still match
+const makeTargetWord = "target:"
+
+// configStepWords are the words that start a stanza: a CI step or a Makefile target
+var configStepWords = map[string]bool{
+	"- name": true, "- uses": true, "- run": true, makeTargetWord: true,
+}
+
+func (s *CIConfigStrategy) Name() string {
+	return "ci-config"
+}
+
+// Preparse leaves content as-is: neither YAML nor Makefiles have block comments, and
+// `/*` is a common glob in both
+func (s *CIConfigStrategy) Preparse(content string, lang *Language) string {
+	return content
+}
+
+func (s *CIConfigStrategy) ParseLine(lineNum int, line string, prevEntry Entry, lang *Language) (Entry, bool) {
+	e, skip := s.NormalizedIndentStrategy.ParseLine(lineNum, line, prevEntry, lang)
+	if skip {
+		return nil, true
+	}
+	entry := e.(*NormalizedIndentEntry)
+	entry.Word = configWord(line, lang)
+	entry.hashBytes = []byte(fmt.Sprintf("%d|%s\n", entry.IndentDelta, entry.Word))
+	return entry, false
+}
+
+func (s *CIConfigStrategy) Score(entries []Entry, similarity float64) int {
+	seen := make(map[string]bool)
+	steps := 0
+	for _, e := range entries {
+		word := e.(*NormalizedIndentEntry).Word
+		seen[word] = true
+		if configStepWords[word] {
+			steps++
+		}
+	}
+	if steps == 0 {
+		return 0 // a run of keys inside one stanza, not a duplicated stanza
+	}
+
+	// No shape imbalance: stanzas have no closing lines. Step names and values
+	// legitimately differ between copies, which keeps similarity low, so steps count
+	// on top of the cubed-similarity word score.
+	simFactor := similarityFactor(similarity)
+	return int(float64(len(seen))*simFactor) + int(float64(3*steps)*similarity) + len(entries)/20
+}
+
+// configWord returns the unit a configuration line is matched by
+func configWord(line string, lang *Language) string {
+	if isMakefile(lang.Ext) {
+		if strings.HasPrefix(line, "\t") {
+			// Recipe line: the command, without the echo/ignore-error prefixes
+			if fields := strings.Fields(strings.TrimLeft(strings.TrimSpace(line), "@-+")); len(fields) > 0 {
+				return fields[0]
+			}
+		}
+		if _, ok := makeTargets(line); ok {
+			return makeTargetWord
+		}
+		return extractFirstWord(line)
+	}
+
+	trimmed := strings.TrimSpace(line)
+	prefix := ""
+	if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+		prefix = "- "
+		trimmed = strings.TrimSpace(trimmed[1:])
+	}
+	if key, ok := yamlKey(trimmed); ok {
+		return prefix + key
+	}
+	if prefix != "" {
+		return "-" // list item without a key
+	}
+	return extractFirstWord(line)
+}
+
+// yamlKey returns the key of a `key: value` or `key:` line
+func yamlKey(trimmed string) (string, bool) {
+	i := strings.Index(trimmed, ":")
+	if i <= 0 || (i+1 < len(trimmed) && trimmed[i+1] != ' ') {
+		return "", false // no key, or a colon inside a value such as a URL
+	}
+	key := strings.Trim(trimmed[:i], `"'`)
+	if strings.ContainsAny(key, " \t") {
+		return "", false
+	}
+	return key, true
+}
+
+// isMakefile reports whether a file type (see fileType) is a Makefile
+func isMakefile(ext string) bool {
+	return ext == "makefile" || ext == ".mk" || ext == ".make"
+}
+
+// makeTargets returns the target names of a Makefile rule line (`build test: deps`),
+// or false for recipes, variable assignments and special targets like .PHONY
+func makeTargets(line string) ([]string, bool) {
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '.' {
+		return nil, false
+	}
+	i := strings.Index(line, ":")
+	if i <= 0 || strings.ContainsAny(line[:i], "=") || strings.HasPrefix(line[i:], ":=") || strings.HasPrefix(line[i:], "::=") {
+		return nil, false
+	}
+	targets := strings.Fields(line[:i])
+	return targets, len(targets) > 0
+}
+
+// stanzaSteps returns the names of the CI steps and Makefile targets in a pattern, in
+// order, so a report says which stanza was duplicated
+func stanzaSteps(entries []Entry) []string {
+	var steps []string
+	for _, e := range entries {
+		entry, ok := e.(*NormalizedIndentEntry)
+		if !ok {
+			continue
+		}
+		switch entry.Word {
+		case "- name":
+			_, value, _ := strings.Cut(entry.SourceLine, ":")
+			if name := strings.Trim(strings.TrimSpace(value), `"'`); name != "" {
+				steps = append(steps, name)
+			}
+		case makeTargetWord:
+			targets, _ := makeTargets(entry.SourceLine)
+			steps = append(steps, strings.Join(targets, " "))
+		}
+	}
+	return steps
+}
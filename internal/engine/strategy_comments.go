@@ -0,0 +1,55 @@
+package engine
+
+import "strings"
+
+// CommentsStrategy is the inverse of the code strategies: it parses only comment
+// lines, to find copy-pasted documentation and license or usage boilerplate that
+// drifts and could reference a shared doc instead. Lines are matched by the first
+// word of their text, ignoring indentation, since the same doc comment often sits at
+// different depths.
+type CommentsStrategy struct {
+	WordOnlyStrategy
+}
+
+func (s *CommentsStrategy) Name() string {
+	return "comments"
+}
+
+// Preparse leaves block comments in place: they're what this strategy parses
+func (s *CommentsStrategy) Preparse(content string, lang *Language) string {
+	return content
+}
+
+func (s *CommentsStrategy) ParseLine(lineNum int, line string, prevEntry Entry, lang *Language) (Entry, bool) {
+	text, ok := commentText(line, lang)
+	if !ok {
+		return nil, true // code, or a comment line without text
+	}
+	word := extractFirstWord(text)
+	return &WordOnlyEntry{
+		LineNumber: lineNum,
+		Word:       word,
+		SourceLine: line,
+		hashBytes:  []byte(word + "\n"),
+	}, false
+}
+
+func (s *CommentsStrategy) BlockedHashes() map[uint64]bool {
+	return make(map[uint64]bool) // the code strategies' blocklists don't apply to prose
+}
+
+// commentText returns the text of a comment-only line without its comment markers.
+// Besides the language's line comment prefix, C-style languages' block comment
+// lines (/*, *, */) count, so Javadoc and /** */ doc blocks are included.
+func commentText(line string, lang *Language) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	switch prefix := lang.CommentPrefix; {
+	case prefix != "" && strings.HasPrefix(trimmed, prefix):
+		trimmed = trimmed[len(prefix):]
+	case prefix == "//" && (strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*")):
+	default:
+		return "", false
+	}
+	text := strings.TrimSpace(strings.Trim(trimmed, "/*#;%!-"))
+	return text, text != ""
+}
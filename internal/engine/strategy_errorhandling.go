@@ -0,0 +1,147 @@
+package engine
+
+import "strings"
+
+// ErrorHandlingStrategy reports duplicated multi-line error handling: an error check
+// (if err != nil, catch, except, rescue) whose block does more than return or rethrow,
+// e.g. logging, wrapping or cleanup before bailing out. Only error-handling blocks are
+// parsed; patterns are then found like normalized-indent, with tailored scoring.
+type ErrorHandlingStrategy struct {
+	NormalizedIndentStrategy
+}
+
+// errorHandlerWords open an error-handling block on their own
+var errorHandlerWords = map[string]bool{
+	"catch": true, "except": true, "rescue": true, "recover": true,
+}
+
+// errorConditionWords open an error check when the condition mentions an error
+var errorConditionWords = map[string]bool{
+	"if": true, "unless": true, "elif": true, "when": true,
+}
+
+// minErrorBodyLines is the smallest non-trivial handler body: the bail-out plus at
+// least one line of logging, wrapping or cleanup
+const minErrorBodyLines = 2
+
+func (s *ErrorHandlingStrategy) Name() string {
+	return "error-handling"
+}
+
+// Preparse blanks every line outside error-handling blocks, so patterns consist of
+// handlers only rather than the code around them
+func (s *ErrorHandlingStrategy) Preparse(content string, lang *Language) string {
+	lines := strings.Split(s.NormalizedIndentStrategy.Preparse(content, lang), "\n")
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if isWhitespaceOnly(line) || isCommentOnly(line, lang) || !isErrorCheck(line) {
+			continue
+		}
+		keep[i] = true
+		indent := calculateIndent(line)
+		j := i + 1
+		if j < len(lines) && strings.TrimSpace(lines[j]) == "{" {
+			keep[j] = true // brace on its own line
+			j++
+		}
+		for ; j < len(lines); j++ {
+			if isWhitespaceOnly(lines[j]) {
+				continue
+			}
+			lineIndent := calculateIndent(lines[j])
+			if lineIndent > indent {
+				keep[j] = true
+				continue
+			}
+			if trimmed := strings.TrimSpace(lines[j]); lineIndent == indent && (strings.HasPrefix(trimmed, "}") || trimmed == "end") {
+				keep[j] = true // closing line
+			}
+			break
+		}
+	}
+	for i := range lines {
+		if !keep[i] {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s *ErrorHandlingStrategy) Score(entries []Entry, similarity float64) int {
+	body, ok := errorHandlerBody(entries)
+	if !ok || len(body) < minErrorBodyLines {
+		return 0 // not error handling, or just `return err`
+	}
+
+	// Distinct words in the handler, so varied logging/wrapping outranks repetition
+	seen := make(map[string]bool)
+	for _, e := range body {
+		seen[e.(*NormalizedIndentEntry).Word] = true
+	}
+
+	simFactor := similarityFactor(similarity)
+	return int(float64(len(seen)+len(body))*simFactor) + len(entries)/20
+}
+
+// errorHandlerBody returns the non-brace lines of the block opened by a pattern's first
+// line, or false if the pattern doesn't start with an error check
+func errorHandlerBody(entries []Entry) ([]Entry, bool) {
+	if len(entries) == 0 || !isErrorCheck(entries[0].GetRaw()) {
+		return nil, false
+	}
+	var body []Entry
+	depth := 0
+	for j, e := range entries[1:] {
+		raw := strings.TrimSpace(e.GetRaw())
+		depth += e.(*NormalizedIndentEntry).IndentDelta
+		if depth <= 0 {
+			if j == 0 && raw == "{" {
+				continue // brace on its own line
+			}
+			break // back at the check's level: the block is over
+		}
+		if strings.Trim(raw, "{}();") != "" {
+			body = append(body, e)
+		}
+	}
+	return body, true
+}
+
+// isErrorCheck reports whether a line opens an error-handling block: a handler keyword
+// (also after a closing brace, as in `} catch (e) {`) or a condition on an error value
+func isErrorCheck(line string) bool {
+	tokens := tokenizeLine(strings.ToLower(line)) // braces separate, so `}` is dropped
+	if len(tokens) == 0 {
+		return false
+	}
+	if errorHandlerWords[tokens[0]] {
+		return true
+	}
+	if !errorConditionWords[tokens[0]] {
+		return false
+	}
+	for _, t := range tokens[1:] {
+		if t == "e" || t == "ex" || strings.Contains(t, "err") || strings.Contains(t, "exception") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ErrorHandlingStrategy) BlockedHashes() map[uint64]bool {
+	blocked := s.NormalizedIndentStrategy.BlockedHashes()
+
+	// The trivial checks: if err != nil { return err }, catch { throw }, except: raise
+	for _, delta := range []int{-1, 0, 1} {
+		for _, check := range []string{"if", "catch", "except", "rescue"} {
+			for _, bail := range []string{"return", "throw", "raise"} {
+				blocked[s.Hash([]Entry{
+					NewNormalizedIndentEntry(delta, check),
+					NewNormalizedIndentEntry(1, bail),
+					NewNormalizedIndentEntry(-1, "}"),
+				})] = true
+			}
+		}
+	}
+	return blocked
+}
@@ -1,7 +1,6 @@
-package main
+package engine
 
 import (
-	"hash/fnv"
 	"strings"
 )
 
@@ -33,16 +32,17 @@ func (s *InlineableStrategy) Name() string {
 	return "inlineable"
 }
 
-func (s *InlineableStrategy) Preparse(content string) string {
+func (s *InlineableStrategy) Preparse(content string, lang *Language) string {
 	return cStyleStripper.Preparse(content)
 }
 
-func (s *InlineableStrategy) ParseLine(lineNum int, line string, prevEntry Entry) (Entry, bool) {
-	if isWhitespaceOnly(line) || isCommentOnly(line) || shouldSkipByFirstWord(line) {
+func (s *InlineableStrategy) ParseLine(lineNum int, line string, prevEntry Entry, lang *Language) (Entry, bool) {
+	if isWhitespaceOnly(line) || isCommentOnly(line, lang) || shouldSkipByFirstWord(line, lang) {
 		return nil, true // skip
 	}
 
-	word := extractFirstWord(line)
+	// Keeps modifiers even under --ignore-modifiers: they're what this strategy looks for
+	word := firstWord(line, false)
 	hashBytes := []byte(word + "\n")
 
 	entry := &InlineableEntry{
@@ -55,11 +55,7 @@ func (s *InlineableStrategy) ParseLine(lineNum int, line string, prevEntry Entry
 }
 
 func (s *InlineableStrategy) Hash(entries []Entry) uint64 {
-	h := fnv.New64a()
-	for _, e := range entries {
-		h.Write(e.HashBytes())
-	}
-	return h.Sum64()
+	return hashEntries(entries)
 }
 
 func (s *InlineableStrategy) Signature(entries []Entry) string {
@@ -109,10 +105,7 @@ func (s *InlineableStrategy) Score(entries []Entry, similarity float64) int {
 
 	// High score for inlineable patterns
 	// Base score of 50, plus similarity bonus
-	adjustedSim := similarity*2 - 1.0
-	if adjustedSim < 0 {
-		adjustedSim = 0
-	}
+	adjustedSim := adjustedSimilarity(similarity)
 
 	return 50 + int(adjustedSim*50)
 }
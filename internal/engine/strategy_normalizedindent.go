@@ -1,8 +1,7 @@
-package main
+package engine
 
 import (
 	"fmt"
-	"hash/fnv"
 	"strings"
 )
 
@@ -36,12 +35,12 @@ func (s *NormalizedIndentStrategy) Name() string {
 	return "normalized-indent"
 }
 
-func (s *NormalizedIndentStrategy) Preparse(content string) string {
+func (s *NormalizedIndentStrategy) Preparse(content string, lang *Language) string {
 	return cStyleStripper.Preparse(content)
 }
 
-func (s *NormalizedIndentStrategy) ParseLine(lineNum int, line string, prevEntry Entry) (Entry, bool) {
-	if isWhitespaceOnly(line) || isCommentOnly(line) || shouldSkipByFirstWord(line) {
+func (s *NormalizedIndentStrategy) ParseLine(lineNum int, line string, prevEntry Entry, lang *Language) (Entry, bool) {
+	if isWhitespaceOnly(line) || isCommentOnly(line, lang) || shouldSkipByFirstWord(line, lang) {
 		return nil, true // skip
 	}
 
@@ -77,11 +76,7 @@ func (s *NormalizedIndentStrategy) ParseLine(lineNum int, line string, prevEntry
 }
 
 func (s *NormalizedIndentStrategy) Hash(entries []Entry) uint64 {
-	h := fnv.New64a()
-	for _, e := range entries {
-		h.Write(e.HashBytes())
-	}
-	return h.Sum64()
+	return hashEntries(entries)
 }
 
 func (s *NormalizedIndentStrategy) Signature(entries []Entry) string {
@@ -95,12 +90,20 @@ func (s *NormalizedIndentStrategy) Signature(entries []Entry) string {
 
 func (s *NormalizedIndentStrategy) Score(entries []Entry, similarity float64) int {
 	seen := make(map[string]bool)
+	for _, e := range entries {
+		seen[e.(*NormalizedIndentEntry).Word] = true
+	}
+
+	// Leading lines that only close blocks finish the code before the pattern; they
+	// don't make the block that follows unbalanced
+	start := 0
+	for start < len(entries)-1 && isClosingLine(entries[start].GetRaw()) {
+		start++
+	}
 	running := 0
 	minRunning := 0
-	for _, e := range entries {
-		entry := e.(*NormalizedIndentEntry)
-		seen[entry.Word] = true
-		running += entry.IndentDelta
+	for _, e := range entries[start:] {
+		running += e.(*NormalizedIndentEntry).IndentDelta
 		if running < minRunning {
 			minRunning = running
 		}
@@ -120,13 +123,7 @@ func (s *NormalizedIndentStrategy) Score(entries []Entry, similarity float64) in
 		effectiveWords = 0
 	}
 
-	adjustedSim := similarity*2 - 1.0
-	if adjustedSim < 0 {
-		adjustedSim = 0
-	}
-	// Cube similarity factor - heavily rewards high similarity
-	// 100%=1.0, 95%=0.73, 90%=0.51, 85%=0.34
-	simFactor := adjustedSim * adjustedSim * adjustedSim
+	simFactor := similarityFactor(similarity)
 	return int(float64(effectiveWords)*simFactor) + len(entries)/20
 }
 
@@ -158,3 +155,9 @@ func (s *NormalizedIndentStrategy) BlockedHashes() map[uint64]bool {
 
 	return blocked
 }
+
+// isClosingLine reports whether a line only closes blocks: }, }), ], end, ...
+func isClosingLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.Trim(trimmed, "})];,") == "" || trimmed == "end"
+}
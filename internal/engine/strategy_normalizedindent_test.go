@@ -0,0 +1,53 @@
+package engine
+
+import "testing"
+
+func TestNormalizedIndentScoreLeadingDedents(t *testing.T) {
+	useStrategy(t, "normalized-indent")
+	src := `func a() {
+	for {
+		if x {
+			y()
+		}
+	}
+}
+func b() {
+	return c
+}
+`
+	entries := parseSource(t, "a.go", src)
+	lines := func(from, to int) []Entry {
+		var pattern []Entry
+		for _, e := range entries {
+			if n := e.GetLineNumber(); n >= from && n <= to {
+				pattern = append(pattern, e)
+			}
+		}
+		return pattern
+	}
+
+	tests := []struct {
+		name     string
+		pattern  []Entry
+		like     []Entry // scores the same, when set
+		positive bool
+	}{
+		{"block after closing braces", lines(5, 10), lines(8, 10), true},
+		{"block after one closing brace", lines(7, 10), lines(8, 10), true},
+		{"closing braces after the block's content (control)", lines(4, 7), nil, false},
+	}
+	s := activeStrategy
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.Score(tt.pattern, 1.0)
+			if (got > 0) != tt.positive {
+				t.Errorf("Score = %d, want positive: %v", got, tt.positive)
+			}
+			if tt.like != nil {
+				if want := s.Score(tt.like, 1.0); got != want {
+					t.Errorf("Score = %d, want %d like the block without its leading closing lines", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Placeholders of token-normalized lines: every identifier reads identifierPlaceholder,
+// and every string literal stringPlaceholder when hashing
+const (
+	identifierPlaceholder = "ID"
+	stringPlaceholder     = "STR"
+)
+
+// tokenKeywords are kept by the token-normalized strategy: the keywords and builtin
+// types of common languages. Any other word is an identifier.
+var tokenKeywords = map[string]bool{
+	// Control flow
+	"if": true, "else": true, "elif": true, "elsif": true, "unless": true, "for": true, "foreach": true,
+	"while": true, "do": true, "loop": true, "until": true, "switch": true, "case": true, "default": true,
+	"match": true, "when": true, "break": true, "continue": true, "return": true, "goto": true,
+	"yield": true, "await": true, "async": true, "try": true, "catch": true, "except": true,
+	"finally": true, "throw": true, "throws": true, "raise": true, "rescue": true, "ensure": true,
+	"defer": true, "go": true, "select": true, "range": true, "in": true, "of": true, "then": true,
+	"end": true, "begin": true, "with": true, "using": true, "lock": true, "pass": true,
+	// Declarations
+	"func": true, "function": true, "fn": true, "def": true, "lambda": true, "var": true, "let": true,
+	"const": true, "val": true, "mut": true, "type": true, "struct": true, "class": true,
+	"interface": true, "enum": true, "trait": true, "impl": true, "record": true, "object": true,
+	"package": true, "namespace": true, "module": true, "import": true, "from": true, "export": true,
+	"extends": true, "implements": true, "static": true, "final": true, "abstract": true,
+	"virtual": true, "override": true, "public": true, "private": true, "protected": true,
+	"internal": true, "readonly": true, "new": true, "delete": true, "map": true, "chan": true,
+	// Operators and values
+	"and": true, "or": true, "not": true, "is": true, "as": true, "instanceof": true, "typeof": true,
+	"sizeof": true, "nil": true, "null": true, "none": true, "None": true, "undefined": true,
+	"true": true, "false": true, "True": true, "False": true, "this": true, "self": true, "super": true,
+	// Builtin types
+	"int": true, "uint": true, "long": true, "short": true, "byte": true, "char": true, "float": true,
+	"double": true, "bool": true, "boolean": true, "string": true, "void": true, "any": true,
+	"error": true, "int32": true, "int64": true, "uint32": true, "uint64": true, "float64": true,
+}
+
+// normalizeTokens tokenizes the code of a line with tokenizeLine and replaces
+// identifiers with identifierPlaceholder, keeping keywords, operators and numbers;
+// string literals are single tokens, quotes included. A line with no tokens, such as
+// a closing brace, is kept as is. lang tells how literals escape quotes.
+func normalizeTokens(line string, lang *Language) []string {
+	line = strings.TrimSpace(trimTrailingPunctuation(line))
+	var tokens []string
+	for len(line) > 0 {
+		start := strings.IndexAny(line, "\"'`")
+		code := line
+		if start >= 0 {
+			code = line[:start]
+		}
+		for _, t := range tokenizeLine(code) {
+			r, _ := utf8.DecodeRuneInString(t)
+			if (unicode.IsLetter(r) || r == '_' || r == '$') && !tokenKeywords[t] {
+				t = identifierPlaceholder
+			}
+			tokens = append(tokens, t)
+		}
+		if start < 0 {
+			break
+		}
+		end := stringLiteralEnd(line, start, lang)
+		tokens = append(tokens, literalToken(line[start:end]))
+		line = line[end:]
+	}
+	if len(tokens) == 0 && line != "" {
+		return []string{line}
+	}
+	return tokens
+}
+
+// stringLiteralEnd returns the end of the string literal opening at start, past its
+// closing quote; an unterminated literal runs to the end of the line. A quote escaped
+// with a backslash doesn't close it, nor does a doubled one in languages escaping
+// quotes that way (SQL, VB), and a triple-quoted string only ends at three quotes.
+func stringLiteralEnd(line string, start int, lang *Language) int {
+	quote := line[start]
+	if triple := strings.Repeat(line[start:start+1], 3); strings.HasPrefix(line[start:], triple) {
+		if end := strings.Index(line[start+3:], triple); end >= 0 {
+			return start + 3 + end + 3
+		}
+		return len(line)
+	}
+	for i := start + 1; i < len(line); i++ {
+		switch {
+		case line[i] == '\\':
+			i++
+		case line[i] == quote && i+1 < len(line) && line[i+1] == quote && lang != nil && lang.DoubledQuotes:
+			i++
+		case line[i] == quote:
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+// isStringLiteral reports whether a normalized token is a string literal
+func isStringLiteral(token string) bool {
+	return strings.ContainsAny(token[:1], "\"'`")
+}
+
+// TokenNormalizedEntry is the Entry implementation for token-normalized strategy
+// Tokens is the whole line with identifiers replaced by a placeholder; string
+// literals are hashed as another placeholder, but compared verbatim
+type TokenNormalizedEntry struct {
+	LineNumber int
+	Tokens     []string
+	SourceLine string
+	hashBytes  []byte
+}
+
+func (e *TokenNormalizedEntry) GetLineNumber() int { return e.LineNumber }
+func (e *TokenNormalizedEntry) GetRaw() string     { return e.SourceLine }
+func (e *TokenNormalizedEntry) HashBytes() []byte  { return e.hashBytes }
+
+// NewTokenNormalizedEntry creates a TokenNormalizedEntry with pre-computed hash bytes
+func NewTokenNormalizedEntry(tokens ...string) *TokenNormalizedEntry {
+	hashed := make([]string, len(tokens))
+	for i, t := range tokens {
+		hashed[i] = t
+		if isStringLiteral(t) {
+			hashed[i] = stringPlaceholder
+		}
+	}
+	return &TokenNormalizedEntry{
+		Tokens:    tokens,
+		hashBytes: []byte(strings.Join(hashed, " ") + "\n"),
+	}
+}
+
+// TokenNormalizedStrategy matches patterns by each line's token sequence with
+// identifiers normalized away, so copies with renamed variables, fields and functions
+// hash the same
+type TokenNormalizedStrategy struct{}
+
+func (s *TokenNormalizedStrategy) Name() string {
+	return "token-normalized"
+}
+
+func (s *TokenNormalizedStrategy) Preparse(content string, lang *Language) string {
+	return cStyleStripper.Preparse(content)
+}
+
+func (s *TokenNormalizedStrategy) ParseLine(lineNum int, line string, prevEntry Entry, lang *Language) (Entry, bool) {
+	if isWhitespaceOnly(line) || isCommentOnly(line, lang) || shouldSkipByFirstWord(line, lang) {
+		return nil, true // skip
+	}
+
+	entry := NewTokenNormalizedEntry(normalizeTokens(line, lang)...)
+	entry.LineNumber = lineNum
+	entry.SourceLine = line
+	return entry, false
+}
+
+func (s *TokenNormalizedStrategy) Hash(entries []Entry) uint64 {
+	return hashEntries(entries)
+}
+
+func (s *TokenNormalizedStrategy) Signature(entries []Entry) string {
+	var parts []string
+	for _, e := range entries {
+		parts = append(parts, strings.Join(e.(*TokenNormalizedEntry).Tokens, " "))
+	}
+	return strings.Join(parts, " ; ")
+}
+
+// SimilarityTokens compares occurrences by their normalized tokens, so renamed copies
+// are as similar as verbatim ones; differing literals still count, which keeps tables
+// of different data apart
+func (s *TokenNormalizedStrategy) SimilarityTokens(pattern []Entry) []string {
+	var tokens []string
+	for _, e := range pattern {
+		tokens = append(tokens, e.(*TokenNormalizedEntry).Tokens...)
+	}
+	return tokens
+}
+
+func (s *TokenNormalizedStrategy) Score(entries []Entry, similarity float64) int {
+	// Identifiers all read ID, so variety comes from keywords, operators and numbers;
+	// strings count once, or tables of them would score as highly varied code
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		for _, t := range e.(*TokenNormalizedEntry).Tokens {
+			if isStringLiteral(t) {
+				t = stringPlaceholder
+			}
+			seen[t] = true
+		}
+	}
+	simFactor := similarityFactor(similarity)
+	return int(float64(len(seen))*simFactor) + len(entries)/20
+}
+
+func (s *TokenNormalizedStrategy) BlockedHashes() map[uint64]bool {
+	blocked := make(map[uint64]bool)
+
+	// Common patterns to ignore
+	uselessPatterns := [][]Entry{
+		// } }
+		{NewTokenNormalizedEntry("}"), NewTokenNormalizedEntry("}")},
+		// } } }
+		{NewTokenNormalizedEntry("}"), NewTokenNormalizedEntry("}"), NewTokenNormalizedEntry("}")},
+		// return x }
+		{NewTokenNormalizedEntry("return", "ID"), NewTokenNormalizedEntry("}")},
+		// } return x }
+		{NewTokenNormalizedEntry("}"), NewTokenNormalizedEntry("return", "ID"), NewTokenNormalizedEntry("}")},
+		// return nil }
+		{NewTokenNormalizedEntry("return", "nil"), NewTokenNormalizedEntry("}")},
+	}
+
+	for _, pattern := range uselessPatterns {
+		blocked[s.Hash(pattern)] = true
+	}
+
+	return blocked
+}
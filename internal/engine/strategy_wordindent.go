@@ -1,8 +1,7 @@
-package main
+package engine
 
 import (
 	"fmt"
-	"hash/fnv"
 	"strings"
 )
 
@@ -28,6 +27,12 @@ func NewWordIndentEntry(indentDelta int, word string) *WordIndentEntry {
 	}
 }
 
+// restoreHashBytes recomputes the unexported hash contribution, which gob doesn't
+// serialize, for entries loaded from the cache
+func (e *WordIndentEntry) restoreHashBytes() {
+	e.hashBytes = NewWordIndentEntry(e.IndentDelta, e.Word).hashBytes
+}
+
 // CStyleCommentStripper removes /* ... */ multiline comments
 type CStyleCommentStripper struct{}
 
@@ -73,12 +78,12 @@ func (s *WordIndentStrategy) Name() string {
 	return "word-indent"
 }
 
-func (s *WordIndentStrategy) Preparse(content string) string {
+func (s *WordIndentStrategy) Preparse(content string, lang *Language) string {
 	return cStyleStripper.Preparse(content)
 }
 
-func (s *WordIndentStrategy) ParseLine(lineNum int, line string, prevEntry Entry) (Entry, bool) {
-	if isWhitespaceOnly(line) || isCommentOnly(line) || shouldSkipByFirstWord(line) {
+func (s *WordIndentStrategy) ParseLine(lineNum int, line string, prevEntry Entry, lang *Language) (Entry, bool) {
+	if isWhitespaceOnly(line) || isCommentOnly(line, lang) || shouldSkipByFirstWord(line, lang) {
 		return nil, true // skip
 	}
 
@@ -105,11 +110,7 @@ func (s *WordIndentStrategy) ParseLine(lineNum int, line string, prevEntry Entry
 }
 
 func (s *WordIndentStrategy) Hash(entries []Entry) uint64 {
-	h := fnv.New64a()
-	for _, e := range entries {
-		h.Write(e.HashBytes())
-	}
-	return h.Sum64()
+	return hashEntries(entries)
 }
 
 func (s *WordIndentStrategy) Signature(entries []Entry) string {
@@ -148,13 +149,7 @@ func (s *WordIndentStrategy) Score(entries []Entry, similarity float64) int {
 		effectiveWords = 0
 	}
 
-	adjustedSim := similarity*2 - 1.0
-	if adjustedSim < 0 {
-		adjustedSim = 0
-	}
-	// Cube similarity factor - heavily rewards high similarity
-	// 100%=1.0, 95%=0.73, 90%=0.51, 85%=0.34
-	simFactor := adjustedSim * adjustedSim * adjustedSim
+	simFactor := similarityFactor(similarity)
 	return int(float64(effectiveWords)*simFactor) + len(entries)/20
 }
 
@@ -1,7 +1,6 @@
-package main
+package engine
 
 import (
-	"hash/fnv"
 	"strings"
 )
 
@@ -33,12 +32,12 @@ func (s *WordOnlyStrategy) Name() string {
 	return "word-only"
 }
 
-func (s *WordOnlyStrategy) Preparse(content string) string {
+func (s *WordOnlyStrategy) Preparse(content string, lang *Language) string {
 	return cStyleStripper.Preparse(content)
 }
 
-func (s *WordOnlyStrategy) ParseLine(lineNum int, line string, prevEntry Entry) (Entry, bool) {
-	if isWhitespaceOnly(line) || isCommentOnly(line) || shouldSkipByFirstWord(line) {
+func (s *WordOnlyStrategy) ParseLine(lineNum int, line string, prevEntry Entry, lang *Language) (Entry, bool) {
+	if isWhitespaceOnly(line) || isCommentOnly(line, lang) || shouldSkipByFirstWord(line, lang) {
 		return nil, true // skip
 	}
 
@@ -55,11 +54,7 @@ func (s *WordOnlyStrategy) ParseLine(lineNum int, line string, prevEntry Entry)
 }
 
 func (s *WordOnlyStrategy) Hash(entries []Entry) uint64 {
-	h := fnv.New64a()
-	for _, e := range entries {
-		h.Write(e.HashBytes())
-	}
-	return h.Sum64()
+	return hashEntries(entries)
 }
 
 func (s *WordOnlyStrategy) Signature(entries []Entry) string {
@@ -78,13 +73,7 @@ func (s *WordOnlyStrategy) Score(entries []Entry, similarity float64) int {
 		seen[entry.Word] = true
 	}
 	uniqueWords := len(seen)
-	adjustedSim := similarity*2 - 1.0
-	if adjustedSim < 0 {
-		adjustedSim = 0
-	}
-	// Cube similarity factor - heavily rewards high similarity
-	// 100%=1.0, 95%=0.73, 90%=0.51, 85%=0.34
-	simFactor := adjustedSim * adjustedSim * adjustedSim
+	simFactor := similarityFactor(similarity)
 	return int(float64(uniqueWords)*simFactor) + len(entries)/20
 }
 
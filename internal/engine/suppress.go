@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"strings"
+	"sync"
+)
+
+// ignoreMarker suppresses duplicates within the block starting on its line or, for a
+// comment on its own line, at the next line of code: // quickdup:ignore, # quickdup:ignore, ...
+const ignoreMarker = "quickdup:ignore"
+
+// Suppressions records the lines suppressed by inline ignore markers, per file.
+// Safe for concurrent use by parse workers.
+type Suppressions struct {
+	mu    sync.Mutex
+	lines map[string]map[int]bool
+}
+
+// Add records the suppressed lines of a file; a nil collector drops them
+func (s *Suppressions) Add(path string, lines []int) {
+	if s == nil || len(lines) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lines == nil {
+		s.lines = make(map[string]map[int]bool)
+	}
+	set := make(map[int]bool, len(lines))
+	for _, l := range lines {
+		set[l] = true
+	}
+	s.lines[path] = set
+}
+
+// Covers reports whether an occurrence starting at filename:line is suppressed
+func (s *Suppressions) Covers(filename string, line int) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lines[filename][line]
+}
+
+// Lines returns the suppressed lines of a file, for caching
+func (s *Suppressions) Lines(path string) []int {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var lines []int
+	for l := range s.lines[path] {
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// suppressedLines returns the entry lines covered by ignore markers in the raw source.
+// A marker covers the block starting at its own line (trailing comment) or at the next
+// line of code (comment on its own line): that entry plus the more deeply indented
+// entries following it and, when they end in one, the closing line (}, end, ...) back
+// at its indentation. A statement with no deeper body covers only its own line.
+func suppressedLines(rawLines []string, entries []Entry, lang *Language) []int {
+	var suppressed []int
+	next := 0 // index of the first entry not yet covered
+	for i, line := range rawLines {
+		if !hasIgnoreMarker(line, lang) {
+			continue
+		}
+		lineNumber := i + 1
+		standalone := isCommentOnly(line, lang) || strings.HasPrefix(strings.TrimSpace(line), "/*")
+		for next < len(entries) && (entries[next].GetLineNumber() < lineNumber ||
+			standalone && entries[next].GetLineNumber() == lineNumber) {
+			next++
+		}
+		if next >= len(entries) {
+			break
+		}
+		// The block: its first entry, the deeper body and the closing entry
+		indent := calculateIndent(entries[next].GetRaw())
+		suppressed = append(suppressed, entries[next].GetLineNumber())
+		body := 0
+		for next++; next < len(entries) && calculateIndent(entries[next].GetRaw()) > indent; next++ {
+			suppressed = append(suppressed, entries[next].GetLineNumber())
+			body++
+		}
+		// A sibling at the same indentation is the next statement, not part of the block
+		if body > 0 && next < len(entries) && calculateIndent(entries[next].GetRaw()) == indent &&
+			isClosingLine(entries[next].GetRaw()) {
+			suppressed = append(suppressed, entries[next].GetLineNumber())
+			next++
+		}
+	}
+	return suppressed
+}
+
+// hasIgnoreMarker reports whether line carries the marker inside a comment, using the
+// file's line-comment prefix or a block comment opener (/* */, <!-- -->)
+func hasIgnoreMarker(line string, lang *Language) bool {
+	idx := strings.Index(line, ignoreMarker)
+	if idx < 0 {
+		return false
+	}
+	before := strings.TrimRight(line[:idx], " \t")
+	return (lang.CommentPrefix != "" && strings.HasSuffix(before, lang.CommentPrefix)) ||
+		strings.HasSuffix(before, "/*") || strings.HasSuffix(before, "<!--")
+}
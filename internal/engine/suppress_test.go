@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSuppressedLines(t *testing.T) {
+	useStrategy(t, "normalized-indent")
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []int
+	}{
+		{
+			"trailing marker on a one-line statement",
+			"a.go",
+			"func f() {\n\tsetup := prepare() // quickdup:ignore\n\ttotal := computeTotal(orders)\n}\n",
+			[]int{2},
+		},
+		{
+			"standalone marker above a brace block",
+			"a.go",
+			"func f() {\n\t// quickdup:ignore\n\tif ok {\n\t\trun()\n\t}\n\tafter()\n}\n",
+			[]int{3, 4, 5},
+		},
+		{
+			"python def followed by a sibling def",
+			"a.py",
+			"# quickdup:ignore\ndef a():\n    return 1\ndef b():\n    return 2\n",
+			[]int{2, 3},
+		},
+		{
+			"ruby def through its end",
+			"a.rb",
+			"# quickdup:ignore\ndef a\n  1\nend\ndef b\n  2\nend\n",
+			[]int{2, 3, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang := languageFor(tt.file)
+			entries := parseSource(t, tt.file, tt.content)
+			got := suppressedLines(strings.Split(tt.content, "\n"), entries, &lang)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suppressedLines = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterPatternsSkipsSuppressedCopies(t *testing.T) {
+	useStrategy(t, "normalized-indent")
+	silence(t)
+	block := `func load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+`
+	sources := map[string]string{
+		"a.go": block,
+		"b.go": block,
+		"c.go": "// quickdup:ignore\n" + block,
+	}
+	suppressions := &Suppressions{}
+	fileData := make(map[string][]Entry)
+	for name, content := range sources {
+		entries, suppressed, err := parseContent(name, []byte(content))
+		if err != nil {
+			t.Fatalf("parse %s: %v", name, err)
+		}
+		fileData[name] = entries
+		suppressions.Add(name, suppressed)
+	}
+	patterns := detectPatterns(fileData, len(fileData), 2, 3, 0, false)
+	if len(patterns) == 0 {
+		t.Fatal("no patterns detected")
+	}
+
+	tests := []struct {
+		name        string
+		minOccur    int
+		wantMatches bool
+	}{
+		{"enough unmarked copies remain", 2, true},
+		{"fewer unmarked copies than -min", 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, stats := FilterPatterns(patterns, FilterConfig{MinOccur: tt.minOccur, Suppressed: suppressions})
+			for _, m := range matches {
+				for _, loc := range m.Locations {
+					if loc.Filename == "c.go" {
+						t.Errorf("match %016x reports suppressed copy c.go:%d", m.Hash, loc.LineStart)
+					}
+				}
+			}
+			if got := len(matches) > 0; got != tt.wantMatches {
+				t.Errorf("%d matches (%d skipped as suppressed), want matches: %v", len(matches), stats.SkippedSuppressed, tt.wantMatches)
+			}
+			if !tt.wantMatches && stats.SkippedSuppressed == 0 {
+				t.Error("no pattern counted as skipped for suppression")
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Test file handling (--tests)
+const (
+	TestsInclude  = "include"  // test files are scanned like any other code
+	TestsExclude  = "exclude"  // test files are skipped
+	TestsSeparate = "separate" // test files get their own report (findTestDuplicates)
+)
+
+// testsMode is how test files are handled (set from --tests)
+var testsMode = TestsInclude
+
+// testDirs are directory names that hold tests by convention
+var testDirs = map[string]bool{"test": true, "tests": true, "__tests__": true, "spec": true, "specs": true}
+
+// isTestFile reports whether a file found under root is a test by naming convention:
+// foo_test.go, test_foo.py, foo.test.ts, foo.spec.js, FooTest.java, FooTests.cs,
+// foo_spec.rb, or anything in a test directory below root
+func isTestFile(root, path string) bool {
+	base := filepath.Base(path)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	switch {
+	case strings.HasSuffix(stem, "_test"), strings.HasSuffix(stem, "_spec"), strings.HasPrefix(stem, "test_"),
+		strings.HasSuffix(stem, ".test"), strings.HasSuffix(stem, ".spec"),
+		strings.HasSuffix(stem, "Test"), strings.HasSuffix(stem, "Tests"), strings.HasSuffix(stem, "Spec"):
+		return true
+	}
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil {
+		return false
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(rel), "/") {
+		if testDirs[strings.ToLower(dir)] {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTestFiles splits parsed files under root into production code and tests
+func splitTestFiles(root string, fileData map[string][]Entry) (map[string][]Entry, map[string][]Entry) {
+	code := make(map[string][]Entry)
+	tests := make(map[string][]Entry)
+	for path, entries := range fileData {
+		if isTestFile(root, path) {
+			tests[path] = entries
+		} else {
+			code[path] = entries
+		}
+	}
+	return code, tests
+}
+
+// assertionPrefixes start the lines of assertion blocks across common test frameworks
+var assertionPrefixes = []string{
+	"assert", "self.assert", "expect(", "expect.", "require.", "should", "t.error", "t.fatal",
+	"verify(", "xctassert", "check(", "is.", "must.", "await expect(",
+}
+
+// isAssertionLine reports whether a lowercased, trimmed line starts an assertion
+func isAssertionLine(line string) bool {
+	for _, prefix := range assertionPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// findTestDuplicates filters the patterns detected among test files alone, tagging
+// each match as assertions or setup: repeated assertions suggest a custom assertion
+// helper, repeated setup a fixture or builder
+func findTestDuplicates(patterns map[uint64][]PatternLocation, filter FilterConfig) []PatternMatch {
+	if len(patterns) == 0 {
+		return nil
+	}
+	matches, _ := FilterPatterns(patterns, filter)
+	for i := range matches {
+		if matches[i].Kind == "" {
+			matches[i].Kind = classifyTestPattern(matches[i].Pattern)
+		}
+	}
+	return matches
+}
+
+// classifyTestPattern returns KindTestAssertions for patterns made mostly of assertion
+// lines, KindTestSetup otherwise. The condition guarding an assertion, as in Go's
+// `if got != want { t.Errorf(...) }`, counts as part of it.
+func classifyTestPattern(pattern []Entry) string {
+	assertions, body := 0, 0
+	prevIf := false
+	for _, e := range pattern {
+		line := strings.ToLower(strings.TrimSpace(e.GetRaw()))
+		if strings.Trim(line, "{}()[];,") == "" {
+			continue
+		}
+		body++
+		if isAssertionLine(line) {
+			assertions++
+			if prevIf {
+				assertions++
+			}
+		}
+		prevIf = strings.HasPrefix(line, "if ")
+	}
+	if body > 0 && assertions*2 >= body {
+		return KindTestAssertions
+	}
+	return KindTestSetup
+}
@@ -0,0 +1,57 @@
+package engine
+
+// Triage tiers, from most to least worth a developer's time
+const (
+	TierActionable  = "actionable"   // high score, high similarity, spread across files
+	TierReview      = "review"       // everything in between
+	TierLikelyNoise = "likely-noise" // low score or data definitions
+)
+
+// tierOrder lists the tiers in report order
+var tierOrder = []string{TierActionable, TierReview, TierLikelyNoise}
+
+// TierConfig holds the thresholds between tiers (--actionable-score, ...)
+type TierConfig struct {
+	ActionableScore      int
+	ActionableSimilarity float64
+	NoiseScore           int
+}
+
+// classifyTier sorts a match into a triage tier using its score, similarity, file
+// spread and kind. Within-file duplicates are never actionable: they're cheaper to
+// spot and fix locally.
+func classifyTier(m PatternMatch, config TierConfig) string {
+	if m.Kind == KindDataDefinition || m.Score < config.NoiseScore {
+		return TierLikelyNoise
+	}
+	if m.Score >= config.ActionableScore && m.Similarity >= config.ActionableSimilarity && spansFiles(m) {
+		return TierActionable
+	}
+	return TierReview
+}
+
+// assignTiers tags every match with its tier
+func assignTiers(matches []PatternMatch, config TierConfig) {
+	for i := range matches {
+		matches[i].Tier = classifyTier(matches[i], config)
+	}
+}
+
+// spansFiles reports whether a match occurs in more than one file
+func spansFiles(m PatternMatch) bool {
+	for _, loc := range m.Locations {
+		if loc.Filename != m.Locations[0].Filename {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesByTier groups matches by tier, keeping their score order
+func matchesByTier(matches []PatternMatch) map[string][]PatternMatch {
+	tiers := make(map[string][]PatternMatch)
+	for _, m := range matches {
+		tiers[m.Tier] = append(tiers[m.Tier], m)
+	}
+	return tiers
+}
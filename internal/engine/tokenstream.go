@@ -0,0 +1,234 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// maxTokenWindowPositions skips token windows found in more places than this: runs
+// of the same few tokens (closing brackets, zero-filled tables) would make the pair
+// count quadratic
+const maxTokenWindowPositions = 32
+
+// minTokenStream is the shortest token run --token-stream accepts: shorter ones are
+// mostly common idioms
+const minTokenStream = 10
+
+// tokenWindowBase is the multiplier of the rolling token window hash
+const tokenWindowBase = 1099511628211
+
+// streamToken is one token of a file's token stream
+type streamToken struct {
+	hash uint64
+	text string
+	line int
+	col  int // 1-based byte column of the first byte
+}
+
+// TokenSpan is one occurrence of a token clone, mapped back to the source
+type TokenSpan struct {
+	Filename  string `json:"filename"`
+	LineStart int    `json:"line_start"`
+	Column    int    `json:"column"`
+	LineEnd   int    `json:"line_end"`
+	EndColumn int    `json:"end_column"` // column of the last token's last byte
+}
+
+// TokenClone is a run of identical tokens found in several places wherever the line
+// breaks fall: an expression spread over several lines, or statements packed onto one
+// (--token-stream)
+type TokenClone struct {
+	Hash   string      `json:"hash"`
+	Tokens int         `json:"tokens"`
+	Spans  []TokenSpan `json:"occurrences"`
+}
+
+// streamTokens tokenizes the parsed lines of a file into one stream: words and
+// numbers, string literals and operator runs are tokens, as is any other
+// non-whitespace character but semicolons, which are left out so statements packed
+// onto one line read as they do on separate lines. lang tells how literals escape quotes.
+func streamTokens(entries []Entry, lang *Language) []streamToken {
+	var tokens []streamToken
+	for _, e := range entries {
+		line := e.GetRaw()
+		for i := 0; i < len(line); {
+			r, size := utf8.DecodeRuneInString(line[i:])
+			start := i
+			switch {
+			case unicode.IsSpace(r) || r == ';':
+				i += size
+				continue
+			case r == '"' || r == '\'' || r == '`':
+				i = stringLiteralEnd(line, i, lang)
+			case isWordRune(r):
+				for i < len(line) {
+					r, size := utf8.DecodeRuneInString(line[i:])
+					if !isWordRune(r) {
+						break
+					}
+					i += size
+				}
+			case strings.ContainsRune(operatorChars, r):
+				for i < len(line) && strings.IndexByte(operatorChars, line[i]) >= 0 {
+					i++
+				}
+			default:
+				i += size
+			}
+			text := line[start:i]
+			tokens = append(tokens, streamToken{hash: xxhash.Sum64String(text), text: text, line: e.GetLineNumber(), col: start + 1})
+		}
+	}
+	return tokens
+}
+
+// isWordRune reports whether r continues an identifier or number
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$'
+}
+
+// tokenPos is the position of a token window: file index and token index
+type tokenPos struct {
+	file, pos int
+}
+
+// findTokenClones finds runs of at least minTokens identical tokens in several places,
+// ignoring line boundaries. Windows of minTokens tokens are indexed by rolling hash;
+// each pair of windows sharing one is extended to the longest common run, and pairs of
+// equal runs are grouped into one clone. Clones whose copies are occurrences of the
+// same exact match are left to it.
+func findTokenClones(fileData map[string][]Entry, minTokens int, matches []PatternMatch) []TokenClone {
+	files := make([]string, 0, len(fileData))
+	for f := range fileData {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	streams := make([][]streamToken, len(files))
+	for i, f := range files {
+		lang := languageFor(f)
+		streams[i] = streamTokens(fileData[f], &lang)
+	}
+
+	// Count windows first, so only the shared ones are indexed with their positions
+	counts := make(map[uint64]int)
+	forEachTokenWindow(streams, minTokens, func(h uint64, _ tokenPos) { counts[h]++ })
+	index := make(map[uint64][]tokenPos)
+	forEachTokenWindow(streams, minTokens, func(h uint64, p tokenPos) {
+		if n := counts[h]; n >= 2 && n <= maxTokenWindowPositions {
+			index[h] = append(index[h], p)
+		}
+	})
+
+	same := func(a, b tokenPos) bool {
+		x, y := streams[a.file][a.pos], streams[b.file][b.pos]
+		return x.hash == y.hash && x.text == y.text
+	}
+	type cloneKey struct {
+		hash   uint64
+		tokens int
+	}
+	spans := make(map[cloneKey]map[tokenPos]bool)
+	for _, positions := range index {
+		for i, a := range positions {
+			for _, b := range positions[i+1:] {
+				// Start of a maximal run only: one that extends backward is found from there
+				if a.pos > 0 && b.pos > 0 && same(tokenPos{a.file, a.pos - 1}, tokenPos{b.file, b.pos - 1}) {
+					continue
+				}
+				n := 0
+				for a.pos+n < len(streams[a.file]) && b.pos+n < len(streams[b.file]) && same(tokenPos{a.file, a.pos + n}, tokenPos{b.file, b.pos + n}) {
+					if a.file == b.file && a.pos+n >= b.pos {
+						break // a run overlapping its own copy
+					}
+					n++
+				}
+				if n < minTokens {
+					continue
+				}
+				h := xxhash.New()
+				for _, t := range streams[a.file][a.pos : a.pos+n] {
+					h.WriteString(t.text)
+					h.WriteString(" ")
+				}
+				key := cloneKey{h.Sum64(), n}
+				if spans[key] == nil {
+					spans[key] = make(map[tokenPos]bool)
+				}
+				spans[key][a], spans[key][b] = true, true
+			}
+		}
+	}
+
+	exact := exactlyMatched(matches)
+	var clones []TokenClone
+	for key, set := range spans {
+		positions := make([]tokenPos, 0, len(set))
+		for p := range set {
+			positions = append(positions, p)
+		}
+		sort.Slice(positions, func(i, j int) bool {
+			if positions[i].file != positions[j].file {
+				return positions[i].file < positions[j].file
+			}
+			return positions[i].pos < positions[j].pos
+		})
+		clone := TokenClone{Hash: fmt.Sprintf("%016x", key.hash), Tokens: key.tokens}
+		for _, p := range positions {
+			first, last := streams[p.file][p.pos], streams[p.file][p.pos+key.tokens-1]
+			clone.Spans = append(clone.Spans, TokenSpan{
+				Filename:  files[p.file],
+				LineStart: first.line,
+				Column:    first.col,
+				LineEnd:   last.line,
+				EndColumn: last.col + len(last.text) - 1,
+			})
+		}
+		a, b := clone.Spans[0], clone.Spans[1]
+		if exact(FuzzyRegion{a.Filename, a.LineStart, a.LineEnd}, FuzzyRegion{b.Filename, b.LineStart, b.LineEnd}) {
+			continue
+		}
+		clones = append(clones, clone)
+	}
+
+	// Longest first, then by first occurrence for stable output
+	sort.Slice(clones, func(i, j int) bool {
+		if clones[i].Tokens != clones[j].Tokens {
+			return clones[i].Tokens > clones[j].Tokens
+		}
+		a, b := clones[i].Spans[0], clones[j].Spans[0]
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.LineStart != b.LineStart {
+			return a.LineStart < b.LineStart
+		}
+		return a.Column < b.Column
+	})
+	return clones
+}
+
+// forEachTokenWindow calls fn with the rolling hash and position of every window of
+// n consecutive tokens
+func forEachTokenWindow(streams [][]streamToken, n int, fn func(uint64, tokenPos)) {
+	power := uint64(1) // tokenWindowBase^(n-1), to remove the token leaving the window
+	for i := 1; i < n; i++ {
+		power *= tokenWindowBase
+	}
+	for f, tokens := range streams {
+		var h uint64
+		for i, t := range tokens {
+			if i >= n {
+				h -= tokens[i-n].hash * power
+			}
+			h = h*tokenWindowBase + t.hash
+			if i >= n-1 {
+				fn(h, tokenPos{f, i - n + 1})
+			}
+		}
+	}
+}
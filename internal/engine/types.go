@@ -0,0 +1,180 @@
+package engine
+
+import "time"
+
+// PatternLocation represents a location where a pattern was found
+type PatternLocation struct {
+	Filename   string
+	LineStart  int
+	EntryIndex int     // start position in entries array
+	Pattern    []Entry // the actual pattern at this location
+
+	Repeats     int // back-to-back copies coalesced into this span by -merge-adjacent (0 = single)
+	SpanEndLine int // last line of the coalesced span, when Repeats > 1
+}
+
+// PatternMatch represents a matched pattern with all its occurrences
+type PatternMatch struct {
+	Hash       uint64
+	Locations  []PatternLocation
+	Pattern    []Entry // representative pattern (see Representative)
+	Similarity float64 // average token similarity across occurrences (0.0-1.0)
+	Score      int     // strategy-computed score
+
+	Representative int // index into Locations of the representative occurrence
+
+	NewestChange time.Time // newest git blame commit time across locations (set by --blame-age)
+
+	Substitutions []Substitution // identifiers consistently renamed between occurrences, if parametrizable
+	Kind          string         // e.g. KindDataDefinition, "" for ordinary code
+	Tier          string         // triage tier, e.g. TierActionable (see classifyTier)
+	Steps         []string       // CI step and Makefile target names in the pattern (ci-config)
+	Dirs          []string       // top-level directories the occurrences span (set with --min-dirs)
+}
+
+// JSON output structures
+
+type JSONLocation struct {
+	Filename  string `json:"filename"`
+	LineStart int    `json:"line_start"`
+	Canonical bool   `json:"canonical,omitempty"` // suggested "keep this one" occurrence (the representative)
+	LineEnd   int    `json:"line_end,omitempty"`  // end of a coalesced span (-merge-adjacent)
+	Repeats   int    `json:"repeats,omitempty"`   // back-to-back copies in the span
+
+	// Similarity to the representative, set only when it varies between occurrences
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+type JSONPattern struct {
+	Hash        string         `json:"hash"`
+	Score       int            `json:"score"`
+	Lines       int            `json:"lines"`
+	Similarity  float64        `json:"similarity"`
+	Occurrences int            `json:"occurrences"`
+	Locations   []JSONLocation `json:"locations"`
+	Pattern     []string       `json:"pattern"` // representative source lines
+
+	NewestChange  string         `json:"newest_change,omitempty"`
+	Substitutions []Substitution `json:"substitutions,omitempty"` // set for parametrizable duplicates
+	Kind          string         `json:"kind,omitempty"`          // e.g. "data-definition"
+	Tier          string         `json:"tier,omitempty"`          // actionable, review or likely-noise
+	Steps         []string       `json:"steps,omitempty"`         // duplicated CI steps / Makefile targets
+	Dirs          []string       `json:"dirs,omitempty"`          // top-level directories spanned (--min-dirs)
+}
+
+type JSONOutput struct {
+	TotalPatterns  int              `json:"total_patterns"`
+	Partial        bool             `json:"partial,omitempty"`           // the scan was cut short (Ctrl-C, -timeout)
+	Interrupted    string           `json:"interrupted_phase,omitempty"` // phase it stopped in: parse, detect, filter
+	HashAlgorithm  string           `json:"hash_algorithm,omitempty"`
+	Config         *JSONConfig      `json:"config,omitempty"`
+	Timings        *Timings         `json:"timings,omitempty"`
+	Patterns       []JSONPattern    `json:"patterns"`
+	Histogram      []LengthBucket   `json:"length_histogram,omitempty"`
+	FilePairs      []FilePairBlock  `json:"file_pairs,omitempty"` // longest shared block per file pair
+	Repeats        []JSONRepeat     `json:"repeats,omitempty"`
+	Fuzzy          []FuzzyDuplicate `json:"fuzzy_duplicates,omitempty"`
+	TokenClones    []TokenClone     `json:"token_clones,omitempty"`
+	SQLQueries     []JSONSQLQuery   `json:"sql_queries,omitempty"`
+	MagicNumbers   []MagicNumber    `json:"magic_numbers,omitempty"`
+	CommonImports  []CommonImport   `json:"common_imports,omitempty"`
+	Accessors      []AccessorClass  `json:"accessor_classes,omitempty"`
+	TestPatterns   []JSONPattern    `json:"test_patterns,omitempty"` // duplication among test files (--tests separate)
+	ScannedFiles   []ScannedFile    `json:"scanned_files,omitempty"`
+	IdenticalFiles []IdenticalFiles `json:"identical_files,omitempty"`
+	Warnings       []Warning        `json:"warnings,omitempty"`
+}
+
+// JSONSummary is the --summary-only --format json output
+type JSONSummary struct {
+	Patterns  int   `json:"patterns"`
+	Files     int   `json:"files"`
+	Lines     int   `json:"lines"`
+	ElapsedMs int64 `json:"elapsed_ms"`
+}
+
+// JSONConfig records the effective settings a results file was produced with, so
+// results can be reproduced and baselines compared like for like
+type JSONConfig struct {
+	Version          string   `json:"version"`
+	Strategy         string   `json:"strategy"`
+	Extension        string   `json:"extension"`
+	MinOccur         int      `json:"min_occur"`
+	MinMode          string   `json:"min_mode,omitempty"`
+	MinScore         int      `json:"min_score"`
+	MinScorePerLine  float64  `json:"min_score_per_line,omitempty"`
+	MinSize          int      `json:"min_size"`
+	MaxSize          int      `json:"max_size"`
+	OnlyGrown        bool     `json:"only_grown,omitempty"`
+	MinDirs          int      `json:"min_dirs,omitempty"`
+	MinSimilarity    float64  `json:"min_similarity"`
+	MaxSimilarity    float64  `json:"max_similarity"`
+	SimilarityFloor  float64  `json:"similarity_floor"`
+	SimilarityMetric string   `json:"similarity_metric"`
+	ScorePreset      string   `json:"score_preset"`
+	Exclude          []string `json:"exclude,omitempty"`
+	Tests            string   `json:"tests,omitempty"`          // test file handling, when not include
+	TestMinOccur     int      `json:"test_min_occur,omitempty"` // -min of test findings (--tests separate)
+	ParseOptions     string   `json:"parse_options,omitempty"`  // see parseOptionsKey
+
+	Overrides []ThresholdOverride `json:"overrides,omitempty"` // per-path thresholds from .quickdup.json
+}
+
+// Timings is the per-phase runtime breakdown of a scan, for tracking performance in CI
+type Timings struct {
+	ParseMs  int64 `json:"parse_ms"`
+	DetectMs int64 `json:"detect_ms"`
+	FilterMs int64 `json:"filter_ms"`
+	TotalMs  int64 `json:"total_ms"` // up to writing results
+	Files    int   `json:"files"`
+	Lines    int   `json:"lines"`
+}
+
+// LengthBucket counts reported patterns whose line count falls in a range
+type LengthBucket struct {
+	Lines    string `json:"lines"` // e.g. "6-10", "21+"
+	Patterns int    `json:"patterns"`
+}
+
+// ScannedFile records a parsed file and its line count (non-blank, non-comment)
+type ScannedFile struct {
+	Filename string `json:"filename"`
+	Lines    int    `json:"lines"`
+}
+
+// IdenticalFiles is a set of files with byte-identical content; only Kept is scanned
+type IdenticalFiles struct {
+	Kept   string   `json:"kept"`
+	Copies []string `json:"copies"`
+}
+
+// JSONRepeat is a block repeated back-to-back, reported once instead of per copy
+type JSONRepeat struct {
+	Hash       string `json:"hash"`
+	Filename   string `json:"filename"`
+	LineStart  int    `json:"line_start"`
+	LineEnd    int    `json:"line_end"`
+	BlockLines int    `json:"block_lines"`
+	Repeats    int    `json:"repeats"`
+}
+
+// IgnoreFile represents the structure of ignore.json
+type IgnoreFile struct {
+	Description   string   `json:"description"`
+	HashAlgorithm string   `json:"hash_algorithm,omitempty"` // --hash the entries were taken with; fnv when unset
+	Ignored       []string `json:"ignored"`
+}
+
+// OccurrenceKey uniquely identifies an occurrence by file and position
+type OccurrenceKey struct {
+	Filename   string
+	EntryIndex int
+}
+
+// JSONSQLQuery is a SQL query literal duplicated across the codebase (--sql)
+type JSONSQLQuery struct {
+	Hash        string        `json:"hash"`
+	Query       string        `json:"query"` // normalized text
+	Occurrences int           `json:"occurrences"`
+	Locations   []SQLLocation `json:"locations"`
+}
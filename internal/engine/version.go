@@ -0,0 +1,21 @@
+package engine
+
+import "runtime/debug"
+
+// toolVersion returns the module version quickdup was built as, or the VCS revision
+// for development builds ("dev" if neither is known)
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value
+		}
+	}
+	return "dev"
+}
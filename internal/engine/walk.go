@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxDepth limits how many directory levels below the scan path the walk descends,
+// 0 for no limit (set from --max-depth)
+var maxDepth int
+
+// collectFiles walks folder and returns all files matching extension that aren't excluded
+// ("Makefile" matches Makefiles, see fileType) or, with --respect-gitignore, ignored;
+// with --tests exclude, test files are left out too.
+// Unreadable entries are recorded as warnings and skipped rather than aborting the walk.
+// If folder is a regular file it is returned as-is: it was named explicitly, so the
+// extension and exclude filters don't apply.
+func collectFiles(folder, extension string, excludePatterns []string, warnings *Warnings) ([]string, error) {
+	info, err := os.Stat(folder)
+	if err != nil {
+		return nil, &ScanError{Op: "walk", Path: folder, Err: err}
+	}
+	if !info.IsDir() {
+		return []string{folder}, nil
+	}
+
+	var ignore *Gitignore
+	if respectGitignore {
+		if ignore, err = loadGitignore(folder, warnings); err != nil {
+			return nil, &ScanError{Op: "walk", Path: folder, Err: err}
+		}
+	}
+
+	var files []string
+	err = filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// The root itself must be readable; anything below it is best-effort
+			if path == folder {
+				return err
+			}
+			warnings.Add("walk", path, err)
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() && maxDepth > 0 && dirDepth(folder, path) > maxDepth {
+			return filepath.SkipDir
+		}
+		if ignore != nil && path != folder {
+			if info.Name() == ".git" || ignore.ignored(path, info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() {
+				absPath, _ := filepath.Abs(path)
+				ignore.enter(absPath, warnings)
+			}
+		}
+		if !info.IsDir() && scansExt(fileType(path), extension) {
+			if !isExcluded(path, excludePatterns) && !(testsMode == TestsExclude && isTestFile(folder, path)) {
+				files = append(files, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &ScanError{Op: "walk", Path: folder, Err: err}
+	}
+	return files, nil
+}
+
+// dirDepth returns how many levels below root dir is: 0 for root itself
+func dirDepth(root, dir string) int {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// isExcluded reports whether path matches any exclude pattern
+func isExcluded(path string, excludePatterns []string) bool {
+	for _, pattern := range excludePatterns {
+		// Check if pattern matches basename (glob) or is contained in path (substring)
+		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+			return true
+		}
+		// Also check if pattern is a substring of the path (for directory patterns like ".Tests/")
+		if strings.Contains(path, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestCollectFilesSingleFileRoot(t *testing.T) {
+	dir, _ := writeFiles(t, map[string]string{
+		"a.go":     "package a\n",
+		"notes.md": "# notes\n",
+		"sub/b.go": "package b\n",
+	})
+	tests := []struct {
+		name string
+		root string
+		want []string
+	}{
+		{"file of the scanned extension", "a.go", []string{"a.go"}},
+		{"file named explicitly despite its extension", "notes.md", []string{"notes.md"}},
+		{"directory", ".", []string{"a.go", "sub/b.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectFiles(filepath.Join(dir, tt.root), ".go", nil, &Warnings{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []string
+			for _, f := range tt.want {
+				want = append(want, filepath.Join(dir, filepath.FromSlash(f)))
+			}
+			sort.Strings(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("collectFiles(%s) = %v, want %v", tt.root, got, want)
+			}
+		})
+	}
+}
+
+func TestSelfScanFindsDuplicationWithinFile(t *testing.T) {
+	useStrategy(t, "normalized-indent")
+	silence(t)
+	handler := `
+	if err := validate(req); err != nil {
+		log.Printf("invalid request: %v", err)
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+`
+	_, paths := writeFiles(t, map[string]string{
+		"self.go": "package self\n\nfunc a() error {" + handler + "\treturn nil\n}\n\nfunc b() error {" + handler + "\treturn nil\n}\n",
+	})
+	files, err := collectFiles(paths[0], ".go", nil, &Warnings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileData, _, _ := parseFilesWithCache(files, nil, nil, &Warnings{}, &Suppressions{})
+
+	patterns := detectPatterns(fileData, len(fileData), 2, 3, 0, false)
+	matches, _ := FilterPatterns(patterns, FilterConfig{MinOccur: 2})
+	if len(matches) == 0 {
+		t.Fatal("no duplication found within the file")
+	}
+	m := matches[0]
+	if len(m.Pattern) < 8 || len(m.Locations) != 2 {
+		t.Errorf("top match: %d lines, %d occurrences; want the 8+ line handler twice", len(m.Pattern), len(m.Locations))
+	}
+	for _, loc := range m.Locations {
+		if loc.Filename != paths[0] {
+			t.Errorf("occurrence in %s, want %s", loc.Filename, paths[0])
+		}
+	}
+}
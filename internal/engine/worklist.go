@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ROIWeights weights the terms of a match's refactoring ROI (set from --roi-weights)
+type ROIWeights struct {
+	Score       float64
+	Occurrences float64
+	LinesSaved  float64
+}
+
+// defaultROIWeights favors lines saved: a long block copied twice beats a short one
+// copied three times
+var defaultROIWeights = ROIWeights{Score: 1, Occurrences: 2, LinesSaved: 0.5}
+
+// parseROIWeights parses "score=1,occurrences=2,lines=0.5"; omitted terms keep their
+// default weight
+func parseROIWeights(s string) (ROIWeights, error) {
+	weights := defaultROIWeights
+	for _, part := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return weights, fmt.Errorf("expected name=weight, got %q", part)
+		}
+		w, err := strconv.ParseFloat(value, 64)
+		if err != nil || w < 0 {
+			return weights, fmt.Errorf("invalid weight for %s: %q", name, value)
+		}
+		switch name {
+		case "score":
+			weights.Score = w
+		case "occurrences":
+			weights.Occurrences = w
+		case "lines":
+			weights.LinesSaved = w
+		default:
+			return weights, fmt.Errorf("unknown term %q (want score, occurrences or lines)", name)
+		}
+	}
+	return weights, nil
+}
+
+func (w ROIWeights) String() string {
+	return fmt.Sprintf("score × %g + occurrences × %g + lines saved × %g", w.Score, w.Occurrences, w.LinesSaved)
+}
+
+// ROI returns a match's refactoring return on investment
+func (w ROIWeights) ROI(m PatternMatch) float64 {
+	return w.Score*float64(m.Score) + w.Occurrences*float64(len(m.Locations)) + w.LinesSaved*float64(linesSaved(m))
+}
+
+// linesSaved estimates the lines removed by extracting a match: every copy but one
+func linesSaved(m PatternMatch) int {
+	return (len(m.Locations) - 1) * len(m.Pattern)
+}
+
+// WriteWorklist writes matches as a markdown checklist of refactoring tasks, highest
+// ROI first. Likely-noise matches are left out.
+func WriteWorklist(matches []PatternMatch, weights ROIWeights, path string) error {
+	var tasks []PatternMatch
+	for _, m := range matches {
+		if m.Tier != TierLikelyNoise {
+			tasks = append(tasks, m)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return weights.ROI(tasks[i]) > weights.ROI(tasks[j])
+	})
+
+	var b strings.Builder
+	b.WriteString("# Refactoring worklist\n\n")
+	fmt.Fprintf(&b, "%d tasks ordered by ROI = %s.\n\n", len(tasks), weights)
+	for i, m := range tasks {
+		loc := m.Locations[m.Representative]
+		verb := "Extract the block"
+		if len(m.Substitutions) > 0 {
+			verb = "Extract a generic from the block"
+		}
+		fmt.Fprintf(&b, "%d. [ ] %s at `%s:%d-%d` appearing %d times, ~%d lines saved\n",
+			i+1, verb, loc.Filename, loc.LineStart, locationEndLine(loc), len(m.Locations), linesSaved(m))
+		fmt.Fprintf(&b, "   - ROI %.0f · score %d · %.0f%% similar · `%016x`\n",
+			weights.ROI(m), m.Score, m.Similarity*100, m.Hash)
+		if len(m.Substitutions) > 0 {
+			fmt.Fprintf(&b, "   - Parameters: %s\n", formatSubstitutions(m.Substitutions))
+		}
+		var others []string
+		for j, other := range m.Locations {
+			if j != m.Representative {
+				others = append(others, fmt.Sprintf("`%s:%d`", other.Filename, other.LineStart))
+			}
+		}
+		fmt.Fprintf(&b, "   - Also at: %s\n", strings.Join(others, ", "))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return &ScanError{Op: "create output directory", Path: filepath.Dir(path), Err: err}
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return &ScanError{Op: "write worklist", Path: path, Err: err}
+	}
+	return nil
+}
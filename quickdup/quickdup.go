@@ -0,0 +1,33 @@
+// Package quickdup finds duplicated code, as the quickdup command does, for Go programs
+// that scan code themselves:
+//
+//	matches, err := quickdup.Analyze(files, quickdup.DefaultOptions())
+//	for _, m := range matches {
+//		loc := m.Locations[m.Representative]
+//		fmt.Printf("%s:%d score %d, %d copies\n", loc.Filename, loc.LineStart, m.Score, len(m.Locations))
+//	}
+package quickdup
+
+import "github.com/asynkron/Asynkron.QuickDup/internal/engine"
+
+type (
+	// PatternMatch is a duplicated pattern with its occurrences, as scored and clustered
+	PatternMatch = engine.PatternMatch
+	// PatternLocation is one occurrence of a pattern
+	PatternLocation = engine.PatternLocation
+	// Entry is a parsed source line of a pattern
+	Entry = engine.Entry
+	// Options configures Analyze: the thresholds of the command line flags of the same name
+	Options = engine.Options
+)
+
+// DefaultOptions returns the command line's defaults
+func DefaultOptions() Options {
+	return engine.DefaultOptions()
+}
+
+// Analyze finds the duplicated code across files, highest scoring first. Calls must
+// not run concurrently.
+func Analyze(files []string, opts Options) ([]PatternMatch, error) {
+	return engine.Analyze(files, opts)
+}